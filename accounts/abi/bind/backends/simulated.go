@@ -83,7 +83,13 @@ func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.Genesis
 		GasLimit: gasLimit,
 		Alloc:    alloc,
 	}
-	blockchain, _ := core.NewBlockChain(database, nil, nil, &genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	return newSimulatedBackend(database, &genesis, vm.Config{})
+}
+
+// newSimulatedBackend creates a backend whose blockchain starts at the genesis and
+// executes transactions with the vm config.
+func newSimulatedBackend(database ethdb.Database, genesis *core.Genesis, vmConfig vm.Config) *SimulatedBackend {
+	blockchain, _ := core.NewBlockChain(database, nil, nil, genesis, nil, ethash.NewFaker(), vmConfig, nil, nil)
 
 	backend := &SimulatedBackend{
 		database:   database,
@@ -667,7 +673,9 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 	// about the transaction and calling mechanisms.
 	txContext := core.NewEVMTxContext(msg)
 	evmContext := core.NewEVMBlockContext(header, b.blockchain, nil)
-	vmEnv := vm.NewEVM(evmContext, txContext, stateDB, b.config, vm.Config{NoBaseFee: true})
+	vmConfig := *b.blockchain.GetVMConfig()
+	vmConfig.NoBaseFee = true
+	vmEnv := vm.NewEVM(evmContext, txContext, stateDB, b.config, vmConfig)
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.ApplyMessage(vmEnv, msg, gasPool)
@@ -695,10 +703,11 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	}
 	// Include tx in chain
 	blocks, receipts := core.GenerateChain(b.config, block, ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
+		vmConfig := *b.blockchain.GetVMConfig()
 		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTxWithChain(b.blockchain, tx)
+			block.AddTxWithChainAndVMConfig(b.blockchain, tx, vmConfig)
 		}
-		block.AddTxWithChain(b.blockchain, tx)
+		block.AddTxWithChainAndVMConfig(b.blockchain, tx, vmConfig)
 	})
 	stateDB, _ := b.blockchain.State()
 
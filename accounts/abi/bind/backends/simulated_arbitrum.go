@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/wasm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// StylusConfig configures how a simulated backend runs Stylus programs
+type StylusConfig struct {
+	// NewProcessingHook creates the hook executing the transactions and calls,
+	// which defaults to the DefaultTxProcessor
+	NewProcessingHook func(evm *vm.EVM) vm.TxProcessingHook
+
+	// Decompress expands compressed programs. Without it, the code of programs
+	// must be the plain wasm after the Stylus prefix.
+	Decompress func(compressed []byte, dictionary byte) ([]byte, error)
+}
+
+// NewSimulatedStylusBackend creates a simulated backend running an Arbitrum chain,
+// which accepts deploying Stylus programs and executes them with the hook of the
+// config. Deployed programs are activated as part of the transaction deploying
+// them, as are the programs of the alloc.
+// Like other simulated backends, it uses chainID 1337.
+func NewSimulatedStylusBackend(alloc core.GenesisAlloc, gasLimit uint64, config StylusConfig) *SimulatedBackend {
+	return NewSimulatedStylusBackendWithDatabase(rawdb.NewMemoryDatabase(), alloc, gasLimit, config)
+}
+
+// NewSimulatedStylusBackendWithDatabase creates a Stylus simulated backend on the given database
+func NewSimulatedStylusBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64, config StylusConfig) *SimulatedBackend {
+	chainConfig := *params.AllEthashProtocolChanges
+	chainConfig.ArbitrumChainParams = params.ArbitrumDevTestParams()
+	genesis := core.Genesis{
+		Config:   &chainConfig,
+		GasLimit: gasLimit,
+		Alloc:    alloc,
+	}
+	// Arbitrum chains only start from a genesis already in the database
+	genesis.MustCommit(database)
+	for _, account := range alloc {
+		if !state.IsStylusProgram(account.Code) {
+			continue
+		}
+		moduleHash, module, err := stylusModule(account.Code, config.Decompress)
+		if err != nil {
+			panic(fmt.Errorf("failed to activate genesis program: %w", err))
+		}
+		rawdb.WriteActivation(database, moduleHash, nil, module, 0)
+		rawdb.WriteActivationReference(database, moduleHash)
+	}
+
+	vmConfig := vm.Config{
+		NewProcessingHook: func(evm *vm.EVM) vm.TxProcessingHook {
+			var hook vm.TxProcessingHook
+			if config.NewProcessingHook != nil {
+				hook = config.NewProcessingHook(evm)
+			} else {
+				hook = vm.NewDefaultTxProcessor(evm)
+			}
+			return &stylusActivator{
+				TxProcessingHook: hook,
+				evm:              evm,
+				decompress:       config.Decompress,
+				deployed:         make(map[common.Address]struct{}),
+			}
+		},
+		NewWasmExecutor: func(evm *vm.EVM) vm.WasmExecutor {
+			executor := vm.NewReferenceWasmExecutor()
+			executor.Decompress = config.Decompress
+			return executor
+		},
+	}
+	return newSimulatedBackend(database, &genesis, vmConfig)
+}
+
+// stylusModule returns the wasm module of a program along with its hash
+func stylusModule(code []byte, decompress func([]byte, byte) ([]byte, error)) (common.Hash, []byte, error) {
+	module, dictionary, err := state.StripStylusPrefix(code)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	if !wasm.IsModule(module) {
+		if decompress == nil {
+			return common.Hash{}, nil, vm.ErrStylusCompressed
+		}
+		if module, err = decompress(module, dictionary); err != nil {
+			return common.Hash{}, nil, err
+		}
+	}
+	return crypto.Keccak256Hash(module), module, nil
+}
+
+// stylusActivator wraps a processing hook, activating the programs deployed by
+// successful transactions
+type stylusActivator struct {
+	vm.TxProcessingHook
+	evm        *vm.EVM
+	decompress func([]byte, byte) ([]byte, error)
+	deployed   map[common.Address]struct{} // the accounts which had no code when their frame began
+}
+
+func (a *stylusActivator) PushContract(contract *vm.Contract) {
+	if a.evm.StateDB.GetCodeSize(contract.Address()) == 0 {
+		a.deployed[contract.Address()] = struct{}{}
+	}
+	a.TxProcessingHook.PushContract(contract)
+}
+
+func (a *stylusActivator) EndTxHook(totalGasUsed uint64, evmSuccess bool) {
+	a.TxProcessingHook.EndTxHook(totalGasUsed, evmSuccess)
+	for address := range a.deployed {
+		code := a.evm.StateDB.GetCode(address)
+		if !state.IsStylusProgram(code) {
+			continue
+		}
+		moduleHash, module, err := stylusModule(code, a.decompress)
+		if err != nil {
+			// leave the program inactive, executing it will fail the same way
+			continue
+		}
+		a.evm.StateDB.ActivateWasm(moduleHash, nil, module, 0)
+	}
+	a.deployed = make(map[common.Address]struct{})
+}
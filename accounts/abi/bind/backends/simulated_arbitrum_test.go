@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/wasm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stylusStorageProgram stores the word it's called with in the slot, and returns
+// what the slot holds.
+func stylusStorageProgram(slot byte) []byte {
+	b := &wasm.Builder{}
+	readArgs := b.Import("vm_hooks", "read_args", wasm.FuncType{Params: []wasm.ValueType{wasm.I32}})
+	writeResult := b.Import("vm_hooks", "write_result", wasm.FuncType{Params: []wasm.ValueType{wasm.I32, wasm.I32}})
+	store := b.Import("vm_hooks", "storage_store_bytes32", wasm.FuncType{Params: []wasm.ValueType{wasm.I32, wasm.I32}})
+	load := b.Import("vm_hooks", "storage_load_bytes32", wasm.FuncType{Params: []wasm.ValueType{wasm.I32, wasm.I32}})
+	b.Memory(1)
+	b.Data(64, common.Hash{31: slot}.Bytes())
+	entrypoint := b.Function(wasm.FuncType{Params: []wasm.ValueType{wasm.I32}, Results: []wasm.ValueType{wasm.I32}}, nil, []byte{
+		0x41, 0, 0x10, byte(readArgs), // read_args(0)
+		0x20, 0, 0x41, 32, 0x46, 0x04, 0x40, // if len == 32
+		0x41, 0xc0, 0, 0x41, 0, 0x10, byte(store), // storage_store_bytes32(64, 0)
+		0x0b,
+		0x41, 0xc0, 0, 0x41, 0xe0, 0, 0x10, byte(load), // storage_load_bytes32(64, 96)
+		0x41, 0xe0, 0, 0x41, 32, 0x10, byte(writeResult), // write_result(96, 32)
+		0x41, 0, // success
+		0x0b,
+	})
+	b.Export("user_entrypoint", wasm.ExternFunc, entrypoint)
+	b.Export("memory", wasm.ExternMemory, 0)
+	return append(state.NewStylusPrefix(0), b.Bytes()...)
+}
+
+// deployCode is init code returning the code
+func deployCode(code []byte) []byte {
+	size := len(code)
+	init := []byte{
+		0x61, byte(size >> 8), byte(size), // PUSH2 size
+		0x80,       // DUP1
+		0x60, 0x0c, // PUSH1 12, the size of the init code
+		0x60, 0x00, // PUSH1 0
+		0x39,       // CODECOPY
+		0x60, 0x00, // PUSH1 0
+		0xf3, // RETURN
+	}
+	return append(init, code...)
+}
+
+func TestSimulatedStylusBackend(t *testing.T) {
+	ctx := context.Background()
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	program, genesisCode := stylusStorageProgram(0), stylusStorageProgram(1)
+	genesisProgram := common.HexToAddress("0x5701")
+	database := rawdb.NewMemoryDatabase()
+	sim := NewSimulatedStylusBackendWithDatabase(database, core.GenesisAlloc{
+		testAddr:       {Balance: big.NewInt(10000000000000000)},
+		genesisProgram: {Code: genesisCode, Balance: new(big.Int)},
+	}, 10000000, StylusConfig{})
+	defer sim.Close()
+
+	// deploy the program
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	gasPrice := new(big.Int).Add(head.BaseFee, big.NewInt(1))
+	signer := types.LatestSigner(sim.config)
+	tx := types.MustSignNewTx(testKey, signer, &types.LegacyTx{
+		Nonce:    0,
+		Gas:      3000000,
+		GasPrice: gasPrice,
+		Data:     deployCode(program),
+	})
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("deployment failed: %v", err)
+	}
+	deployed := receipt.ContractAddress
+	if code, _ := sim.CodeAt(ctx, deployed, nil); !state.IsStylusProgram(code) {
+		t.Fatalf("deployed code %x", code)
+	}
+
+	// the programs are activated
+	for _, code := range [][]byte{program, genesisCode} {
+		module, _, _ := state.StripStylusPrefix(code)
+		if rawdb.ReadActivatedModule(database, crypto.Keccak256Hash(module)) == nil {
+			t.Errorf("program %x wasn't activated", crypto.Keccak256(code))
+		}
+	}
+
+	// store through a transaction, and read through calls
+	value := common.HexToHash("0x2a")
+	tx = types.MustSignNewTx(testKey, signer, &types.LegacyTx{
+		Nonce:    1,
+		To:       &deployed,
+		Gas:      1000000,
+		GasPrice: gasPrice,
+		Data:     value[:],
+	})
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to store: %v", err)
+	}
+	sim.Commit()
+	for _, address := range []common.Address{deployed, genesisProgram} {
+		stored, err := sim.CallContract(ctx, ethereum.CallMsg{From: testAddr, To: &address}, nil)
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		want := common.Hash{}
+		if address == deployed {
+			want = value
+		}
+		if common.BytesToHash(stored) != want {
+			t.Errorf("program at %v returned %x, want %v", address, stored, want)
+		}
+	}
+	if gas, err := sim.EstimateGas(ctx, ethereum.CallMsg{From: testAddr, To: &deployed, Data: value[:]}); err != nil || gas == 0 {
+		t.Errorf("failed to estimate gas: %d, %v", gas, err)
+	}
+}
+
+func TestSimulatedStylusBackendHook(t *testing.T) {
+	ctx := context.Background()
+	program := common.HexToAddress("0x5701")
+	var executed int
+	sim := NewSimulatedStylusBackend(core.GenesisAlloc{
+		program: {Code: stylusStorageProgram(0), Balance: new(big.Int)},
+	}, 10000000, StylusConfig{
+		NewProcessingHook: func(evm *vm.EVM) vm.TxProcessingHook {
+			return countingHook{vm.NewDefaultTxProcessor(evm), &executed}
+		},
+	})
+	defer sim.Close()
+
+	if _, err := sim.CallContract(ctx, ethereum.CallMsg{To: &program}, nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if executed != 1 {
+		t.Errorf("the hook executed %d programs", executed)
+	}
+}
+
+type countingHook struct {
+	vm.DefaultTxProcessor
+	executed *int
+}
+
+func (h countingHook) ExecuteWASM(scope *vm.ScopeContext, input []byte, interpreter *vm.EVMInterpreter) ([]byte, error) {
+	*h.executed++
+	return h.DefaultTxProcessor.ExecuteWASM(scope, input, interpreter)
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/ethereum/go-ethereum/arbitrum_types"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/tracers"
-	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
@@ -37,6 +36,7 @@ type APIBackend struct {
 
 	fallbackClient types.FallbackClient
 	sync           SyncProgressBackend
+	stateCache     *RecreatedStateCache
 }
 
 type timeoutFallbackClient struct {
@@ -86,8 +86,8 @@ type SyncProgressBackend interface {
 	FinalizedBlockNumber(ctx context.Context) (uint64, error)
 }
 
-func createRegisterAPIBackend(backend *Backend, sync SyncProgressBackend, filterConfig filters.Config, fallbackClientUrl string, fallbackClientTimeout time.Duration) (*filters.FilterSystem, error) {
-	fallbackClient, err := CreateFallbackClient(fallbackClientUrl, fallbackClientTimeout)
+func createRegisterAPIBackend(backend *Backend, sync SyncProgressBackend, filterConfig filters.Config, fallbackClientConfig *FallbackClientConfig) (*filters.FilterSystem, error) {
+	fallbackClient, err := CreateFallbackClientWithConfig(fallbackClientConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +96,9 @@ func createRegisterAPIBackend(backend *Backend, sync SyncProgressBackend, filter
 		fallbackClient: fallbackClient,
 		sync:           sync,
 	}
+	if backend.config.RecreatedStateCacheSize > 0 {
+		backend.apiBackend.stateCache = NewRecreatedStateCache(backend.arb.BlockChain(), backend.chainDb, backend.config.RecreatedStateCacheSize)
+	}
 	filterSystem := filters.NewFilterSystem(backend.apiBackend, filterConfig)
 	backend.stack.RegisterAPIs(backend.apiBackend.GetAPIs(filterSystem))
 	return filterSystem, nil
@@ -118,6 +121,20 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 		Public:    true,
 	})
 
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbRetryableAPI(a),
+		Public:    true,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service:   NewArbFeeAPI(a),
+		Public:    true,
+	})
+
 	apis = append(apis, rpc.API{
 		Namespace: "net",
 		Version:   "1.0",
@@ -126,10 +143,17 @@ func (a *APIBackend) GetAPIs(filterSystem *filters.FilterSystem) []rpc.API {
 	})
 
 	apis = append(apis, rpc.API{
-		Namespace: "txpool",
+		Namespace: "debug",
 		Version:   "1.0",
-		Service:   NewPublicTxPoolAPI(),
-		Public:    true,
+		Service:   NewArbDebugAPI(a),
+		Public:    false,
+	})
+
+	apis = append(apis, rpc.API{
+		Namespace: "arbdebug",
+		Version:   "1.0",
+		Service:   NewArbCostAPI(a),
+		Public:    false,
 	})
 
 	apis = append(apis, tracers.APIs(a)...)
@@ -179,116 +203,28 @@ func (a *APIBackend) FeeHistory(
 	newestBlock rpc.BlockNumber,
 	rewardPercentiles []float64,
 ) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
-	if core.GetArbOSSpeedLimitPerSecond == nil {
-		return nil, nil, nil, nil, errors.New("ArbOS not installed")
-	}
-
-	nitroGenesis := rpc.BlockNumber(a.ChainConfig().ArbitrumChainParams.GenesisBlockNum)
-	newestBlock, latestBlock := a.blockChain().ClipToPostNitroGenesis(newestBlock)
-
-	maxFeeHistory := a.b.config.FeeHistoryMaxBlockCount
-	if blocks > maxFeeHistory {
-		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
-		blocks = maxFeeHistory
+	history, err := a.arbFeeHistory(ctx, blocks, newestBlock, nil)
+	if err != nil {
+		return common.Big0, nil, nil, nil, err
 	}
-	if blocks < 1 {
+	if history == nil {
 		// returning with no data and no error means there are no retrievable blocks
 		return common.Big0, nil, nil, nil, nil
 	}
 
-	// don't attempt to include blocks before genesis
-	if rpc.BlockNumber(blocks) > (newestBlock - nitroGenesis) {
-		blocks = uint64(newestBlock - nitroGenesis + 1)
-	}
-	oldestBlock := uint64(newestBlock) + 1 - blocks
-
 	// inform that tipping has no effect on inclusion
-	rewards := make([][]*big.Int, blocks)
-	zeros := make([]*big.Int, len(rewardPercentiles))
-	for i := range zeros {
-		zeros[i] = common.Big0
-	}
-	for i := range rewards {
-		rewards[i] = zeros
-	}
-	if len(rewardPercentiles) == 0 {
-		rewards = nil
-	}
-
-	// use the most recent average compute rate for all blocks
-	// note: while we could query this value for each block, it'd be prohibitively expensive
-	state, _, err := a.StateAndHeaderByNumber(ctx, newestBlock)
-	if err != nil {
-		return common.Big0, nil, nil, nil, err
-	}
-	speedLimit, err := core.GetArbOSSpeedLimitPerSecond(state)
-	if err != nil {
-		return common.Big0, nil, nil, nil, err
-	}
-
-	gasUsed := make([]float64, blocks)
-	basefees := make([]*big.Int, blocks+1) // the RPC semantics are to predict the future value
-
-	// collect the basefees
-	baseFeeLookup := newestBlock + 1
-	if newestBlock == latestBlock {
-		baseFeeLookup = newestBlock
-	}
-	var prevTimestamp uint64
-	var timeSinceLastTimeChange uint64
-	var currentTimestampGasUsed uint64
-	if rpc.BlockNumber(oldestBlock) > nitroGenesis {
-		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(oldestBlock-1))
-		if err != nil {
-			return common.Big0, nil, nil, nil, err
-		}
-		prevTimestamp = header.Time
-	}
-	for block := oldestBlock; block <= uint64(baseFeeLookup); block++ {
-		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(block))
-		if err != nil {
-			return common.Big0, nil, nil, nil, err
-		}
-		basefees[block-oldestBlock] = header.BaseFee
-
-		if block > uint64(newestBlock) {
-			break
-		}
-
-		if header.Time > prevTimestamp {
-			timeSinceLastTimeChange = header.Time - prevTimestamp
-			currentTimestampGasUsed = 0
+	var rewards [][]*big.Int
+	if len(rewardPercentiles) > 0 {
+		rewards = make([][]*big.Int, len(history.l2GasUsedRatio))
+		zeros := make([]*big.Int, len(rewardPercentiles))
+		for i := range zeros {
+			zeros[i] = common.Big0
 		}
-
-		receipts := a.blockChain().GetReceiptsByHash(header.ReceiptHash)
-		for _, receipt := range receipts {
-			if receipt.GasUsed > receipt.GasUsedForL1 {
-				currentTimestampGasUsed += receipt.GasUsed - receipt.GasUsedForL1
-			}
+		for i := range rewards {
+			rewards[i] = zeros
 		}
-
-		prevTimestamp = header.Time
-
-		// In vanilla geth, this RPC returns the gasUsed ratio so a client can know how the basefee will change
-		// To emulate this, we translate the compute rate into something similar, centered at an analogous 0.5
-		var fullnessAnalogue float64
-		if timeSinceLastTimeChange > 0 {
-			fullnessAnalogue = float64(currentTimestampGasUsed) / float64(speedLimit) / float64(timeSinceLastTimeChange) / 2.0
-			if fullnessAnalogue > 1.0 {
-				fullnessAnalogue = 1.0
-			}
-		} else {
-			// We haven't looked far enough back to know the last timestamp change,
-			// so treat this block as full.
-			fullnessAnalogue = 1.0
-		}
-		gasUsed[block-oldestBlock] = fullnessAnalogue
-	}
-	if newestBlock == latestBlock {
-		basefees[blocks] = basefees[blocks-1] // guess the basefee won't change
 	}
-
-	return big.NewInt(int64(oldestBlock)), rewards, basefees, gasUsed, nil
+	return new(big.Int).SetUint64(history.oldestBlock), rewards, history.baseFees, history.l2GasUsedRatio, nil
 }
 
 func (a *APIBackend) ChainDb() ethdb.Database {
@@ -319,6 +255,14 @@ func (a *APIBackend) UnprotectedAllowed() bool {
 	return a.b.config.TxAllowUnprotected
 }
 
+func (a *APIBackend) ConditionalTxMaxSlots() int {
+	return a.b.config.ConditionalTxMaxSlots
+}
+
+func (a *APIBackend) BundleMaxTxs() int {
+	return a.b.config.BundleMaxTxs
+}
+
 // Blockchain API
 func (a *APIBackend) SetHead(number uint64) {
 	panic("not implemented") // TODO: Implement
@@ -415,40 +359,59 @@ func (a *APIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.
 	return nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
-func (a *APIBackend) stateAndHeaderFromHeader(ctx context.Context, header *types.Header, err error) (*state.StateDB, *types.Header, error) {
+func (a *APIBackend) stateAndHeaderFromHeader(ctx context.Context, header *types.Header, err error) (*state.StateDB, *types.Header, ethapi.StateReleaseFunc, error) {
 	if err != nil {
-		return nil, header, err
+		return nil, header, nil, err
 	}
 	if header == nil {
-		return nil, nil, errors.New("header not found")
+		return nil, nil, nil, errors.New("header not found")
 	}
 	if !a.blockChain().Config().IsArbitrumNitro(header.Number) {
-		return nil, header, types.ErrUseFallback
+		return nil, header, nil, types.ErrUseFallback
+	}
+	statedb, release, err := a.stateFor(ctx, header)
+	if err != nil {
+		return nil, header, nil, err
 	}
+	return statedb, header, release, nil
+}
+
+// stateFor returns the state of the block, recreating it if it isn't available
+// on disk. The state stays valid until the returned function is called.
+func (a *APIBackend) stateFor(ctx context.Context, header *types.Header) (*state.StateDB, ethapi.StateReleaseFunc, error) {
 	bc := a.blockChain()
+	if a.stateCache != nil {
+		if statedb, err := bc.StateAt(header.Root); err == nil {
+			return statedb, ethapi.NoopStateRelease, nil
+		}
+		statedb, release, err := a.stateCache.StateFor(ctx, header, a.b.config.MaxRecreateStateDepth)
+		if err != nil {
+			return nil, nil, err
+		}
+		return statedb, ethapi.StateReleaseFunc(release), nil
+	}
 	stateFor := func(header *types.Header) (*state.StateDB, error) {
 		return bc.StateAt(header.Root)
 	}
-	state, lastHeader, err := FindLastAvailableState(ctx, bc, stateFor, header, nil, a.b.config.MaxRecreateStateDepth)
+	statedb, lastHeader, err := FindLastAvailableState(ctx, bc, stateFor, header, nil, a.b.config.MaxRecreateStateDepth)
 	if err != nil {
 		return nil, nil, err
 	}
-	if lastHeader == header {
-		return state, header, nil
-	}
-	state, err = AdvanceStateUpToBlock(ctx, bc, state, header, lastHeader, nil)
-	if err != nil {
-		return nil, nil, err
+	if lastHeader != header {
+		statedb, err = AdvanceStateUpToBlock(ctx, bc, statedb, header, lastHeader, nil)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	return state, header, err
+	return statedb, ethapi.NoopStateRelease, nil
 }
 
-func (a *APIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+func (a *APIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, ethapi.StateReleaseFunc, error) {
 	header, err := a.HeaderByNumber(ctx, number)
 	return a.stateAndHeaderFromHeader(ctx, header, err)
 }
 
-func (a *APIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+func (a *APIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, ethapi.StateReleaseFunc, error) {
 	header, err := a.HeaderByNumberOrHash(ctx, blockNrOrHash)
 	return a.stateAndHeaderFromHeader(ctx, header, err)
 }
@@ -510,19 +473,30 @@ func (a *APIBackend) SendConditionalTx(ctx context.Context, signedTx *types.Tran
 	return a.b.EnqueueL2Message(ctx, signedTx, options)
 }
 
+func (a *APIBackend) SendConditionalBundle(ctx context.Context, signedTxs types.Transactions, options *arbitrum_types.ConditionalOptions) error {
+	return a.b.EnqueueL2Bundle(ctx, signedTxs, options)
+}
+
 func (a *APIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
 	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(a.b.chainDb, txHash)
 	return tx, blockHash, blockNumber, index, nil
 }
 
 func (a *APIBackend) GetPoolTransactions() (types.Transactions, error) {
-	// Arbitrum doesn't have a pool
-	return types.Transactions{}, nil
+	// Arbitrum doesn't have a pool, serve the transactions submitted through this node
+	pending, queued := a.b.submittedTxs.Content()
+	var txs types.Transactions
+	for _, accountTxs := range pending {
+		txs = append(txs, accountTxs...)
+	}
+	for _, accountTxs := range queued {
+		txs = append(txs, accountTxs...)
+	}
+	return txs, nil
 }
 
 func (a *APIBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction {
-	// Arbitrum doesn't have a pool
-	return nil
+	return a.b.submittedTxs.Get(txHash)
 }
 
 func (a *APIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
@@ -530,19 +504,19 @@ func (a *APIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uin
 	if err != nil {
 		return 0, err
 	}
-	return stateDB.GetNonce(addr), nil
+	return a.b.submittedTxs.Nonce(addr, stateDB.GetNonce(addr)), nil
 }
 
 func (a *APIBackend) Stats() (pending int, queued int) {
-	panic("not implemented") // TODO: Implement
+	return a.b.submittedTxs.Stats()
 }
 
 func (a *APIBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
-	panic("not implemented") // TODO: Implement
+	return a.b.submittedTxs.Content()
 }
 
 func (a *APIBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
-	panic("not implemented") // TODO: Implement
+	return a.b.submittedTxs.ContentFrom(addr)
 }
 
 func (a *APIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
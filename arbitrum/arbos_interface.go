@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -13,3 +15,17 @@ type ArbInterface interface {
 	BlockChain() *core.BlockChain
 	ArbNode() interface{}
 }
+
+// BundlePublisher is implemented by ArbInterfaces able to publish several
+// transactions as a unit, sequenced back-to-back or not at all.
+type BundlePublisher interface {
+	PublishBundle(ctx context.Context, txs types.Transactions, options *arbitrum_types.ConditionalOptions) error
+}
+
+// StylusProgramReader is implemented by ArbInterfaces able to read the activation
+// of Stylus programs from the ArbOS state.
+type StylusProgramReader interface {
+	// StylusProgramModuleHash returns the module hash of the program with the given
+	// code hash, or false if it isn't activated.
+	StylusProgramModuleHash(statedb *state.StateDB, codeHash common.Hash) (common.Hash, bool, error)
+}
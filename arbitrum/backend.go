@@ -21,12 +21,17 @@ type Backend struct {
 	config     *Config
 	chainDb    ethdb.Database
 
-	txFeed event.Feed
-	scope  event.SubscriptionScope
+	txFeed       event.Feed
+	scope        event.SubscriptionScope
+	submittedTxs *submittedTxPool
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	retryableIndexer *core.ChainIndexer // Retryable index operating during block imports, nil unless enabled
+
+	recordingDb *RecordingDatabase
+
 	shutdownTracker *shutdowncheck.ShutdownTracker
 
 	chanTxs      chan *types.Transaction
@@ -51,8 +56,14 @@ func NewBackend(stack *node.Node, config *Config, chainDb ethdb.Database, publis
 		chanNewBlock: make(chan struct{}, 1),
 	}
 
+	backend.submittedTxs = newSubmittedTxPool(backend.arb.BlockChain(), config.SubmittedTxLifetime)
+	backend.recordingDb = NewRecordingDatabase(&config.RecordingDatabase, chainDb, backend.arb.BlockChain())
 	backend.bloomIndexer.Start(backend.arb.BlockChain())
-	filterSystem, err := createRegisterAPIBackend(backend, sync, filterConfig, config.ClassicRedirect, config.ClassicRedirectTimeout)
+	if config.RetryableIndex {
+		backend.retryableIndexer = newRetryableIndexer(chainDb, backend.arb.BlockChain().Config())
+		backend.retryableIndexer.Start(backend.arb.BlockChain())
+	}
+	filterSystem, err := createRegisterAPIBackend(backend, sync, filterConfig, config.FallbackClientConfig())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -67,8 +78,35 @@ func (b *Backend) ChainDb() ethdb.Database {
 	return b.chainDb
 }
 
+// RecordingDatabase returns the recording database of the node, to be shared
+// by everything recording state so their states and wasm recompiler are too
+func (b *Backend) RecordingDatabase() *RecordingDatabase {
+	return b.recordingDb
+}
+
 func (b *Backend) EnqueueL2Message(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
-	return b.arb.PublishTransaction(ctx, tx, options)
+	if err := b.arb.PublishTransaction(ctx, tx, options); err != nil {
+		return err
+	}
+	b.trackSubmitted(types.Transactions{tx})
+	return nil
+}
+
+func (b *Backend) EnqueueL2Bundle(ctx context.Context, txs types.Transactions, options *arbitrum_types.ConditionalOptions) error {
+	publisher, ok := b.arb.(BundlePublisher)
+	if !ok {
+		return arbitrum_types.NewRejectedError("transaction bundles aren't supported by this node")
+	}
+	if err := publisher.PublishBundle(ctx, txs, options); err != nil {
+		return err
+	}
+	b.trackSubmitted(txs)
+	return nil
+}
+
+// trackSubmitted serves the published transactions from the txpool API until they're included
+func (b *Backend) trackSubmitted(txs types.Transactions) {
+	b.submittedTxs.add(txs)
 }
 
 func (b *Backend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
@@ -86,6 +124,7 @@ func (b *Backend) ArbInterface() ArbInterface {
 // TODO: this is used when registering backend as lifecycle in stack
 func (b *Backend) Start() error {
 	b.startBloomHandlers(b.config.BloomBitsBlocks)
+	b.submittedTxs.start()
 	b.shutdownTracker.MarkStartup()
 	b.shutdownTracker.Start()
 
@@ -95,7 +134,14 @@ func (b *Backend) Start() error {
 func (b *Backend) Stop() error {
 	b.scope.Close()
 	b.bloomIndexer.Close()
+	b.submittedTxs.stop()
+	if b.retryableIndexer != nil {
+		b.retryableIndexer.Close()
+	}
 	b.shutdownTracker.Stop()
+	if closer, ok := b.apiBackend.fallbackClient.(interface{ Close() }); ok {
+		closer.Close()
+	}
 	b.chainDb.Close()
 	close(b.chanClose)
 	return nil
@@ -0,0 +1,135 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// testArbInterface is an ArbInterface recording the transactions it's asked to publish
+type testArbInterface struct {
+	bc        *core.BlockChain
+	published types.Transactions
+}
+
+func (a *testArbInterface) PublishTransaction(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	a.published = append(a.published, tx)
+	return nil
+}
+
+func (a *testArbInterface) BlockChain() *core.BlockChain {
+	return a.bc
+}
+
+func (a *testArbInterface) ArbNode() interface{} {
+	return nil
+}
+
+// testChain is a chain of blocks transferring from testAddress, whose states
+// past genesis only live in the memory of the blockchain
+type testChain struct {
+	db     ethdb.Database
+	bc     *core.BlockChain
+	blocks []*types.Block
+	signer types.Signer
+}
+
+func newTestChainConfig() *params.ChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.ArbitrumChainParams = params.ArbitrumDevTestParams()
+	return &config
+}
+
+// newTestChain creates a chain of n blocks, each with a transfer from testAddress
+// followed by the transactions gen adds, if any.
+func newTestChain(t *testing.T, n int, gen func(int, *core.BlockGen)) *testChain {
+	t.Helper()
+	return newTestChainWithCacheConfig(t, nil, n, gen)
+}
+
+// newTestChainWithCacheConfig is newTestChain with the given cache config
+func newTestChainWithCacheConfig(t *testing.T, cacheConfig *core.CacheConfig, n int, gen func(int, *core.BlockGen)) *testChain {
+	t.Helper()
+	return newTestChainWith(t, newTestChainConfig(), ethash.NewFaker(), cacheConfig, n, gen)
+}
+
+// newRewardlessTestChain is newTestChain past the merge, whose blocks carry no
+// rewards like Arbitrum blocks, so that they can be executed without an engine
+func newRewardlessTestChain(t *testing.T, n int, gen func(int, *core.BlockGen)) *testChain {
+	t.Helper()
+	config := newTestChainConfig()
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	return newTestChainWith(t, config, beacon.NewFaker(), nil, n, gen)
+}
+
+// newTestChainWith is newTestChain with the given chain config, engine and cache config
+func newTestChainWith(t *testing.T, config *params.ChainConfig, engine consensus.Engine, cacheConfig *core.CacheConfig, n int, gen func(int, *core.BlockGen)) *testChain {
+	t.Helper()
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		gspec = &core.Genesis{
+			Config:  config,
+			Alloc:   core.GenesisAlloc{testAddress: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, n, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(testAddress), common.Address{0x01}, big.NewInt(1), params.TxGas, block.BaseFee(), nil), signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+		if gen != nil {
+			gen(i, block)
+		}
+	})
+	// Arbitrum chains only start from a genesis already in the database
+	gspec.MustCommit(db)
+	bc, err := core.NewBlockChain(db, cacheConfig, nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(bc.Stop)
+	if i, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	return &testChain{db: db, bc: bc, blocks: blocks, signer: signer}
+}
+
+// apiBackend creates an APIBackend over the chain with the given config
+func (c *testChain) apiBackend(config Config) *APIBackend {
+	return c.apiBackendWith(&testArbInterface{bc: c.bc}, config)
+}
+
+// apiBackendWith creates an APIBackend over the chain with the given ArbInterface and config
+func (c *testChain) apiBackendWith(arb ArbInterface, config Config) *APIBackend {
+	backend := &Backend{
+		arb:          arb,
+		config:       &config,
+		chainDb:      c.db,
+		submittedTxs: newSubmittedTxPool(c.bc, config.SubmittedTxLifetime),
+		recordingDb:  NewRecordingDatabase(&config.RecordingDatabase, c.db, c.bc),
+	}
+	backend.apiBackend = &APIBackend{b: backend}
+	return backend.apiBackend
+}
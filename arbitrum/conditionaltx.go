@@ -3,6 +3,7 @@ package arbitrum
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/arbitrum_types"
 	"github.com/ethereum/go-ethereum/common"
@@ -31,23 +32,91 @@ func (s *ArbTransactionAPI) SendRawTransactionConditional(ctx context.Context, i
 }
 
 func SubmitConditionalTransaction(ctx context.Context, b *APIBackend, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) (common.Hash, error) {
+	if err := checkSubmittedTransaction(b, tx); err != nil {
+		return common.Hash{}, err
+	}
+	if options != nil {
+		if err := options.CheckLimits(b.ConditionalTxMaxSlots()); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	if err := b.SendConditionalTx(ctx, tx, options); err != nil {
+		return common.Hash{}, err
+	}
+	if err := logSubmittedTransaction(b, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SendBundleConditional submits the transactions as a unit, which is sequenced
+// back-to-back in the given order if the options hold, or rejected as a whole.
+func (s *ArbTransactionAPI) SendBundleConditional(ctx context.Context, inputs []hexutil.Bytes, options *arbitrum_types.ConditionalOptions) ([]common.Hash, error) {
+	txs := make(types.Transactions, 0, len(inputs))
+	for i, input := range inputs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(input); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d of bundle: %w", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return SubmitConditionalBundle(ctx, s.b, txs, options)
+}
+
+func SubmitConditionalBundle(ctx context.Context, b *APIBackend, txs types.Transactions, options *arbitrum_types.ConditionalOptions) ([]common.Hash, error) {
+	if len(txs) == 0 {
+		return nil, arbitrum_types.NewRejectedError("empty transaction bundle")
+	}
+	if maxTxs := b.BundleMaxTxs(); maxTxs > 0 && len(txs) > maxTxs {
+		return nil, arbitrum_types.NewLimitExceededError(fmt.Sprintf("bundle has %d transactions, exceeding the limit of %d", len(txs), maxTxs))
+	}
+	hashes := make([]common.Hash, 0, len(txs))
+	seen := make(map[common.Hash]struct{}, len(txs))
+	for i, tx := range txs {
+		if err := checkSubmittedTransaction(b, tx); err != nil {
+			return nil, arbitrum_types.WrapOptionsCheckError(err, fmt.Sprintf("transaction %d of bundle", i))
+		}
+		if _, ok := seen[tx.Hash()]; ok {
+			return nil, arbitrum_types.NewRejectedError(fmt.Sprintf("duplicate transaction %v in bundle", tx.Hash()))
+		}
+		seen[tx.Hash()] = struct{}{}
+		hashes = append(hashes, tx.Hash())
+	}
+	if options != nil {
+		if err := options.CheckLimits(b.ConditionalTxMaxSlots()); err != nil {
+			return nil, err
+		}
+	}
+	if err := b.SendConditionalBundle(ctx, txs, options); err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if err := logSubmittedTransaction(b, tx); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+func checkSubmittedTransaction(b *APIBackend, tx *types.Transaction) error {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := ethapi.CheckTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
-		return common.Hash{}, err
+		return err
 	}
 	if !b.UnprotectedAllowed() && !tx.Protected() {
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
-		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
-	}
-	if err := b.SendConditionalTx(ctx, tx, options); err != nil {
-		return common.Hash{}, err
+		return errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
+	return nil
+}
+
+func logSubmittedTransaction(b *APIBackend, tx *types.Transaction) error {
 	// Print a log with full tx details for manual investigations and interventions
 	signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number, b.CurrentBlock().Time)
 	from, err := types.Sender(signer, tx)
 	if err != nil {
-		return common.Hash{}, err
+		return err
 	}
 
 	if tx.To() == nil {
@@ -56,7 +125,7 @@ func SubmitConditionalTransaction(ctx context.Context, b *APIBackend, tx *types.
 	} else {
 		log.Info("Submitted transaction", "hash", tx.Hash().Hex(), "from", from, "nonce", tx.Nonce(), "recipient", tx.To(), "value", tx.Value())
 	}
-	return tx.Hash(), nil
+	return nil
 }
 
 func SendConditionalTransactionRPC(ctx context.Context, rpc *rpc.Client, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
@@ -66,3 +135,17 @@ func SendConditionalTransactionRPC(ctx context.Context, rpc *rpc.Client, tx *typ
 	}
 	return rpc.CallContext(ctx, nil, "eth_sendRawTransactionConditional", hexutil.Encode(data), options)
 }
+
+func SendBundleConditionalRPC(ctx context.Context, rpc *rpc.Client, txs types.Transactions, options *arbitrum_types.ConditionalOptions) ([]common.Hash, error) {
+	inputs := make([]hexutil.Bytes, 0, len(txs))
+	for _, tx := range txs {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, data)
+	}
+	var hashes []common.Hash
+	err := rpc.CallContext(ctx, &hashes, "eth_sendBundleConditional", inputs, options)
+	return hashes, err
+}
@@ -0,0 +1,150 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testBundleArbInterface is a testArbInterface recording the bundles it's asked to publish
+type testBundleArbInterface struct {
+	testArbInterface
+	bundles []types.Transactions
+	err     error
+}
+
+func (a *testBundleArbInterface) PublishBundle(ctx context.Context, txs types.Transactions, options *arbitrum_types.ConditionalOptions) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.bundles = append(a.bundles, txs)
+	return nil
+}
+
+func checkErrorCode(t *testing.T, err error, want int) {
+	t.Helper()
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != want {
+		t.Errorf("have error %v, want code %d", err, want)
+	}
+}
+
+func TestSubmitConditionalBundle(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		chain  = newTestChain(t, 1, nil)
+		arb    = &testBundleArbInterface{testArbInterface: testArbInterface{bc: chain.bc}}
+		config = DefaultConfig
+	)
+	config.BundleMaxTxs = 2
+	config.ConditionalTxMaxSlots = 1
+	backend := chain.apiBackendWith(arb, config)
+	txs := types.Transactions{newSubmittedTestTx(t, chain, 1), newSubmittedTestTx(t, chain, 2), newSubmittedTestTx(t, chain, 3)}
+
+	// bundles are rejected as a whole before reaching the publisher
+	if _, err := SubmitConditionalBundle(ctx, backend, nil, nil); err == nil {
+		t.Error("submitted an empty bundle")
+	} else {
+		checkErrorCode(t, err, -32003)
+	}
+	if _, err := SubmitConditionalBundle(ctx, backend, txs, nil); err == nil {
+		t.Error("submitted a bundle exceeding the transaction limit")
+	} else {
+		checkErrorCode(t, err, -32005)
+	}
+	if _, err := SubmitConditionalBundle(ctx, backend, types.Transactions{txs[0], txs[0]}, nil); err == nil {
+		t.Error("submitted a bundle with a duplicate transaction")
+	} else {
+		checkErrorCode(t, err, -32003)
+	}
+	expensive, err := types.SignTx(types.NewTransaction(2, common.Address{0x02}, big.NewInt(1), params.TxGas, big.NewInt(params.Ether), nil), chain.signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SubmitConditionalBundle(ctx, backend, types.Transactions{txs[0], expensive}, nil); err == nil || !strings.Contains(err.Error(), "transaction 1 of bundle") {
+		t.Errorf("have %v, want the fee cap exceeded by transaction 1", err)
+	}
+	options := &arbitrum_types.ConditionalOptions{
+		KnownAccounts: map[common.Address]arbitrum_types.RootHashOrSlots{
+			testAddress: {SlotValue: map[common.Hash]common.Hash{{0x01}: {}, {0x02}: {}}},
+		},
+	}
+	if _, err := SubmitConditionalBundle(ctx, backend, txs[:2], options); err == nil {
+		t.Error("submitted a bundle with options exceeding the slot limit")
+	} else {
+		checkErrorCode(t, err, -32005)
+	}
+	if len(arb.bundles) != 0 {
+		t.Fatalf("published %d rejected bundles", len(arb.bundles))
+	}
+
+	// failing to publish doesn't serve the transactions
+	arb.err = errors.New("sequencer unavailable")
+	if _, err := SubmitConditionalBundle(ctx, backend, txs[:2], nil); !errors.Is(err, arb.err) {
+		t.Errorf("have %v, want the publisher's error", err)
+	}
+	if backend.b.submittedTxs.Get(txs[0].Hash()) != nil {
+		t.Error("transaction of an unpublished bundle is served")
+	}
+
+	// published bundles keep their order and are served until included
+	arb.err = nil
+	hashes, err := SubmitConditionalBundle(ctx, backend, txs[:2], nil)
+	if err != nil {
+		t.Fatalf("failed to submit bundle: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0] != txs[0].Hash() || hashes[1] != txs[1].Hash() {
+		t.Errorf("have hashes %v, want those of the bundle in order", hashes)
+	}
+	if len(arb.bundles) != 1 {
+		t.Fatalf("have %d published bundles, want 1", len(arb.bundles))
+	}
+	checkNonces(t, arb.bundles[0], 1, 2)
+	for _, tx := range txs[:2] {
+		if backend.b.submittedTxs.Get(tx.Hash()) == nil {
+			t.Errorf("transaction %v of the bundle isn't served", tx.Hash())
+		}
+	}
+}
+
+func TestSendBundleConditional(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(t, 1, nil)
+	)
+	tx := newSubmittedTestTx(t, chain, 1)
+	input, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nodes whose ArbInterface can't publish bundles reject them
+	api := NewArbTransactionAPI(chain.apiBackend(DefaultConfig))
+	if _, err := api.SendBundleConditional(ctx, []hexutil.Bytes{input}, nil); err == nil {
+		t.Error("submitted a bundle to a node not supporting them")
+	} else {
+		checkErrorCode(t, err, -32003)
+	}
+
+	arb := &testBundleArbInterface{testArbInterface: testArbInterface{bc: chain.bc}}
+	api = NewArbTransactionAPI(chain.apiBackendWith(arb, DefaultConfig))
+	if _, err := api.SendBundleConditional(ctx, []hexutil.Bytes{input, {0x01}}, nil); err == nil || !strings.Contains(err.Error(), "transaction 1 of bundle") {
+		t.Errorf("have %v, want transaction 1 invalid", err)
+	}
+	hashes, err := api.SendBundleConditional(ctx, []hexutil.Bytes{input}, nil)
+	if err != nil {
+		t.Fatalf("failed to send bundle: %v", err)
+	}
+	if len(hashes) != 1 || hashes[0] != tx.Hash() || len(arb.bundles) != 1 {
+		t.Errorf("have hashes %v and %d published bundles, want the transaction published", hashes, len(arb.bundles))
+	}
+}
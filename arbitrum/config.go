@@ -18,6 +18,14 @@ type Config struct {
 
 	TxAllowUnprotected bool `koanf:"tx-allow-unprotected"`
 
+	// ConditionalTxMaxSlots limits the state entries the options of a conditional tx may check
+	ConditionalTxMaxSlots int `koanf:"conditional-tx-max-slots"`
+	// BundleMaxTxs limits the number of transactions in a conditional bundle
+	BundleMaxTxs int `koanf:"bundle-max-txs"`
+
+	// SubmittedTxLifetime is how long submitted transactions are served by the txpool API unless included
+	SubmittedTxLifetime time.Duration `koanf:"submitted-tx-lifetime"`
+
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration `koanf:"evm-timeout"`
 
@@ -29,14 +37,27 @@ type Config struct {
 	FilterLogCacheSize int           `koanf:"filter-log-cache-size"`
 	FilterTimeout      time.Duration `koanf:"filter-timeout"`
 
+	// RetryableIndex enables indexing the transactions of each retryable ticket for arb_getRetryableHistory
+	RetryableIndex bool `koanf:"retryable-index"`
+
 	// FeeHistoryMaxBlockCount limits the number of historical blocks a fee history request may cover
 	FeeHistoryMaxBlockCount uint64 `koanf:"feehistory-max-block-count"`
 
 	ArbDebug ArbDebugConfig `koanf:"arbdebug"`
 
-	ClassicRedirect        string        `koanf:"classic-redirect"`
-	ClassicRedirectTimeout time.Duration `koanf:"classic-redirect-timeout"`
-	MaxRecreateStateDepth  int64         `koanf:"max-recreate-state-depth"`
+	ClassicRedirect                    string          `koanf:"classic-redirect"`
+	ClassicRedirectTimeout             time.Duration   `koanf:"classic-redirect-timeout"`
+	ClassicRedirectTimeouts            []time.Duration `koanf:"classic-redirect-timeouts"`
+	ClassicRedirectPolicy              string          `koanf:"classic-redirect-policy"`
+	ClassicRedirectHealthCheckInterval time.Duration   `koanf:"classic-redirect-health-check-interval"`
+	ClassicRedirectCacheSize           int             `koanf:"classic-redirect-cache-size"`
+	MaxRecreateStateDepth              int64           `koanf:"max-recreate-state-depth"`
+
+	// RecreatedStateCacheSize is the memory limit in MB of the states recreated for RPC requests
+	RecreatedStateCacheSize int `koanf:"recreated-state-cache-size"`
+
+	// RecordingDatabase configures the recording database shared by validation and debug_recordBlockWitness
+	RecordingDatabase RecordingDatabaseConfig `koanf:"recording-database"`
 }
 
 type ArbDebugConfig struct {
@@ -48,15 +69,25 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Uint64(prefix+".gas-cap", DefaultConfig.RPCGasCap, "cap on computation gas that can be used in eth_call/estimateGas (0=infinite)")
 	f.Float64(prefix+".tx-fee-cap", DefaultConfig.RPCTxFeeCap, "cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)")
 	f.Bool(prefix+".tx-allow-unprotected", DefaultConfig.TxAllowUnprotected, "allow transactions that aren't EIP-155 replay protected to be submitted over the RPC")
+	f.Int(prefix+".conditional-tx-max-slots", DefaultConfig.ConditionalTxMaxSlots, "maximum number of storage slots, storage roots and accounts the options of a conditional transaction may check (0=unlimited)")
+	f.Int(prefix+".bundle-max-txs", DefaultConfig.BundleMaxTxs, "maximum number of transactions in a conditional bundle (0=unlimited)")
+	f.Duration(prefix+".submitted-tx-lifetime", DefaultConfig.SubmittedTxLifetime, "how long transactions submitted through this node are served by the txpool API unless included (0=disabled)")
 	f.Duration(prefix+".evm-timeout", DefaultConfig.RPCEVMTimeout, "timeout used for eth_call (0=infinite)")
 	f.Uint64(prefix+".bloom-bits-blocks", DefaultConfig.BloomBitsBlocks, "number of blocks a single bloom bit section vector holds")
 	f.Uint64(prefix+".bloom-confirms", DefaultConfig.BloomConfirms, "number of confirmation blocks before a bloom section is considered final")
+	f.Bool(prefix+".retryable-index", DefaultConfig.RetryableIndex, "index the transactions submitting and redeeming each retryable ticket, serving arb_getRetryableHistory")
 	f.Uint64(prefix+".feehistory-max-block-count", DefaultConfig.FeeHistoryMaxBlockCount, "max number of blocks a fee history request may cover")
-	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "url to redirect classic requests, use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
+	f.String(prefix+".classic-redirect", DefaultConfig.ClassicRedirect, "comma separated urls to redirect classic requests to, use \"error:[CODE:]MESSAGE\" to return specified error instead of redirecting")
 	f.Duration(prefix+".classic-redirect-timeout", DefaultConfig.ClassicRedirectTimeout, "timeout for forwarded classic requests, where 0 = no timeout")
+	f.DurationSlice(prefix+".classic-redirect-timeouts", DefaultConfig.ClassicRedirectTimeouts, "timeouts for forwarded classic requests of each classic-redirect endpoint, in order, overriding classic-redirect-timeout")
+	f.String(prefix+".classic-redirect-policy", DefaultConfig.ClassicRedirectPolicy, "how classic requests are spread over the classic-redirect endpoints (\"priority\" or \"round-robin\")")
+	f.Duration(prefix+".classic-redirect-health-check-interval", DefaultConfig.ClassicRedirectHealthCheckInterval, "interval of the health probes of the classic-redirect endpoints (0 = disabled)")
+	f.Int(prefix+".classic-redirect-cache-size", DefaultConfig.ClassicRedirectCacheSize, "number of immutable classic block, receipt and transaction responses to cache (0 = disabled)")
 	f.Int(prefix+".filter-log-cache-size", DefaultConfig.FilterLogCacheSize, "log filter system maximum number of cached blocks")
 	f.Duration(prefix+".filter-timeout", DefaultConfig.FilterTimeout, "log filter system maximum time filters stay active")
 	f.Int64(prefix+".max-recreate-state-depth", DefaultConfig.MaxRecreateStateDepth, "maximum depth for recreating state, measured in l2 gas (0=don't recreate state, -1=infinite, -2=use default value for archive or non-archive node (whichever is configured))")
+	f.Int(prefix+".recreated-state-cache-size", DefaultConfig.RecreatedStateCacheSize, "memory limit in MB of the cache of states recreated for RPC requests, shared by concurrent requests (0=disabled)")
+	RecordingDatabaseConfigAddOptions(prefix+".recording-database", f)
 	arbDebug := DefaultConfig.ArbDebug
 	f.Uint64(prefix+".arbdebug.block-range-bound", arbDebug.BlockRangeBound, "bounds the number of blocks arbdebug calls may return")
 	f.Uint64(prefix+".arbdebug.timeout-queue-bound", arbDebug.TimeoutQueueBound, "bounds the length of timeout queues arbdebug calls may return")
@@ -70,19 +101,38 @@ const (
 )
 
 var DefaultConfig = Config{
-	RPCGasCap:               ethconfig.Defaults.RPCGasCap,   // 50,000,000
-	RPCTxFeeCap:             ethconfig.Defaults.RPCTxFeeCap, // 1 ether
-	TxAllowUnprotected:      true,
-	RPCEVMTimeout:           ethconfig.Defaults.RPCEVMTimeout, // 5 seconds
-	BloomBitsBlocks:         params.BloomBitsBlocks * 4,       // we generally have smaller blocks
-	BloomConfirms:           params.BloomConfirms,
-	FilterLogCacheSize:      32,
-	FilterTimeout:           5 * time.Minute,
-	FeeHistoryMaxBlockCount: 1024,
-	ClassicRedirect:         "",
-	MaxRecreateStateDepth:   UninitializedMaxRecreateStateDepth, // default value should be set for depending on node type (archive / non-archive)
+	RPCGasCap:                          ethconfig.Defaults.RPCGasCap,   // 50,000,000
+	RPCTxFeeCap:                        ethconfig.Defaults.RPCTxFeeCap, // 1 ether
+	TxAllowUnprotected:                 true,
+	ConditionalTxMaxSlots:              1000,
+	BundleMaxTxs:                       16,
+	SubmittedTxLifetime:                10 * time.Minute,
+	RPCEVMTimeout:                      ethconfig.Defaults.RPCEVMTimeout, // 5 seconds
+	BloomBitsBlocks:                    params.BloomBitsBlocks * 4,       // we generally have smaller blocks
+	BloomConfirms:                      params.BloomConfirms,
+	FilterLogCacheSize:                 32,
+	FilterTimeout:                      5 * time.Minute,
+	FeeHistoryMaxBlockCount:            1024,
+	ClassicRedirect:                    "",
+	ClassicRedirectPolicy:              FallbackPolicyPriority,
+	ClassicRedirectHealthCheckInterval: 0,
+	MaxRecreateStateDepth:              UninitializedMaxRecreateStateDepth, // default value should be set for depending on node type (archive / non-archive)
+	RecreatedStateCacheSize:            0,
+	RecordingDatabase:                  DefaultRecordingDatabaseConfig,
 	ArbDebug: ArbDebugConfig{
 		BlockRangeBound:   256,
 		TimeoutQueueBound: 512,
 	},
 }
+
+// FallbackClientConfig returns the configuration of the client classic requests are redirected to
+func (c *Config) FallbackClientConfig() *FallbackClientConfig {
+	return &FallbackClientConfig{
+		URLs:                splitFallbackURLs(c.ClassicRedirect),
+		Timeout:             c.ClassicRedirectTimeout,
+		Timeouts:            c.ClassicRedirectTimeouts,
+		Policy:              c.ClassicRedirectPolicy,
+		HealthCheckInterval: c.ClassicRedirectHealthCheckInterval,
+		CacheSize:           c.ClassicRedirectCacheSize,
+	}
+}
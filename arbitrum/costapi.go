@@ -0,0 +1,138 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ArbCostAPI offers the breakdown of what transactions paid for L1 and L2
+type ArbCostAPI struct {
+	b               *APIBackend
+	blockRangeBound uint64
+}
+
+func NewArbCostAPI(b *APIBackend) *ArbCostAPI {
+	return &ArbCostAPI{b, b.b.config.ArbDebug.BlockRangeBound}
+}
+
+// TxCosts is what a transaction paid for posting it to L1 and for executing it
+type TxCosts struct {
+	TxHash            common.Hash    `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64 `json:"transactionIndex"`
+	Type              hexutil.Uint64 `json:"type"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	GasUsedForL1      hexutil.Uint64 `json:"gasUsedForL1"`
+	GasUsedForL2      hexutil.Uint64 `json:"gasUsedForL2"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	L1Cost            *hexutil.Big   `json:"l1Cost"` // wei paid for the gas used for L1
+	L2Cost            *hexutil.Big   `json:"l2Cost"` // wei paid for the gas used for L2
+}
+
+// BlockCosts is what the transactions of a block paid for L1 and L2
+type BlockCosts struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	BaseFee      *hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedForL1 hexutil.Uint64 `json:"gasUsedForL1"`
+	GasUsedForL2 hexutil.Uint64 `json:"gasUsedForL2"`
+	L1Cost       *hexutil.Big   `json:"l1Cost"`
+	L2Cost       *hexutil.Big   `json:"l2Cost"`
+	Transactions []*TxCosts     `json:"transactions"`
+}
+
+// L1CostBreakdown returns, for each block from start to end inclusive, the gas each
+// transaction used for L1 and for L2 along with the wei paid for them, and the totals
+// of the block. The range may cover at most block-range-bound blocks, unless it's 0.
+func (api *ArbCostAPI) L1CostBreakdown(ctx context.Context, start, end rpc.BlockNumber) ([]*BlockCosts, error) {
+	first, err := api.b.blockNumberToUint(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	last, err := api.b.blockNumberToUint(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if last < first {
+		return nil, errors.New("start must not be after end")
+	}
+	if api.blockRangeBound > 0 && last-first >= api.blockRangeBound {
+		return nil, fmt.Errorf("block range of %d exceeds the bound of %d", last-first+1, api.blockRangeBound)
+	}
+	results := make([]*BlockCosts, 0, last-first+1)
+	for number := first; number <= last; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.b.blockChain().GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		costs, err := api.blockCosts(block)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, costs)
+	}
+	return results, nil
+}
+
+func (api *ArbCostAPI) blockCosts(block *types.Block) (*BlockCosts, error) {
+	txs := block.Transactions()
+	receipts := api.b.blockChain().GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts of block #%d not found", block.NumberU64())
+	}
+	var (
+		l1GasUsed, l2GasUsed uint64
+		l1Cost, l2Cost       = new(big.Int), new(big.Int)
+	)
+	result := &BlockCosts{
+		Number:       hexutil.Uint64(block.NumberU64()),
+		Hash:         block.Hash(),
+		BaseFee:      (*hexutil.Big)(block.BaseFee()),
+		Transactions: make([]*TxCosts, 0, len(txs)),
+	}
+	nitro := api.b.ChainConfig().IsArbitrumNitro(block.Number())
+	for i, tx := range txs {
+		receipt := receipts[i]
+		price := receipt.EffectiveGasPrice
+		if nitro {
+			price = block.BaseFee()
+		} else if legacy, ok := tx.GetInner().(*types.ArbitrumLegacyTxData); ok {
+			price = new(big.Int).SetUint64(legacy.EffectiveGasPrice)
+		}
+		if price == nil {
+			price = new(big.Int)
+		}
+		gasUsedForL2 := receipt.GasUsedForL2()
+		txL1Cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsedForL1), price)
+		txL2Cost := new(big.Int).Mul(new(big.Int).SetUint64(gasUsedForL2), price)
+		result.Transactions = append(result.Transactions, &TxCosts{
+			TxHash:            tx.Hash(),
+			TransactionIndex:  hexutil.Uint64(i),
+			Type:              hexutil.Uint64(tx.Type()),
+			GasUsed:           hexutil.Uint64(receipt.GasUsed),
+			GasUsedForL1:      hexutil.Uint64(receipt.GasUsedForL1),
+			GasUsedForL2:      hexutil.Uint64(gasUsedForL2),
+			EffectiveGasPrice: (*hexutil.Big)(price),
+			L1Cost:            (*hexutil.Big)(txL1Cost),
+			L2Cost:            (*hexutil.Big)(txL2Cost),
+		})
+		l1GasUsed += receipt.GasUsedForL1
+		l2GasUsed += gasUsedForL2
+		l1Cost.Add(l1Cost, txL1Cost)
+		l2Cost.Add(l2Cost, txL2Cost)
+	}
+	result.GasUsedForL1 = hexutil.Uint64(l1GasUsed)
+	result.GasUsedForL2 = hexutil.Uint64(l2GasUsed)
+	result.L1Cost = (*hexutil.Big)(l1Cost)
+	result.L2Cost = (*hexutil.Big)(l2Cost)
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestL1CostBreakdown(t *testing.T) {
+	ctx := context.Background()
+	chain := newTestChain(t, 3, nil)
+	// attribute gas to L1 in the second block
+	l1Block := chain.blocks[1]
+	receipts := rawdb.ReadRawReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64())
+	receipts[0].GasUsedForL1 = 5000
+	rawdb.WriteReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64(), receipts)
+	// and more gas to L1 than the transaction used in the third, leaving none for L2
+	l1Block = chain.blocks[2]
+	receipts = rawdb.ReadRawReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64())
+	receipts[0].GasUsedForL1 = 30000
+	rawdb.WriteReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64(), receipts)
+
+	// a bound of 0 doesn't limit the range
+	config := DefaultConfig
+	config.ArbDebug.BlockRangeBound = 0
+	costs, err := NewArbCostAPI(chain.apiBackend(config)).L1CostBreakdown(ctx, 1, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get costs: %v", err)
+	}
+	if len(costs) != 3 {
+		t.Fatalf("have costs of %d blocks, want 3", len(costs))
+	}
+	for i, block := range costs {
+		wantL1, wantL2 := uint64(0), params.TxGas
+		switch i {
+		case 1:
+			wantL1, wantL2 = 5000, params.TxGas-5000
+		case 2:
+			wantL1, wantL2 = 30000, 0
+		}
+		baseFee := chain.blocks[i].BaseFee()
+		if uint64(block.Number) != uint64(i+1) || block.Hash != chain.blocks[i].Hash() || len(block.Transactions) != 1 {
+			t.Fatalf("block %d: have costs %+v", i+1, block)
+		}
+		tx := block.Transactions[0]
+		if uint64(tx.GasUsedForL1) != wantL1 || uint64(tx.GasUsedForL2) != wantL2 || tx.TxHash != chain.blocks[i].Transactions()[0].Hash() {
+			t.Errorf("block %d: have tx costs %+v, want %d gas for L1 and %d for L2", i+1, tx, wantL1, wantL2)
+		}
+		wantL1Cost := new(big.Int).Mul(new(big.Int).SetUint64(wantL1), baseFee)
+		wantL2Cost := new(big.Int).Mul(new(big.Int).SetUint64(wantL2), baseFee)
+		if tx.EffectiveGasPrice.ToInt().Cmp(baseFee) != 0 || tx.L1Cost.ToInt().Cmp(wantL1Cost) != 0 || tx.L2Cost.ToInt().Cmp(wantL2Cost) != 0 {
+			t.Errorf("block %d: have tx paying %v and %v at %v, want %v and %v at the base fee", i+1, tx.L1Cost, tx.L2Cost, tx.EffectiveGasPrice, wantL1Cost, wantL2Cost)
+		}
+		if uint64(block.GasUsedForL1) != wantL1 || uint64(block.GasUsedForL2) != wantL2 || block.L1Cost.ToInt().Cmp(wantL1Cost) != 0 || block.L2Cost.ToInt().Cmp(wantL2Cost) != 0 {
+			t.Errorf("block %d: have totals %+v, want those of its transaction", i+1, block)
+		}
+	}
+
+	// a bound limits the number of blocks covered
+	config.ArbDebug.BlockRangeBound = 2
+	api := NewArbCostAPI(chain.apiBackend(config))
+	if _, err := api.L1CostBreakdown(ctx, 1, 3); err == nil {
+		t.Error("got costs of a range exceeding the bound")
+	}
+	if costs, err := api.L1CostBreakdown(ctx, 2, 3); err != nil || len(costs) != 2 {
+		t.Errorf("have %d blocks (%v), want 2", len(costs), err)
+	}
+	if _, err := api.L1CostBreakdown(ctx, 3, 2); err == nil {
+		t.Error("got costs of a reversed range")
+	}
+	if _, err := api.L1CostBreakdown(ctx, 4, 4); err == nil {
+		t.Error("got costs of a missing block")
+	}
+}
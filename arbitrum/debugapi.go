@@ -0,0 +1,210 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ArbDebugAPI offers Arbitrum specific debugging RPC methods
+type ArbDebugAPI struct {
+	b        *APIBackend
+	programs StylusProgramReader // nil unless the ArbInterface reads Stylus programs
+}
+
+func NewArbDebugAPI(b *APIBackend) *ArbDebugAPI {
+	programs, _ := b.b.arb.(StylusProgramReader)
+	return &ArbDebugAPI{b: b, programs: programs}
+}
+
+// StylusAsmInfo describes the asm stored locally for a single target
+type StylusAsmInfo struct {
+	Size            hexutil.Uint64  `json:"size"`
+	CompilerVersion *hexutil.Uint64 `json:"compilerVersion,omitempty"`
+}
+
+// StylusProgramInfo describes the code and activation of a potential Stylus program
+type StylusProgramInfo struct {
+	Address    common.Address                      `json:"address"`
+	CodeHash   common.Hash                         `json:"codeHash"`
+	CodeSize   hexutil.Uint64                      `json:"codeSize"`
+	IsStylus   bool                                `json:"isStylus"`
+	Dictionary *hexutil.Uint64                     `json:"dictionary,omitempty"`
+	Activated  *bool                               `json:"activated,omitempty"`
+	ModuleHash *common.Hash                        `json:"moduleHash,omitempty"`
+	ModuleSize *hexutil.Uint64                     `json:"moduleSize,omitempty"`
+	Referenced *bool                               `json:"referenced,omitempty"`
+	Asm        map[rawdb.WasmTarget]*StylusAsmInfo `json:"asm,omitempty"`
+}
+
+// GetStylusProgramInfo reports whether the code at the address is a Stylus program
+// and, if the ArbInterface reads Stylus programs, how it's activated and what's
+// stored for it locally.
+func (api *ArbDebugAPI) GetStylusProgramInfo(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*StylusProgramInfo, error) {
+	statedb, _, release, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	code := statedb.GetCode(address)
+	info := &StylusProgramInfo{
+		Address:  address,
+		CodeHash: statedb.GetCodeHash(address),
+		CodeSize: hexutil.Uint64(len(code)),
+		IsStylus: state.IsStylusProgram(code),
+	}
+	if !info.IsStylus {
+		return info, nil
+	}
+	_, dictionary, err := state.StripStylusPrefix(code)
+	if err != nil {
+		return nil, err
+	}
+	dict := hexutil.Uint64(dictionary)
+	info.Dictionary = &dict
+
+	if api.programs == nil {
+		return info, nil
+	}
+	moduleHash, activated, err := api.programs.StylusProgramModuleHash(statedb, info.CodeHash)
+	if err != nil {
+		return nil, err
+	}
+	info.Activated = &activated
+	if !activated {
+		return info, nil
+	}
+	info.ModuleHash = &moduleHash
+
+	// read the disk directly so that missing asm isn't recompiled
+	db := api.b.ChainDb()
+	moduleSize := hexutil.Uint64(len(rawdb.ReadActivatedModule(db, moduleHash)))
+	referenced := rawdb.HasActivationReference(db, moduleHash)
+	info.ModuleSize = &moduleSize
+	info.Referenced = &referenced
+	info.Asm = make(map[rawdb.WasmTarget]*StylusAsmInfo)
+	for _, target := range rawdb.WasmTargets() {
+		asm := rawdb.ReadActivatedAsm(db, target, moduleHash)
+		if len(asm) == 0 {
+			continue
+		}
+		asmInfo := &StylusAsmInfo{Size: hexutil.Uint64(len(asm))}
+		if version := rawdb.ReadActivationVersion(db, target, moduleHash); version != nil {
+			compilerVersion := hexutil.Uint64(version.Compiler)
+			asmInfo.CompilerVersion = &compilerVersion
+		}
+		info.Asm[target] = asmInfo
+	}
+	return info, nil
+}
+
+// TxBalanceAudit is the balance audit of a tx, or the reason it couldn't be audited
+type TxBalanceAudit struct {
+	TxHash common.Hash          `json:"txHash"`
+	Audit  *native.BalanceAudit `json:"audit,omitempty"`
+	Error  string               `json:"error,omitempty"`
+}
+
+// BlockBalanceAudit is the balance audit of every tx in a block
+type BlockBalanceAudit struct {
+	BlockNumber hexutil.Uint64    `json:"blockNumber"`
+	BlockHash   common.Hash       `json:"blockHash"`
+	Txs         []*TxBalanceAudit `json:"txs"`
+	Residual    *hexutil.Big      `json:"residual"`
+	Balanced    bool              `json:"balanced"`
+}
+
+// AuditBlockBalances replays a block with the balanceAuditTracer, reporting the
+// balance changes of each tx by transfer purpose along with any ETH minted or
+// burnt without being accounted for. The block is balanced when every tx could
+// be audited and no such residual remains.
+func (api *ArbDebugAPI) AuditBlockBalances(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockBalanceAudit, error) {
+	block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash.String())
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not auditable")
+	}
+	statedb, _, release, err := api.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(block.ParentHash(), false))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	var (
+		config   = api.b.ChainConfig()
+		is158    = config.IsEIP158(block.Number())
+		blockCtx = core.NewEVMBlockContext(block.Header(), api.b.blockChain(), nil)
+		signer   = types.MakeSigner(config, block.Number(), block.Time())
+		residual = new(big.Int)
+	)
+	audit := &BlockBalanceAudit{
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		Txs:         make([]*TxBalanceAudit, 0, len(block.Transactions())),
+		Balanced:    true,
+	}
+	for i, tx := range block.Transactions() {
+		msg, err := core.TransactionToMessage(tx, signer, block.BaseFee())
+		if err != nil {
+			return nil, fmt.Errorf("could not prepare tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		tracer := native.NewBalanceAuditTracer()
+		evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, config, vm.Config{Tracer: tracer, NoBaseFee: true})
+		statedb.SetTxContext(tx.Hash(), i)
+		if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit)); err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		statedb.Finalise(is158)
+
+		txAudit := &TxBalanceAudit{TxHash: tx.Hash()}
+		audit.Txs = append(audit.Txs, txAudit)
+		txAudit.Audit, err = tracer.Audit()
+		if err != nil {
+			txAudit.Error = err.Error()
+		} else if txAudit.Audit.Residual == nil {
+			txAudit.Error = "unexpected balance delta not tracked"
+		}
+		if txAudit.Error != "" {
+			audit.Balanced = false
+			continue
+		}
+		if txAudit.Audit.Residual.ToInt().Sign() != 0 {
+			audit.Balanced = false
+		}
+		residual.Add(residual, txAudit.Audit.Residual.ToInt())
+	}
+	audit.Residual = (*hexutil.Big)(residual)
+	return audit, nil
+}
+
+// RecordBlockWitness executes a block on top of a recording of its parent's state,
+// returning the versioned encoding of the witness needed to execute it offline.
+func (api *ArbDebugAPI) RecordBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash.String())
+	}
+	witness, err := api.b.b.RecordingDatabase().RecordBlockWitness(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeBlockWitness(witness)
+}
@@ -0,0 +1,149 @@
+package arbitrum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testStylusArbInterface is a testArbInterface reading Stylus activations from a map
+type testStylusArbInterface struct {
+	testArbInterface
+	modules map[common.Hash]common.Hash
+}
+
+func (a *testStylusArbInterface) StylusProgramModuleHash(statedb *state.StateDB, codeHash common.Hash) (common.Hash, bool, error) {
+	moduleHash, activated := a.modules[codeHash]
+	return moduleHash, activated, nil
+}
+
+func TestGetStylusProgramInfo(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		code       = append(state.NewStylusPrefix(1), 0x00, 0x61, 0x73, 0x6d)
+		program    = crypto.CreateAddress(testAddress, 1)
+		moduleHash = common.HexToHash("0x01")
+		latest     = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	// deploys the program with initcode returning it
+	initcode := append([]byte{0x60, byte(len(code)), 0x80, 0x60, 0x0b, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}, code...)
+	chain := newTestChain(t, 1, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewContractCreation(block.TxNonce(testAddress), new(big.Int), 100000, block.BaseFee(), initcode), types.LatestSigner(newTestChainConfig()), testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+	rawdb.WriteActivation(chain.db, moduleHash, map[rawdb.WasmTarget][]byte{rawdb.TargetWavm: {0xa, 0xb}}, []byte{0xc}, 2)
+
+	// the code is inspected without ArbOS
+	info, err := NewArbDebugAPI(chain.apiBackend(DefaultConfig)).GetStylusProgramInfo(ctx, program, latest)
+	if err != nil {
+		t.Fatalf("failed to get program info: %v", err)
+	}
+	if !info.IsStylus || info.Dictionary == nil || *info.Dictionary != 1 || info.CodeHash != crypto.Keccak256Hash(code) {
+		t.Fatalf("have program info %+v, want a stylus program with dictionary 1", info)
+	}
+	if info.Activated != nil {
+		t.Error("have activation without a program reader")
+	}
+	info, err = NewArbDebugAPI(chain.apiBackend(DefaultConfig)).GetStylusProgramInfo(ctx, testAddress, latest)
+	if err != nil {
+		t.Fatalf("failed to get account info: %v", err)
+	}
+	if info.IsStylus || info.Dictionary != nil {
+		t.Errorf("have account info %+v, want no stylus program", info)
+	}
+
+	// the activation and what's stored for it are read through the ArbInterface
+	arb := &testStylusArbInterface{testArbInterface: testArbInterface{bc: chain.bc}, modules: make(map[common.Hash]common.Hash)}
+	api := NewArbDebugAPI(chain.apiBackendWith(arb, DefaultConfig))
+	info, err = api.GetStylusProgramInfo(ctx, program, latest)
+	if err != nil {
+		t.Fatalf("failed to get program info: %v", err)
+	}
+	if info.Activated == nil || *info.Activated || info.ModuleHash != nil {
+		t.Errorf("have program info %+v, want inactive", info)
+	}
+	arb.modules[crypto.Keccak256Hash(code)] = moduleHash
+	info, err = api.GetStylusProgramInfo(ctx, program, latest)
+	if err != nil {
+		t.Fatalf("failed to get program info: %v", err)
+	}
+	if info.Activated == nil || !*info.Activated || info.ModuleHash == nil || *info.ModuleHash != moduleHash {
+		t.Fatalf("have program info %+v, want activated as module %v", info, moduleHash)
+	}
+	if info.ModuleSize == nil || *info.ModuleSize != 1 {
+		t.Errorf("have module size %v, want 1", info.ModuleSize)
+	}
+	if info.Referenced == nil {
+		t.Error("missing the module's reference")
+	}
+	asm := info.Asm[rawdb.TargetWavm]
+	if len(info.Asm) != 1 || asm == nil || asm.Size != 2 || asm.CompilerVersion == nil || *asm.CompilerVersion != 2 {
+		t.Errorf("have asm %+v, want 2 bytes of wavm by compiler 2", info.Asm)
+	}
+}
+
+func TestAuditBlockBalances(t *testing.T) {
+	// every block transfers 1 wei to 0x01 and adds a transfer of 2 wei to 0x02
+	chain := newTestChain(t, 2, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(testAddress), common.Address{0x02}, big.NewInt(2), params.TxGas, block.BaseFee(), nil), types.LatestSigner(newTestChainConfig()), testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+	block := chain.blocks[1]
+	audit, err := NewArbDebugAPI(chain.apiBackend(DefaultConfig)).AuditBlockBalances(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	if err != nil {
+		t.Fatalf("failed to audit block: %v", err)
+	}
+	if uint64(audit.BlockNumber) != block.NumberU64() || audit.BlockHash != block.Hash() || len(audit.Txs) != 2 {
+		t.Fatalf("have audit of block %d %v with %d txs, want block %d %v with 2", audit.BlockNumber, audit.BlockHash, len(audit.Txs), block.NumberU64(), block.Hash())
+	}
+	// the fees burnt are reported by the state transition, and the unexpected
+	// delta of each tx is its own rather than including the txs before it
+	burnt := new(big.Int).Mul(block.BaseFee(), new(big.Int).SetUint64(params.TxGas))
+	for i, txAudit := range audit.Txs {
+		to, value := common.Address{byte(i + 1)}, big.NewInt(int64(i+1))
+		if txAudit.TxHash != block.Transactions()[i].Hash() || txAudit.Error != "" || txAudit.Audit == nil {
+			t.Fatalf("tx %d: have audit %+v", i, txAudit)
+		}
+		purpose := txAudit.Audit.Purposes["value"]
+		if purpose == nil || purpose.Count != 1 || purpose.Moved.ToInt().Cmp(value) != 0 || purpose.Minted.ToInt().Sign() != 0 || purpose.Burned.ToInt().Sign() != 0 {
+			t.Errorf("tx %d: have value purpose %+v, want %v wei moved", i, purpose, value)
+		}
+		if delta := txAudit.Audit.Accounts[to]["value"]; delta == nil || delta.ToInt().Cmp(value) != 0 {
+			t.Errorf("tx %d: have %v credited to %v, want %v", i, delta, to, value)
+		}
+		if delta := txAudit.Audit.Accounts[testAddress]["value"]; delta == nil || delta.ToInt().Cmp(new(big.Int).Neg(value)) != 0 {
+			t.Errorf("tx %d: have %v debited from the sender, want %v", i, delta, value)
+		}
+		want := new(big.Int).Neg(burnt)
+		if txAudit.Audit.ExpectedDelta.ToInt().Cmp(want) != 0 {
+			t.Errorf("tx %d: have expected delta %v, want %v", i, txAudit.Audit.ExpectedDelta, want)
+		}
+		if txAudit.Audit.UnexpectedDelta == nil || txAudit.Audit.UnexpectedDelta.ToInt().Cmp(want) != 0 {
+			t.Errorf("tx %d: have unexpected delta %v, want %v", i, txAudit.Audit.UnexpectedDelta, want)
+		}
+		if txAudit.Audit.Residual == nil || txAudit.Audit.Residual.ToInt().Sign() != 0 {
+			t.Errorf("tx %d: have residual %v, want 0", i, txAudit.Audit.Residual)
+		}
+	}
+	if audit.Residual.ToInt().Sign() != 0 || !audit.Balanced {
+		t.Errorf("have block residual %v and balanced %v, want a balanced block", audit.Residual, audit.Balanced)
+	}
+	if _, err := NewArbDebugAPI(chain.apiBackend(DefaultConfig)).AuditBlockBalances(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Error("audited the genesis block")
+	}
+}
@@ -0,0 +1,302 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	FallbackPolicyPriority   = "priority"    // endpoints are tried in the configured order
+	FallbackPolicyRoundRobin = "round-robin" // requests are spread over the endpoints
+)
+
+var (
+	fallbackCacheHitCounter  = metrics.NewRegisteredCounter("arb/apibackend/fallback/cache/hit", nil)
+	fallbackCacheMissCounter = metrics.NewRegisteredCounter("arb/apibackend/fallback/cache/miss", nil)
+	fallbackFailoverCounter  = metrics.NewRegisteredCounter("arb/apibackend/fallback/failover", nil)
+)
+
+// FallbackClientConfig configures the endpoints requests for blocks before the
+// Nitro genesis are forwarded to.
+type FallbackClientConfig struct {
+	URLs                []string
+	Timeout             time.Duration   // timeout of the endpoints without their own (0 = no timeout)
+	Timeouts            []time.Duration // timeouts of the endpoints, in the order of the URLs
+	Policy              string          // FallbackPolicyPriority or FallbackPolicyRoundRobin
+	HealthCheckInterval time.Duration   // interval of the health probes (0 = disabled)
+	CacheSize           int             // number of immutable responses cached (0 = disabled)
+}
+
+type fallbackEndpoint struct {
+	url     string
+	client  *rpc.Client
+	timeout time.Duration
+	healthy atomic.Bool
+
+	requests metrics.Counter
+	failures metrics.Counter
+	latency  metrics.Timer
+	up       metrics.Gauge
+}
+
+func (e *fallbackEndpoint) setHealthy(healthy bool) {
+	if e.healthy.Swap(healthy) != healthy {
+		if healthy {
+			log.Info("Fallback endpoint is healthy", "url", e.url)
+		} else {
+			log.Warn("Fallback endpoint is unhealthy", "url", e.url)
+		}
+	}
+	if healthy {
+		e.up.Update(1)
+	} else {
+		e.up.Update(0)
+	}
+}
+
+func (e *fallbackEndpoint) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if e.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	e.requests.Inc(1)
+	start := time.Now()
+	err := e.client.CallContext(ctx, result, method, args...)
+	e.latency.UpdateSince(start)
+	return err
+}
+
+// multiFallbackClient forwards requests to several endpoints, failing over to
+// the next one when an endpoint can't be reached. Endpoints failing requests or
+// health probes are tried last until they recover.
+type multiFallbackClient struct {
+	endpoints  []*fallbackEndpoint
+	roundRobin bool
+	next       atomic.Uint64
+	cache      *lru.Cache[string, json.RawMessage]
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newMultiFallbackClient(config *FallbackClientConfig) (*multiFallbackClient, error) {
+	c := &multiFallbackClient{stop: make(chan struct{})}
+	switch config.Policy {
+	case "", FallbackPolicyPriority:
+	case FallbackPolicyRoundRobin:
+		c.roundRobin = true
+	default:
+		return nil, fmt.Errorf("unknown fallback policy %q", config.Policy)
+	}
+	if len(config.Timeouts) > len(config.URLs) {
+		return nil, fmt.Errorf("%d fallback timeouts configured for %d endpoints", len(config.Timeouts), len(config.URLs))
+	}
+	for i, url := range config.URLs {
+		client, err := rpc.Dial(url)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed creating fallback connection to %v: %w", url, err)
+		}
+		timeout := config.Timeout
+		if i < len(config.Timeouts) && config.Timeouts[i] != 0 {
+			timeout = config.Timeouts[i]
+		}
+		prefix := fmt.Sprintf("arb/apibackend/fallback/endpoint/%d/", i)
+		endpoint := &fallbackEndpoint{
+			url:      url,
+			client:   client,
+			timeout:  timeout,
+			requests: metrics.GetOrRegisterCounter(prefix+"requests", nil),
+			failures: metrics.GetOrRegisterCounter(prefix+"failures", nil),
+			latency:  metrics.GetOrRegisterTimer(prefix+"latency", nil),
+			up:       metrics.GetOrRegisterGauge(prefix+"up", nil),
+		}
+		endpoint.setHealthy(true)
+		c.endpoints = append(c.endpoints, endpoint)
+	}
+	if config.CacheSize > 0 {
+		c.cache = lru.NewCache[string, json.RawMessage](config.CacheSize)
+	}
+	if config.HealthCheckInterval > 0 {
+		go c.healthCheckLoop(config.HealthCheckInterval)
+	}
+	return c, nil
+}
+
+// order returns the endpoints in the order they should be tried, the healthy ones first
+func (c *multiFallbackClient) order() []*fallbackEndpoint {
+	ordered := make([]*fallbackEndpoint, 0, len(c.endpoints))
+	start := 0
+	if c.roundRobin {
+		start = int(c.next.Add(1) % uint64(len(c.endpoints)))
+	}
+	for _, healthy := range []bool{true, false} {
+		for i := range c.endpoints {
+			endpoint := c.endpoints[(start+i)%len(c.endpoints)]
+			if endpoint.healthy.Load() == healthy {
+				ordered = append(ordered, endpoint)
+			}
+		}
+	}
+	return ordered
+}
+
+func (c *multiFallbackClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	key := c.cacheKey(method, args)
+	if key != "" {
+		if cached, ok := c.cache.Get(key); ok {
+			fallbackCacheHitCounter.Inc(1)
+			return unmarshalFallbackResult(cached, result)
+		}
+		fallbackCacheMissCounter.Inc(1)
+	}
+	var raw json.RawMessage
+	var err error
+	for i, endpoint := range c.order() {
+		if i > 0 {
+			fallbackFailoverCounter.Inc(1)
+		}
+		err = endpoint.call(ctx, &raw, method, args...)
+		var rpcErr rpc.Error
+		if err == nil || errors.As(err, &rpcErr) {
+			// the endpoint answered, even if with an error
+			endpoint.setHealthy(true)
+			break
+		}
+		endpoint.failures.Inc(1)
+		if ctx.Err() != nil {
+			return err
+		}
+		log.Debug("Fallback request failed", "url", endpoint.url, "method", method, "err", err)
+		endpoint.setHealthy(false)
+	}
+	if err != nil {
+		return err
+	}
+	if key != "" && len(raw) > 0 && string(raw) != "null" {
+		c.cache.Add(key, raw)
+	}
+	return unmarshalFallbackResult(raw, result)
+}
+
+func unmarshalFallbackResult(raw json.RawMessage, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
+
+// immutableFallbackMethods are the methods whose responses for blocks before
+// the Nitro genesis never change, keyed by whether their first argument is a
+// block number which must then be explicit rather than a tag.
+var immutableFallbackMethods = map[string]bool{
+	"eth_getBlockByHash":                      false,
+	"eth_getBlockByNumber":                    true,
+	"eth_getTransactionByHash":                false,
+	"eth_getTransactionReceipt":               false,
+	"eth_getTransactionByBlockHashAndIndex":   false,
+	"eth_getTransactionByBlockNumberAndIndex": true,
+}
+
+// cacheKey returns the key the response of the request is cached under, or an
+// empty string if it's not cacheable.
+func (c *multiFallbackClient) cacheKey(method string, args []interface{}) string {
+	if c.cache == nil {
+		return ""
+	}
+	byNumber, ok := immutableFallbackMethods[method]
+	if !ok || len(args) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	if byNumber {
+		first, err := json.Marshal(args[0])
+		if err != nil || !strings.HasPrefix(string(first), `"0x`) {
+			return ""
+		}
+	}
+	return method + string(encoded)
+}
+
+func (c *multiFallbackClient) healthCheckLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, endpoint := range c.endpoints {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				var chainId string
+				err := endpoint.call(ctx, &chainId, "eth_chainId")
+				cancel()
+				if err != nil {
+					endpoint.failures.Inc(1)
+				}
+				endpoint.setHealthy(err == nil)
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close stops the health probes and closes the connections to the endpoints
+func (c *multiFallbackClient) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		for _, endpoint := range c.endpoints {
+			endpoint.client.Close()
+		}
+	})
+}
+
+// CreateFallbackClientWithConfig creates the client forwarding requests for
+// blocks before the Nitro genesis. A single URL of the form
+// "error:[CODE:]MESSAGE" sets the error returned instead.
+func CreateFallbackClientWithConfig(config *FallbackClientConfig) (types.FallbackClient, error) {
+	if len(config.URLs) == 0 {
+		return nil, nil
+	}
+	if len(config.URLs) == 1 && strings.HasPrefix(config.URLs[0], "error:") {
+		return CreateFallbackClient(config.URLs[0], config.Timeout)
+	}
+	for _, url := range config.URLs {
+		if strings.HasPrefix(url, "error:") {
+			return nil, errors.New("fallback error can't be combined with other endpoints")
+		}
+	}
+	if len(config.URLs) == 1 && len(config.Timeouts) == 0 && config.HealthCheckInterval == 0 && config.CacheSize == 0 {
+		return CreateFallbackClient(config.URLs[0], config.Timeout)
+	}
+	return newMultiFallbackClient(config)
+}
+
+// splitFallbackURLs splits a comma separated list of fallback endpoints
+func splitFallbackURLs(urls string) []string {
+	var split []string
+	if strings.HasPrefix(urls, "error:") {
+		return []string{urls}
+	}
+	for _, url := range strings.Split(urls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			split = append(split, url)
+		}
+	}
+	return split
+}
@@ -0,0 +1,239 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testFallbackService is the eth namespace of a classic endpoint, answering
+// with its name and counting the block requests it serves
+type testFallbackService struct {
+	name  string
+	calls atomic.Int32
+	down  atomic.Bool
+}
+
+func (s *testFallbackService) ChainId() (string, error) {
+	if s.down.Load() {
+		return "", errors.New("syncing")
+	}
+	return "0xa4b1", nil
+}
+
+func (s *testFallbackService) GetBlockByNumber(number string, full bool) map[string]string {
+	s.calls.Add(1)
+	return map[string]string{"endpoint": s.name, "number": number}
+}
+
+func (s *testFallbackService) GetBlockByHash(hash common.Hash, full bool) map[string]string {
+	s.calls.Add(1)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return map[string]string{"endpoint": s.name, "hash": hash.Hex()}
+}
+
+func (s *testFallbackService) GetTransactionReceipt(hash common.Hash) (map[string]string, error) {
+	s.calls.Add(1)
+	return nil, errors.New("receipt unavailable")
+}
+
+type testFallbackEndpoint struct {
+	*testFallbackService
+	server *httptest.Server
+}
+
+func newTestFallbackEndpoints(t *testing.T, names ...string) ([]*testFallbackEndpoint, []string) {
+	t.Helper()
+	var (
+		endpoints []*testFallbackEndpoint
+		urls      []string
+	)
+	for _, name := range names {
+		service := &testFallbackService{name: name}
+		rpcServer := rpc.NewServer()
+		if err := rpcServer.RegisterName("eth", service); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(rpcServer)
+		t.Cleanup(server.Close)
+		t.Cleanup(rpcServer.Stop)
+		endpoints = append(endpoints, &testFallbackEndpoint{service, server})
+		urls = append(urls, server.URL)
+	}
+	return endpoints, urls
+}
+
+func newTestFallbackClient(t *testing.T, config *FallbackClientConfig) *multiFallbackClient {
+	t.Helper()
+	client, err := newMultiFallbackClient(config)
+	if err != nil {
+		t.Fatalf("failed to create fallback client: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// checkFallbackEndpoint checks which endpoint answered a block request
+func checkFallbackEndpoint(t *testing.T, client *multiFallbackClient, want string) {
+	t.Helper()
+	var block map[string]string
+	if err := client.CallContext(context.Background(), &block, "eth_getBlockByNumber", "latest", false); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if block["endpoint"] != want {
+		t.Errorf("answered by %q, want %q", block["endpoint"], want)
+	}
+}
+
+func TestFallbackClientPriority(t *testing.T) {
+	endpoints, urls := newTestFallbackEndpoints(t, "a", "b")
+	client := newTestFallbackClient(t, &FallbackClientConfig{URLs: urls, Policy: FallbackPolicyPriority})
+
+	checkFallbackEndpoint(t, client, "a")
+	checkFallbackEndpoint(t, client, "a")
+
+	// unreachable endpoints are failed over and tried last
+	endpoints[0].server.Close()
+	checkFallbackEndpoint(t, client, "b")
+	if client.endpoints[0].healthy.Load() {
+		t.Error("unreachable endpoint is healthy")
+	}
+	order := client.order()
+	if order[0] != client.endpoints[1] || order[1] != client.endpoints[0] {
+		t.Error("unhealthy endpoint isn't tried last")
+	}
+	checkFallbackEndpoint(t, client, "b")
+
+	// endpoints answering with an error aren't failed over
+	var receipt map[string]string
+	if err := client.CallContext(context.Background(), &receipt, "eth_getTransactionReceipt", common.Hash{0x01}); err == nil {
+		t.Error("endpoint error wasn't returned")
+	}
+	if !client.endpoints[1].healthy.Load() {
+		t.Error("endpoint answering with an error is unhealthy")
+	}
+
+	endpoints[1].server.Close()
+	if err := client.CallContext(context.Background(), &receipt, "eth_getBlockByNumber", "latest", false); err == nil {
+		t.Error("request succeeded with every endpoint unreachable")
+	}
+}
+
+func TestFallbackClientRoundRobin(t *testing.T) {
+	endpoints, urls := newTestFallbackEndpoints(t, "a", "b", "c")
+	client := newTestFallbackClient(t, &FallbackClientConfig{URLs: urls, Policy: FallbackPolicyRoundRobin})
+
+	for i := 0; i < 6; i++ {
+		checkFallbackEndpoint(t, client, endpoints[(i+1)%3].name)
+	}
+	for _, endpoint := range endpoints {
+		if calls := endpoint.calls.Load(); calls != 2 {
+			t.Errorf("endpoint %s served %d requests, want 2", endpoint.name, calls)
+		}
+	}
+	// requests meant for an unreachable endpoint go to the next one
+	endpoints[1].server.Close()
+	checkFallbackEndpoint(t, client, "c")
+	checkFallbackEndpoint(t, client, "c")
+	checkFallbackEndpoint(t, client, "a")
+}
+
+func TestFallbackClientHealthCheck(t *testing.T) {
+	endpoints, urls := newTestFallbackEndpoints(t, "a", "b")
+	client := newTestFallbackClient(t, &FallbackClientConfig{URLs: urls, HealthCheckInterval: 10 * time.Millisecond})
+
+	waitHealthy := func(healthy bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); client.endpoints[0].healthy.Load() != healthy; {
+			if time.Now().After(deadline) {
+				t.Fatalf("endpoint didn't become healthy=%v", healthy)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	endpoints[0].down.Store(true)
+	waitHealthy(false)
+	checkFallbackEndpoint(t, client, "b")
+	endpoints[0].down.Store(false)
+	waitHealthy(true)
+	checkFallbackEndpoint(t, client, "a")
+}
+
+func TestFallbackClientCache(t *testing.T) {
+	endpoints, urls := newTestFallbackEndpoints(t, "a")
+	client := newTestFallbackClient(t, &FallbackClientConfig{URLs: urls, CacheSize: 16})
+	service := endpoints[0].testFallbackService
+
+	call := func(method string, args ...interface{}) {
+		t.Helper()
+		var result map[string]string
+		if err := client.CallContext(context.Background(), &result, method, args...); err != nil && method != "eth_getTransactionReceipt" {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+	}
+	for _, test := range []struct {
+		method string
+		args   []interface{}
+		cached bool
+	}{
+		{"eth_getBlockByNumber", []interface{}{"0x1", false}, true},
+		{"eth_getBlockByNumber", []interface{}{"latest", false}, false},
+		{"eth_getBlockByHash", []interface{}{common.Hash{0x01}, true}, true},
+		{"eth_getBlockByHash", []interface{}{common.Hash{}, true}, false},      // null responses
+		{"eth_getTransactionReceipt", []interface{}{common.Hash{0x01}}, false}, // errors
+	} {
+		before := service.calls.Load()
+		call(test.method, test.args...)
+		call(test.method, test.args...)
+		served := service.calls.Load() - before
+		if test.cached && served != 1 {
+			t.Errorf("%s%v: served %d times, want once", test.method, test.args, served)
+		}
+		if !test.cached && served != 2 {
+			t.Errorf("%s%v: served %d times, want uncached", test.method, test.args, served)
+		}
+	}
+	// only the responses of immutable methods are cached
+	for _, method := range []string{"eth_chainId", "eth_getBalance"} {
+		if key := client.cacheKey(method, []interface{}{common.Address{}, "0x1"}); key != "" {
+			t.Errorf("%s: cached under %q", method, key)
+		}
+	}
+	// the arguments are part of the key
+	if client.cacheKey("eth_getBlockByNumber", []interface{}{"0x1", false}) == client.cacheKey("eth_getBlockByNumber", []interface{}{"0x1", true}) {
+		t.Error("full and header-only blocks share a cache key")
+	}
+}
+
+func TestCreateFallbackClientWithConfig(t *testing.T) {
+	_, urls := newTestFallbackEndpoints(t, "a", "b")
+	for _, config := range []*FallbackClientConfig{
+		{URLs: []string{urls[0], "error:classic"}},
+		{URLs: urls, Policy: "random"},
+		{URLs: urls[:1], Timeouts: []time.Duration{time.Second, time.Second}},
+	} {
+		if _, err := CreateFallbackClientWithConfig(config); err == nil {
+			t.Errorf("created fallback client with invalid config %+v", config)
+		}
+	}
+	client, err := CreateFallbackClientWithConfig(&FallbackClientConfig{URLs: urls, Timeouts: []time.Duration{0, time.Second}, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("failed to create fallback client: %v", err)
+	}
+	multi, ok := client.(*multiFallbackClient)
+	if !ok {
+		t.Fatalf("have %T, want a client over both endpoints", client)
+	}
+	defer multi.Close()
+	if multi.endpoints[0].timeout != time.Minute || multi.endpoints[1].timeout != time.Second {
+		t.Errorf("have timeouts %v and %v, want the default and the endpoint's", multi.endpoints[0].timeout, multi.endpoints[1].timeout)
+	}
+}
@@ -0,0 +1,242 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errInvalidPercentile = errors.New("invalid L1 cost percentile")
+
+// arbFeeHistory is the fee history of a range of blocks, splitting the gas used
+// into the part paying for posting the transactions to L1 and the part executing them
+type arbFeeHistory struct {
+	oldestBlock       uint64
+	baseFees          []*big.Int // one more than the blocks, predicting the next basefee
+	l2GasUsedRatio    []float64
+	l1GasUsed         []uint64
+	l2GasUsed         []uint64
+	l1CostPercentiles [][]*big.Int
+}
+
+// arbFeeHistory collects the fee history of up to FeeHistoryMaxBlockCount blocks
+// ending with newestBlock, computing the percentiles of the L1 cost of the
+// transactions of each block. It returns nil if there are no retrievable blocks.
+func (a *APIBackend) arbFeeHistory(
+	ctx context.Context,
+	blocks uint64,
+	newestBlock rpc.BlockNumber,
+	l1CostPercentiles []float64,
+) (*arbFeeHistory, error) {
+	if core.GetArbOSSpeedLimitPerSecond == nil {
+		return nil, errors.New("ArbOS not installed")
+	}
+	for i, p := range l1CostPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("%w: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < l1CostPercentiles[i-1] {
+			return nil, fmt.Errorf("%w: #%d:%f > #%d:%f", errInvalidPercentile, i-1, l1CostPercentiles[i-1], i, p)
+		}
+	}
+
+	nitroGenesis := rpc.BlockNumber(a.ChainConfig().ArbitrumChainParams.GenesisBlockNum)
+	newestBlock, latestBlock := a.blockChain().ClipToPostNitroGenesis(newestBlock)
+
+	maxFeeHistory := a.b.config.FeeHistoryMaxBlockCount
+	if blocks > maxFeeHistory {
+		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
+		blocks = maxFeeHistory
+	}
+	if blocks < 1 {
+		return nil, nil
+	}
+
+	// don't attempt to include blocks before genesis
+	if rpc.BlockNumber(blocks) > (newestBlock - nitroGenesis) {
+		blocks = uint64(newestBlock - nitroGenesis + 1)
+	}
+	oldestBlock := uint64(newestBlock) + 1 - blocks
+
+	// use the most recent average compute rate for all blocks
+	// note: while we could query this value for each block, it'd be prohibitively expensive
+	state, _, release, err := a.StateAndHeaderByNumber(ctx, newestBlock)
+	if err != nil {
+		return nil, err
+	}
+	speedLimit, err := core.GetArbOSSpeedLimitPerSecond(state)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	history := &arbFeeHistory{
+		oldestBlock:    oldestBlock,
+		baseFees:       make([]*big.Int, blocks+1), // the RPC semantics are to predict the future value
+		l2GasUsedRatio: make([]float64, blocks),
+		l1GasUsed:      make([]uint64, blocks),
+		l2GasUsed:      make([]uint64, blocks),
+	}
+	if len(l1CostPercentiles) > 0 {
+		history.l1CostPercentiles = make([][]*big.Int, blocks)
+	}
+
+	// collect the basefees
+	baseFeeLookup := newestBlock + 1
+	if newestBlock == latestBlock {
+		baseFeeLookup = newestBlock
+	}
+	var prevTimestamp uint64
+	var timeSinceLastTimeChange uint64
+	var currentTimestampGasUsed uint64
+	if rpc.BlockNumber(oldestBlock) > nitroGenesis {
+		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(oldestBlock-1))
+		if err != nil {
+			return nil, err
+		}
+		prevTimestamp = header.Time
+	}
+	for block := oldestBlock; block <= uint64(baseFeeLookup); block++ {
+		header, err := a.HeaderByNumber(ctx, rpc.BlockNumber(block))
+		if err != nil {
+			return nil, err
+		}
+		i := block - oldestBlock
+		history.baseFees[i] = header.BaseFee
+
+		if block > uint64(newestBlock) {
+			break
+		}
+
+		if header.Time > prevTimestamp {
+			timeSinceLastTimeChange = header.Time - prevTimestamp
+			currentTimestampGasUsed = 0
+		}
+
+		receipts := a.blockChain().GetReceiptsByHash(header.Hash())
+		l1Costs := make([]*big.Int, 0, len(receipts))
+		for _, receipt := range receipts {
+			history.l1GasUsed[i] += receipt.GasUsedForL1
+			history.l2GasUsed[i] += receipt.GasUsedForL2()
+			if receipt.GasUsedForL1 > 0 && header.BaseFee != nil {
+				// the effective gas price of Arbitrum transactions is the basefee
+				l1Costs = append(l1Costs, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsedForL1), header.BaseFee))
+			}
+		}
+		currentTimestampGasUsed += history.l2GasUsed[i]
+		if history.l1CostPercentiles != nil {
+			history.l1CostPercentiles[i] = costPercentiles(l1Costs, l1CostPercentiles)
+		}
+
+		prevTimestamp = header.Time
+
+		// In vanilla geth, this RPC returns the gasUsed ratio so a client can know how the basefee will change
+		// To emulate this, we translate the compute rate into something similar, centered at an analogous 0.5
+		var fullnessAnalogue float64
+		if timeSinceLastTimeChange > 0 {
+			fullnessAnalogue = float64(currentTimestampGasUsed) / float64(speedLimit) / float64(timeSinceLastTimeChange) / 2.0
+			if fullnessAnalogue > 1.0 {
+				fullnessAnalogue = 1.0
+			}
+		} else {
+			// We haven't looked far enough back to know the last timestamp change,
+			// so treat this block as full.
+			fullnessAnalogue = 1.0
+		}
+		history.l2GasUsedRatio[i] = fullnessAnalogue
+	}
+	if newestBlock == latestBlock {
+		history.baseFees[blocks] = history.baseFees[blocks-1] // guess the basefee won't change
+	}
+	return history, nil
+}
+
+// costPercentiles returns the given percentiles of the costs, which are zero if there are none
+func costPercentiles(costs []*big.Int, percentiles []float64) []*big.Int {
+	result := make([]*big.Int, len(percentiles))
+	if len(costs) == 0 {
+		for i := range result {
+			result[i] = new(big.Int)
+		}
+		return result
+	}
+	sort.Slice(costs, func(i, j int) bool { return costs[i].Cmp(costs[j]) < 0 })
+	for i, p := range percentiles {
+		index := int(p / 100 * float64(len(costs)))
+		if index >= len(costs) {
+			index = len(costs) - 1
+		}
+		result[i] = costs[index]
+	}
+	return result
+}
+
+// ArbFeeAPI offers Arbitrum specific fee RPC methods
+type ArbFeeAPI struct {
+	b *APIBackend
+}
+
+func NewArbFeeAPI(b *APIBackend) *ArbFeeAPI {
+	return &ArbFeeAPI{b}
+}
+
+// ArbFeeHistoryResult is the fee history of a range of blocks, with the gas used
+// split into the parts paying for L1 and for L2
+type ArbFeeHistoryResult struct {
+	OldestBlock    *hexutil.Big     `json:"oldestBlock"`
+	BaseFee        []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	L2GasUsedRatio []float64        `json:"l2GasUsedRatio"`
+	L1GasUsed      []hexutil.Uint64 `json:"l1GasUsed"`
+	L2GasUsed      []hexutil.Uint64 `json:"l2GasUsed"`
+	// L1CostPercentiles are the percentiles of the wei paid for L1 by the
+	// transactions of each block which posted data to L1
+	L1CostPercentiles [][]*hexutil.Big `json:"l1CostPercentiles,omitempty"`
+}
+
+// FeeHistory returns the fee history of up to FeeHistoryMaxBlockCount blocks ending
+// with lastBlock, like eth_feeHistory, along with the gas each block used for L1 and
+// for L2 and the percentiles of the L1 costs of its transactions. The L2 gas used
+// ratio measures the execution congestion, regardless of the L1 costs.
+func (api *ArbFeeAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, l1CostPercentiles []float64) (*ArbFeeHistoryResult, error) {
+	history, err := api.b.arbFeeHistory(ctx, uint64(blockCount), lastBlock, l1CostPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &ArbFeeHistoryResult{
+		OldestBlock:    (*hexutil.Big)(new(big.Int)),
+		L2GasUsedRatio: []float64{},
+		L1GasUsed:      []hexutil.Uint64{},
+		L2GasUsed:      []hexutil.Uint64{},
+	}
+	if history == nil {
+		return result, nil
+	}
+	result.OldestBlock = (*hexutil.Big)(new(big.Int).SetUint64(history.oldestBlock))
+	result.L2GasUsedRatio = history.l2GasUsedRatio
+	result.BaseFee = make([]*hexutil.Big, len(history.baseFees))
+	for i, v := range history.baseFees {
+		result.BaseFee[i] = (*hexutil.Big)(v)
+	}
+	for i := range history.l1GasUsed {
+		result.L1GasUsed = append(result.L1GasUsed, hexutil.Uint64(history.l1GasUsed[i]))
+		result.L2GasUsed = append(result.L2GasUsed, hexutil.Uint64(history.l2GasUsed[i]))
+	}
+	if history.l1CostPercentiles != nil {
+		result.L1CostPercentiles = make([][]*hexutil.Big, len(history.l1CostPercentiles))
+		for i, costs := range history.l1CostPercentiles {
+			result.L1CostPercentiles[i] = make([]*hexutil.Big, len(costs))
+			for j, cost := range costs {
+				result.L1CostPercentiles[i][j] = (*hexutil.Big)(cost)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const testSpeedLimit = 7_000_000
+
+func TestArbFeeHistory(t *testing.T) {
+	getSpeedLimit := core.GetArbOSSpeedLimitPerSecond
+	core.GetArbOSSpeedLimitPerSecond = func(*state.StateDB) (uint64, error) { return testSpeedLimit, nil }
+	t.Cleanup(func() { core.GetArbOSSpeedLimitPerSecond = getSpeedLimit })
+
+	signer := types.LatestSigner(newTestChainConfig())
+	chain := newTestChain(t, 4, func(i int, block *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(testAddress), common.Address{0x02}, big.NewInt(1), params.TxGas, block.BaseFee(), nil), signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+	// attribute gas to L1 in the second block, more than its second transaction used
+	l1Block := chain.blocks[1]
+	receipts := rawdb.ReadRawReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64())
+	receipts[0].GasUsedForL1 = 5000
+	receipts[1].GasUsedForL1 = 30000
+	rawdb.WriteReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64(), receipts)
+
+	api := NewArbFeeAPI(chain.apiBackend(DefaultConfig))
+	history, err := api.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatalf("failed to get fee history: %v", err)
+	}
+	if history.OldestBlock.ToInt().Uint64() != 1 {
+		t.Errorf("have oldest block %v, want 1", history.OldestBlock)
+	}
+	if len(history.BaseFee) != 5 || history.BaseFee[4].ToInt().Cmp(history.BaseFee[3].ToInt()) != 0 {
+		t.Errorf("have basefees %v, want the latest one repeated", history.BaseFee)
+	}
+	baseFee := l1Block.BaseFee()
+	for i, block := range chain.blocks {
+		wantL1, wantL2 := uint64(0), 2*params.TxGas
+		wantCosts := []*big.Int{new(big.Int), new(big.Int), new(big.Int)}
+		if block == l1Block {
+			// the L2 gas of the transaction with more L1 gas than gas used doesn't wrap
+			wantL1, wantL2 = 35000, params.TxGas-5000
+			low, high := new(big.Int).Mul(big.NewInt(5000), baseFee), new(big.Int).Mul(big.NewInt(30000), baseFee)
+			wantCosts = []*big.Int{low, high, high}
+		}
+		if have := uint64(history.L1GasUsed[i]); have != wantL1 {
+			t.Errorf("block %d: have L1 gas used %d, want %d", i+1, have, wantL1)
+		}
+		if have := uint64(history.L2GasUsed[i]); have != wantL2 {
+			t.Errorf("block %d: have L2 gas used %d, want %d", i+1, have, wantL2)
+		}
+		if want := float64(wantL2) / testSpeedLimit / 10 / 2; history.L2GasUsedRatio[i] != want {
+			t.Errorf("block %d: have L2 gas used ratio %v, want %v", i+1, history.L2GasUsedRatio[i], want)
+		}
+		for j, cost := range history.L1CostPercentiles[i] {
+			if cost.ToInt().Cmp(wantCosts[j]) != 0 {
+				t.Errorf("block %d: have L1 cost percentile %d of %v, want %v", i+1, j, cost, wantCosts[j])
+			}
+		}
+	}
+
+	for _, percentiles := range [][]float64{{-1}, {101}, {50, 10}} {
+		if _, err := api.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, percentiles); !errors.Is(err, errInvalidPercentile) {
+			t.Errorf("percentiles %v: have error %v, want invalid percentile", percentiles, err)
+		}
+	}
+}
+
+// eth_feeHistory reports the gas used ratio of the blocks from their receipts,
+// leaving out the gas used for L1
+func TestEthFeeHistory(t *testing.T) {
+	getSpeedLimit := core.GetArbOSSpeedLimitPerSecond
+	core.GetArbOSSpeedLimitPerSecond = func(*state.StateDB) (uint64, error) { return testSpeedLimit, nil }
+	t.Cleanup(func() { core.GetArbOSSpeedLimitPerSecond = getSpeedLimit })
+
+	chain := newTestChain(t, 4, nil)
+	// charge the transaction of the second block more L1 gas than it used
+	l1Block := chain.blocks[1]
+	receipts := rawdb.ReadRawReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64())
+	receipts[0].GasUsedForL1 = 30000
+	rawdb.WriteReceipts(chain.db, l1Block.Hash(), l1Block.NumberU64(), receipts)
+
+	api := ethapi.NewEthereumAPI(chain.apiBackend(DefaultConfig))
+	history, err := api.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, []float64{50})
+	if err != nil {
+		t.Fatalf("failed to get fee history: %v", err)
+	}
+	if history.OldestBlock.ToInt().Uint64() != 1 || len(history.GasUsedRatio) != 4 || len(history.BaseFee) != 5 {
+		t.Fatalf("have history %+v, want 4 blocks from block 1", history)
+	}
+	for i, block := range chain.blocks {
+		want := float64(params.TxGas) / testSpeedLimit / 10 / 2
+		if block == l1Block {
+			want = 0
+		}
+		if history.GasUsedRatio[i] != want {
+			t.Errorf("block %d: have gas used ratio %v, want %v", i+1, history.GasUsedRatio[i], want)
+		}
+		if len(history.Reward[i]) != 1 || history.Reward[i][0].ToInt().Sign() != 0 {
+			t.Errorf("block %d: have rewards %v, want none as tips have no effect", i+1, history.Reward[i])
+		}
+	}
+}
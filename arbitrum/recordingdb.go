@@ -54,12 +54,18 @@ func (db *RecordingKV) Get(key []byte) ([]byte, error) {
 		// Retrieving code
 		copy(hash[:], key[len(rawdb.CodePrefix):])
 		res, err = db.diskDb.Get(key)
-	} else if ok, _ := rawdb.IsActivatedAsmKey(key); ok {
-		// Arbitrum: the asm is non-consensus
+	} else if ok, _, _ := rawdb.IsActivatedAsmKey(key); ok {
+		// Arbitrum: the asm of every target is non-consensus
 		return db.diskDb.Get(key)
 	} else if ok, _ := rawdb.IsActivatedModuleKey(key); ok {
 		// Arbitrum: the module is non-consensus (only its hash is)
 		return db.diskDb.Get(key)
+	} else if ok, _, _ := rawdb.IsActivatedVersionKey(key); ok {
+		// Arbitrum: activation metadata is non-consensus
+		return db.diskDb.Get(key)
+	} else if ok, _ := rawdb.IsActivatedReferenceKey(key); ok {
+		// Arbitrum: activation metadata is non-consensus
+		return db.diskDb.Get(key)
 	} else {
 		err = fmt.Errorf("recording KV attempted to access non-hash key %v", hex.EncodeToString(key))
 	}
@@ -184,6 +190,7 @@ type RecordingDatabase struct {
 	bc         *core.BlockChain
 	mutex      sync.Mutex // protects StateFor and Dereference
 	references int64
+	recompiler state.WasmRecompiler
 }
 
 func NewRecordingDatabase(config *RecordingDatabaseConfig, ethdb ethdb.Database, blockchain *core.BlockChain) *RecordingDatabase {
@@ -194,6 +201,17 @@ func NewRecordingDatabase(config *RecordingDatabaseConfig, ethdb ethdb.Database,
 	}
 }
 
+// SetWasmRecompiler installs the hook rebuilding missing or corrupted asm,
+// both for the database and for the recordings prepared from it. The rebuilt
+// asm is only written to the database if persist is set, which it must not be
+// if the database was opened read-only. Recordings never write it.
+func (r *RecordingDatabase) SetWasmRecompiler(recompiler state.WasmRecompiler, persist bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.recompiler = recompiler
+	r.db.SetWasmRecompiler(recompiler, persist)
+}
+
 // Normal geth state.New + Reference is not atomic vs Dereference. This one is.
 // This function does not recreate a state
 func (r *RecordingDatabase) StateFor(header *types.Header) (*state.StateDB, error) {
@@ -269,6 +287,9 @@ func (r *RecordingDatabase) PrepareRecording(ctx context.Context, lastBlockHeade
 	recordingKeyValue := newRecordingKV(r.db.TrieDB(), r.db.DiskDB())
 
 	recordingStateDatabase := state.NewDatabase(rawdb.NewDatabase(recordingKeyValue))
+	r.mutex.Lock()
+	recordingStateDatabase.SetWasmRecompiler(r.recompiler, false)
+	r.mutex.Unlock()
 	var prevRoot common.Hash
 	if lastBlockHeader != nil {
 		prevRoot = lastBlockHeader.Root
@@ -291,13 +312,11 @@ func (r *RecordingDatabase) PrepareRecording(ctx context.Context, lastBlockHeade
 
 func (r *RecordingDatabase) PreimagesFromRecording(chainContextIf core.ChainContext, recordingDb *RecordingKV) (map[common.Hash][]byte, error) {
 	entries := recordingDb.GetRecordedEntries()
-	recordingChainContext, ok := chainContextIf.(*RecordingChainContext)
-	if (recordingChainContext == nil) || (!ok) {
-		return nil, errors.New("recordingChainContext invalid")
+	headers, err := r.headersFromRecording(chainContextIf)
+	if err != nil {
+		return nil, err
 	}
-
-	for i := recordingChainContext.GetMinBlockNumberAccessed(); i <= recordingChainContext.initialBlockNumber; i++ {
-		header := r.bc.GetHeaderByNumber(i)
+	for _, header := range headers {
 		hash := header.Hash()
 		bytes, err := rlp.EncodeToBytes(header)
 		if err != nil {
@@ -308,6 +327,24 @@ func (r *RecordingDatabase) PreimagesFromRecording(chainContextIf core.ChainCont
 	return entries, nil
 }
 
+// headersFromRecording returns the headers from the oldest one accessed up to the recording's initial block
+func (r *RecordingDatabase) headersFromRecording(chainContextIf core.ChainContext) ([]*types.Header, error) {
+	recordingChainContext, ok := chainContextIf.(*RecordingChainContext)
+	if (recordingChainContext == nil) || (!ok) {
+		return nil, errors.New("recordingChainContext invalid")
+	}
+
+	var headers []*types.Header
+	for i := recordingChainContext.GetMinBlockNumberAccessed(); i <= recordingChainContext.initialBlockNumber; i++ {
+		header := r.bc.GetHeaderByNumber(i)
+		if header == nil {
+			return nil, fmt.Errorf("header %d not found", i)
+		}
+		headers = append(headers, header)
+	}
+	return headers, nil
+}
+
 func (r *RecordingDatabase) GetOrRecreateState(ctx context.Context, header *types.Header, logFunc StateBuildingLogFunction) (*state.StateDB, error) {
 	state, currentHeader, err := FindLastAvailableState(ctx, r.bc, r.StateFor, header, logFunc, -1)
 	if err != nil {
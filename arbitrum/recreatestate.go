@@ -23,6 +23,7 @@ type StateForHeaderFunction func(header *types.Header) (*state.StateDB, error)
 // if maxDepthInL2Gas is positive, it constitutes a limit for cumulative l2 gas used of the traversed blocks
 // else if maxDepthInL2Gas is -1, the traversal depth is not limited
 // otherwise only targetHeader state is checked and no search is performed
+// when searching, the nearest state checkpoint is preferred over the states of the blocks in between
 func FindLastAvailableState(ctx context.Context, bc *core.BlockChain, stateFor StateForHeaderFunction, targetHeader *types.Header, logFunc StateBuildingLogFunction, maxDepthInL2Gas int64) (*state.StateDB, *types.Header, error) {
 	genesis := bc.Config().ArbitrumChainParams.GenesisBlockNum
 	currentHeader := targetHeader
@@ -35,6 +36,11 @@ func FindLastAvailableState(ctx context.Context, bc *core.BlockChain, stateFor S
 		if err == nil {
 			break
 		}
+		if currentHeader == targetHeader && maxDepthInL2Gas != 0 {
+			if checkpointState, checkpoint := stateFromCheckpoint(ctx, bc, stateFor, targetHeader, logFunc, maxDepthInL2Gas); checkpointState != nil {
+				return checkpointState, checkpoint, nil
+			}
+		}
 		if maxDepthInL2Gas > 0 {
 			receipts := bc.GetReceiptsByHash(currentHeader.Hash())
 			if receipts == nil {
@@ -63,6 +69,53 @@ func FindLastAvailableState(ctx context.Context, bc *core.BlockChain, stateFor S
 	return state, currentHeader, ctx.Err()
 }
 
+// stateFromCheckpoint returns the state of the nearest state checkpoint below the target,
+// if it's available and the blocks in between are within maxDepthInL2Gas
+func stateFromCheckpoint(ctx context.Context, bc *core.BlockChain, stateFor StateForHeaderFunction, targetHeader *types.Header, logFunc StateBuildingLogFunction, maxDepthInL2Gas int64) (*state.StateDB, *types.Header) {
+	checkpoint := bc.NearestStateCheckpoint(targetHeader.Number.Uint64())
+	if checkpoint == nil || checkpoint.Number.Cmp(targetHeader.Number) >= 0 {
+		return nil, nil
+	}
+	var l2GasUsed uint64
+	var traversed []*types.Header
+	for header := targetHeader; header.Number.Cmp(checkpoint.Number) > 0; {
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		if maxDepthInL2Gas > 0 {
+			receipts := bc.GetReceiptsByHash(header.Hash())
+			if receipts == nil {
+				return nil, nil
+			}
+			for _, receipt := range receipts {
+				l2GasUsed += receipt.GasUsed - receipt.GasUsedForL1
+			}
+			if l2GasUsed > uint64(maxDepthInL2Gas) {
+				return nil, nil
+			}
+		}
+		traversed = append(traversed, header)
+		header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if header == nil {
+			return nil, nil
+		}
+		if header.Number.Cmp(checkpoint.Number) == 0 && header.Hash() != checkpoint.Hash() {
+			// the target isn't a descendant of the checkpoint
+			return nil, nil
+		}
+	}
+	state, err := stateFor(checkpoint)
+	if err != nil {
+		return nil, nil
+	}
+	if logFunc != nil {
+		for _, header := range traversed {
+			logFunc(targetHeader, header, false)
+		}
+	}
+	return state, checkpoint
+}
+
 func AdvanceStateByBlock(ctx context.Context, bc *core.BlockChain, state *state.StateDB, targetHeader *types.Header, blockToRecreate uint64, prevBlockHash common.Hash, logFunc StateBuildingLogFunction) (*state.StateDB, *types.Block, error) {
 	block := bc.GetBlockByNumber(blockToRecreate)
 	if block == nil {
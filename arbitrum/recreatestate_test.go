@@ -0,0 +1,76 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestFindLastAvailableStateFromCheckpoint(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		cacheConfig = &core.CacheConfig{
+			TriesInMemory:         128,
+			TrieRetention:         30 * time.Minute,
+			TrieCleanLimit:        256,
+			TrieDirtyLimit:        256,
+			TrieTimeLimit:         5 * time.Minute,
+			StateCheckpointBlocks: 4,
+		}
+		chain  = newTestChainWithCacheConfig(t, cacheConfig, 10, nil)
+		diskdb = state.NewDatabase(chain.db)
+		target = chain.blocks[6].Header()
+		tried  []uint64
+	)
+	// only the states of the genesis and the checkpoints are on disk
+	stateFor := func(header *types.Header) (*state.StateDB, error) {
+		tried = append(tried, header.Number.Uint64())
+		return state.New(header.Root, diskdb, nil)
+	}
+	var recreated []uint64
+	logFunc := func(targetHeader, header *types.Header, hasState bool) {
+		if !hasState {
+			recreated = append(recreated, header.Number.Uint64())
+		}
+	}
+
+	// the checkpoint is taken without trying the states in between
+	statedb, header, err := FindLastAvailableState(ctx, chain.bc, stateFor, target, logFunc, InfiniteMaxRecreateStateDepth)
+	if err != nil {
+		t.Fatalf("failed to find state: %v", err)
+	}
+	if header.Hash() != chain.blocks[3].Hash() {
+		t.Fatalf("have state of block %d, want the checkpoint at 4", header.Number)
+	}
+	if len(tried) != 2 || tried[0] != 7 || tried[1] != 4 {
+		t.Errorf("tried the states of blocks %v, want 7 and 4", tried)
+	}
+	if len(recreated) != 3 {
+		t.Errorf("have blocks %v to recreate, want 7, 6 and 5", recreated)
+	}
+	statedb, err = AdvanceStateUpToBlock(ctx, chain.bc, statedb, target, header, nil)
+	if err != nil {
+		t.Fatalf("failed to advance state: %v", err)
+	}
+	checkNonce(t, statedb, 7)
+
+	// the checkpoint is within a depth covering the blocks in between
+	if _, header, err := FindLastAvailableState(ctx, chain.bc, stateFor, target, nil, 3*int64(params.TxGas)); err != nil || header.Hash() != chain.blocks[3].Hash() {
+		t.Errorf("have state of block %v (%v), want the checkpoint at 4", header.Number, err)
+	}
+
+	// but not beyond the depth limit
+	if _, _, err := FindLastAvailableState(ctx, chain.bc, stateFor, target, nil, 2*int64(params.TxGas)); !errors.Is(err, ErrDepthLimitExceeded) {
+		t.Errorf("have %v, want the depth limit exceeded", err)
+	}
+	// and not when only the target state is checked
+	if _, _, err := FindLastAvailableState(ctx, chain.bc, stateFor, target, nil, 0); err == nil {
+		t.Error("found a state without searching")
+	}
+}
@@ -0,0 +1,206 @@
+package arbitrum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// retryableIndexSectionSize indexes every block as soon as it's written
+	retryableIndexSectionSize = 1
+	retryableIndexConfirms    = 0
+	retryableIndexThrottling  = 0
+)
+
+var errRetryableIndexDisabled = errors.New("the retryable index is disabled, see the retryable-index option")
+
+// retryableIndexer is a core.ChainIndexerBackend indexing the transactions that
+// submit and redeem each retryable ticket, keyed by the id of the ticket. The id
+// of a ticket is the hash of the transaction submitting it.
+type retryableIndexer struct {
+	db     ethdb.Database
+	config *params.ChainConfig
+	batch  ethdb.Batch
+}
+
+// newRetryableIndexer returns a chain indexer building the retryable index
+func newRetryableIndexer(db ethdb.Database, config *params.ChainConfig) *core.ChainIndexer {
+	backend := &retryableIndexer{db: db, config: config}
+	table := rawdb.NewTable(db, string(rawdb.RetryableIndexTablePrefix))
+	return core.NewChainIndexer(db, table, backend, retryableIndexSectionSize, retryableIndexConfirms, retryableIndexThrottling, "retryables")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new batch of entries.
+// Entries of reorged blocks are left behind, and skipped by readers.
+func (r *retryableIndexer) Reset(ctx context.Context, section uint64, prevHead common.Hash) error {
+	r.batch = r.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the retryable transactions of the block.
+func (r *retryableIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+	body := rawdb.ReadBody(r.db, hash, number)
+	if body == nil {
+		return fmt.Errorf("block body #%d [%x..] not found", number, hash[:4])
+	}
+	var receipts types.Receipts
+	for i, tx := range body.Transactions {
+		ticketId, ok := tx.RetryableTicketId()
+		if !ok {
+			continue
+		}
+		if receipts == nil {
+			receipts = rawdb.ReadReceipts(r.db, hash, number, header.Time, r.config)
+			if len(receipts) != len(body.Transactions) {
+				return fmt.Errorf("receipts of block #%d [%x..] not found", number, hash[:4])
+			}
+		}
+		rawdb.WriteRetryableTxEntry(r.batch, ticketId, rawdb.RetryableTxEntry{
+			TxType:      tx.Type(),
+			TxHash:      tx.Hash(),
+			BlockHash:   hash,
+			BlockNumber: number,
+			TxIndex:     uint64(i),
+			Status:      receipts[i].Status,
+			GasUsed:     receipts[i].GasUsed,
+		})
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the entries of the section.
+func (r *retryableIndexer) Commit() error {
+	return r.batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, which the retryable index doesn't support.
+func (r *retryableIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// ArbRetryableAPI offers the history of retryable tickets
+type ArbRetryableAPI struct {
+	b *APIBackend
+}
+
+func NewArbRetryableAPI(b *APIBackend) *ArbRetryableAPI {
+	return &ArbRetryableAPI{b}
+}
+
+// RetryableTx is a transaction submitting or redeeming a retryable ticket
+type RetryableTx struct {
+	Type             hexutil.Uint64 `json:"type"`
+	Hash             common.Hash    `json:"hash"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	From             common.Address `json:"from"`
+	Status           hexutil.Uint64 `json:"status"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+
+	// Submission
+	RequestId        *common.Hash    `json:"requestId,omitempty"`
+	L1BaseFee        *hexutil.Big    `json:"l1BaseFee,omitempty"`
+	DepositValue     *hexutil.Big    `json:"depositValue,omitempty"`
+	RetryTo          *common.Address `json:"retryTo,omitempty"`
+	RetryValue       *hexutil.Big    `json:"retryValue,omitempty"`
+	Beneficiary      *common.Address `json:"beneficiary,omitempty"`
+	MaxSubmissionFee *hexutil.Big    `json:"maxSubmissionFee,omitempty"`
+
+	// Redeem attempt
+	MaxRefund           *hexutil.Big `json:"maxRefund,omitempty"`
+	SubmissionFeeRefund *hexutil.Big `json:"submissionFeeRefund,omitempty"`
+
+	RefundTo common.Address `json:"refundTo"`
+}
+
+// RetryableHistory is the lifecycle of a retryable ticket, as far as it's indexed
+type RetryableHistory struct {
+	TicketId   common.Hash    `json:"ticketId"`
+	Submission *RetryableTx   `json:"submission"`
+	Redeems    []*RetryableTx `json:"redeems"`
+	// Redeemed is whether a redeem attempt succeeded
+	Redeemed bool `json:"redeemed"`
+	// IndexedBlocks is the number of blocks the index covers from genesis,
+	// which is behind the chain while the index catches up
+	IndexedBlocks hexutil.Uint64 `json:"indexedBlocks"`
+}
+
+// GetRetryableHistory returns the transaction submitting the retryable ticket with
+// the given id along with every attempt to redeem it, in order, as found in the
+// canonical chain. The submission is null if it wasn't found.
+func (api *ArbRetryableAPI) GetRetryableHistory(ctx context.Context, ticketId common.Hash) (*RetryableHistory, error) {
+	indexer := api.b.b.retryableIndexer
+	if indexer == nil {
+		return nil, errRetryableIndexDisabled
+	}
+	indexed, _, _ := indexer.Sections()
+	history := &RetryableHistory{
+		TicketId:      ticketId,
+		Redeems:       []*RetryableTx{},
+		IndexedBlocks: hexutil.Uint64(indexed * retryableIndexSectionSize),
+	}
+	db := api.b.ChainDb()
+	for _, entry := range rawdb.ReadRetryableTxEntries(db, ticketId) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if rawdb.ReadCanonicalHash(db, entry.BlockNumber) != entry.BlockHash {
+			continue
+		}
+		block := api.b.blockChain().GetBlock(entry.BlockHash, entry.BlockNumber)
+		if block == nil || entry.TxIndex >= uint64(len(block.Transactions())) {
+			return nil, fmt.Errorf("block #%d [%x..] of retryable transaction not found", entry.BlockNumber, entry.BlockHash[:4])
+		}
+		result := newRetryableTx(block.Transactions()[entry.TxIndex], entry)
+		if entry.TxType == types.ArbitrumSubmitRetryableTxType {
+			history.Submission = result
+			continue
+		}
+		history.Redeems = append(history.Redeems, result)
+		if entry.Status == types.ReceiptStatusSuccessful {
+			history.Redeemed = true
+		}
+	}
+	return history, nil
+}
+
+func newRetryableTx(tx *types.Transaction, entry rawdb.RetryableTxEntry) *RetryableTx {
+	result := &RetryableTx{
+		Type:             hexutil.Uint64(entry.TxType),
+		Hash:             entry.TxHash,
+		BlockHash:        entry.BlockHash,
+		BlockNumber:      hexutil.Uint64(entry.BlockNumber),
+		TransactionIndex: hexutil.Uint64(entry.TxIndex),
+		Status:           hexutil.Uint64(entry.Status),
+		GasUsed:          hexutil.Uint64(entry.GasUsed),
+	}
+	switch inner := tx.GetInner().(type) {
+	case *types.ArbitrumSubmitRetryableTx:
+		result.From = inner.From
+		result.RequestId = &inner.RequestId
+		result.L1BaseFee = (*hexutil.Big)(inner.L1BaseFee)
+		result.DepositValue = (*hexutil.Big)(inner.DepositValue)
+		result.RetryTo = inner.RetryTo
+		result.RetryValue = (*hexutil.Big)(inner.RetryValue)
+		result.Beneficiary = &inner.Beneficiary
+		result.MaxSubmissionFee = (*hexutil.Big)(inner.MaxSubmissionFee)
+		result.RefundTo = inner.FeeRefundAddr
+	case *types.ArbitrumRetryTx:
+		result.From = inner.From
+		result.MaxRefund = (*hexutil.Big)(inner.MaxRefund)
+		result.SubmissionFeeRefund = (*hexutil.Big)(inner.SubmissionFeeRefund)
+		result.RefundTo = inner.RefundTo
+	}
+	return result
+}
@@ -0,0 +1,198 @@
+package arbitrum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// retryableTestGen submits a retryable ticket in the first block and redeems it in
+// the next ones, the first attempt failing; the redeem of the third block goes to retryTo
+func retryableTestGen(retryTo common.Address) func(int, *core.BlockGen) {
+	config := newTestChainConfig()
+	return func(i int, block *core.BlockGen) {
+		retry := &types.ArbitrumRetryTx{
+			ChainId:             config.ChainID,
+			From:                testAddress,
+			GasFeeCap:           block.BaseFee(),
+			Gas:                 100000,
+			Value:               new(big.Int),
+			TicketId:            retryableTestSubmission().Hash(),
+			RefundTo:            common.Address{0x05},
+			MaxRefund:           big.NewInt(1000),
+			SubmissionFeeRefund: big.NewInt(10),
+		}
+		switch i {
+		case 0:
+			block.AddTx(retryableTestSubmission())
+		case 1:
+			retry.Data = []byte{byte(0xfd)} // creation reverting for lack of stack
+		case 2:
+			retry.Nonce = 1
+			retry.To = &retryTo
+		}
+		if i > 0 {
+			block.AddTx(types.NewTx(retry))
+		}
+	}
+}
+
+func retryableTestSubmission() *types.Transaction {
+	return types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:          newTestChainConfig().ChainID,
+		RequestId:        common.Hash{0x01},
+		From:             testAddress,
+		L1BaseFee:        big.NewInt(1),
+		DepositValue:     big.NewInt(1000000),
+		GasFeeCap:        big.NewInt(1000000000),
+		Gas:              100000,
+		RetryTo:          &common.Address{0x03},
+		RetryValue:       new(big.Int),
+		Beneficiary:      common.Address{0x04},
+		MaxSubmissionFee: big.NewInt(100),
+		FeeRefundAddr:    common.Address{0x05},
+	})
+}
+
+func TestRetryableHistory(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		chain    = newTestChain(t, 3, retryableTestGen(common.Address{0x03}))
+		fork     = newTestChain(t, 3, retryableTestGen(common.Address{0x06}))
+		ticketId = retryableTestSubmission().Hash()
+	)
+	if fork.blocks[1].Hash() != chain.blocks[1].Hash() || fork.blocks[2].Hash() == chain.blocks[2].Hash() {
+		t.Fatal("fork doesn't branch off at the last block")
+	}
+	backend := chain.apiBackend(DefaultConfig)
+	api := NewArbRetryableAPI(backend)
+	if _, err := api.GetRetryableHistory(ctx, ticketId); !errors.Is(err, errRetryableIndexDisabled) {
+		t.Errorf("have %v, want the index disabled", err)
+	}
+
+	indexer := newRetryableIndexer(chain.db, chain.bc.Config())
+	indexer.Start(chain.bc)
+	defer indexer.Close()
+	backend.b.retryableIndexer = indexer
+	waitIndexed := func(blocks uint64) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+			if sections, _, _ := indexer.Sections(); sections == blocks {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("index didn't cover %d blocks", blocks)
+			}
+		}
+	}
+	waitIndexed(4)
+
+	history, err := api.GetRetryableHistory(ctx, ticketId)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if history.Submission == nil || history.Submission.Hash != ticketId || history.Submission.BlockNumber != 1 {
+		t.Fatalf("have submission %+v, want the ticket's in block 1", history.Submission)
+	}
+	if *history.Submission.RequestId != (common.Hash{0x01}) || *history.Submission.Beneficiary != (common.Address{0x04}) || history.Submission.From != testAddress {
+		t.Errorf("have submission %+v, want the fields of the ticket", history.Submission)
+	}
+	if len(history.Redeems) != 2 || history.Redeems[0].Hash != chain.blocks[1].Transactions()[1].Hash() || history.Redeems[1].Hash != chain.blocks[2].Transactions()[1].Hash() {
+		t.Fatalf("have redeems %+v, want those of blocks 2 and 3", history.Redeems)
+	}
+	if uint64(history.Redeems[0].Status) != types.ReceiptStatusFailed || uint64(history.Redeems[1].Status) != types.ReceiptStatusSuccessful || !history.Redeemed {
+		t.Errorf("have redeem statuses %d and %d, redeemed %v, want failed then redeemed", history.Redeems[0].Status, history.Redeems[1].Status, history.Redeemed)
+	}
+	if history.IndexedBlocks != 4 {
+		t.Errorf("have %d indexed blocks, want 4", history.IndexedBlocks)
+	}
+
+	// redeems reorged out of the canonical chain are skipped
+	if err := chain.bc.ReorgToOldBlock(chain.blocks[1]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	waitIndexed(3)
+	if history, err = api.GetRetryableHistory(ctx, ticketId); err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history.Redeems) != 1 || history.Redeemed {
+		t.Errorf("have redeems %+v, redeemed %v, want the failed attempt only", history.Redeems, history.Redeemed)
+	}
+	if n, err := chain.bc.InsertChain(fork.blocks[2:]); err != nil {
+		t.Fatalf("failed to insert fork block %d: %v", n, err)
+	}
+	waitIndexed(4)
+	if history, err = api.GetRetryableHistory(ctx, ticketId); err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(history.Redeems) != 2 || history.Redeems[1].Hash != fork.blocks[2].Transactions()[1].Hash() || !history.Redeemed {
+		t.Errorf("have redeems %+v, want the fork's", history.Redeems)
+	}
+
+	// the RPC output of the fork's history
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("arb", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+	var result map[string]json.RawMessage
+	if err := client.CallContext(ctx, &result, "arb_getRetryableHistory", ticketId); err != nil {
+		t.Fatalf("failed to call arb_getRetryableHistory: %v", err)
+	}
+	var (
+		submission map[string]interface{}
+		redeems    []map[string]interface{}
+	)
+	if err := json.Unmarshal(result["submission"], &submission); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(result["redeems"], &redeems); err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]interface{}{
+		"type":             hexutil.EncodeUint64(types.ArbitrumSubmitRetryableTxType),
+		"blockNumber":      "0x1",
+		"requestId":        common.Hash{0x01}.Hex(),
+		"maxSubmissionFee": "0x64",
+		"refundTo":         common.Address{0x05}.Hex(),
+	} {
+		if submission[field] != want {
+			t.Errorf("submission %s: have %v, want %v", field, submission[field], want)
+		}
+	}
+	if _, ok := submission["maxRefund"]; ok {
+		t.Error("submission has a redeem field")
+	}
+	if len(redeems) != 2 {
+		t.Fatalf("have %d redeems, want 2", len(redeems))
+	}
+	for field, want := range map[string]interface{}{
+		"type":                hexutil.EncodeUint64(types.ArbitrumRetryTxType),
+		"blockHash":           fork.blocks[2].Hash().Hex(),
+		"transactionIndex":    "0x1",
+		"status":              "0x1",
+		"maxRefund":           "0x3e8",
+		"submissionFeeRefund": "0xa",
+	} {
+		if redeems[1][field] != want {
+			t.Errorf("redeem %s: have %v, want %v", field, redeems[1][field], want)
+		}
+	}
+	if _, ok := redeems[1]["requestId"]; ok {
+		t.Error("redeem has a submission field")
+	}
+	if string(result["redeemed"]) != "true" || string(result["indexedBlocks"]) != `"0x4"` {
+		t.Errorf("have redeemed %s and indexed blocks %s, want true and 0x4", result["redeemed"], result["indexedBlocks"])
+	}
+}
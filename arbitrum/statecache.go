@@ -0,0 +1,313 @@
+package arbitrum
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	stateCacheHitCounter     = metrics.NewRegisteredCounter("arb/apibackend/statecache/hit", nil)
+	stateCacheMissCounter    = metrics.NewRegisteredCounter("arb/apibackend/statecache/miss", nil)
+	stateCacheDedupedCounter = metrics.NewRegisteredCounter("arb/apibackend/statecache/deduped", nil)
+	stateCacheSizeGauge      = metrics.NewRegisteredGauge("arb/apibackend/statecache/size", nil)
+	stateCacheEntriesGauge   = metrics.NewRegisteredGauge("arb/apibackend/statecache/entries", nil)
+)
+
+// stateCacheEntry is a recreated state committed to the cache's trie database
+type stateCacheEntry struct {
+	header *types.Header
+	refs   int // one held by the cache while cached, plus one per user
+	elem   *list.Element
+}
+
+// stateRecreation is an in-flight recreation other requests for the same block wait on
+type stateRecreation struct {
+	done chan struct{}
+	err  error
+}
+
+// RecreatedStateCache shares the states recreated for RPC requests, so that
+// concurrent requests for the same block recreate it once, and requests for
+// later blocks continue from the intermediate states instead of the last state
+// available on disk.
+//
+// Recreated states are committed to a separate trie database on top of the
+// chain database, each of them referenced while cached or used. Once the dirty
+// nodes exceed the size limit, the least recently used states are dropped.
+type RecreatedStateCache struct {
+	bc    *core.BlockChain
+	db    state.Database
+	limit common.StorageSize
+
+	mutex    sync.Mutex // protects the entries, the in-flight recreations and the trie database references
+	entries  map[common.Hash]*stateCacheEntry
+	lru      *list.List // cached entries, the most recently used first
+	inflight map[common.Hash]*stateRecreation
+}
+
+// NewRecreatedStateCache creates a cache holding up to sizeMB megabytes of recreated state.
+func NewRecreatedStateCache(bc *core.BlockChain, ethdb ethdb.Database, sizeMB int) *RecreatedStateCache {
+	return &RecreatedStateCache{
+		bc:       bc,
+		db:       state.NewDatabaseWithConfig(ethdb, &trie.Config{Cache: 16}),
+		limit:    common.StorageSize(sizeMB) * 1024 * 1024,
+		entries:  make(map[common.Hash]*stateCacheEntry),
+		lru:      list.New(),
+		inflight: make(map[common.Hash]*stateRecreation),
+	}
+}
+
+// StateFor returns the state of the block, recreating it from the closest cached
+// or stored state within maxDepthInL2Gas (see FindLastAvailableState). The state
+// stays valid until the returned function is called.
+func (c *RecreatedStateCache) StateFor(ctx context.Context, header *types.Header, maxDepthInL2Gas int64) (*state.StateDB, tracers.StateReleaseFunc, error) {
+	hash := header.Hash()
+	for {
+		if entry := c.acquire(hash); entry != nil {
+			stateCacheHitCounter.Inc(1)
+			statedb, err := state.New(header.Root, c.db, nil)
+			if err != nil {
+				c.release(entry)
+				return nil, nil, err
+			}
+			return statedb, func() { c.release(entry) }, nil
+		}
+		c.mutex.Lock()
+		recreation := c.inflight[hash]
+		if recreation == nil {
+			recreation = &stateRecreation{done: make(chan struct{})}
+			c.inflight[hash] = recreation
+			c.mutex.Unlock()
+			break
+		}
+		c.mutex.Unlock()
+
+		stateCacheDedupedCounter.Inc(1)
+		select {
+		case <-recreation.done:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		// a recreation aborted by its own request is retried by the waiters
+		if recreation.err != nil && !errors.Is(recreation.err, context.Canceled) && !errors.Is(recreation.err, context.DeadlineExceeded) {
+			return nil, nil, recreation.err
+		}
+	}
+	stateCacheMissCounter.Inc(1)
+	statedb, entry, err := c.recreate(ctx, header, maxDepthInL2Gas)
+
+	c.mutex.Lock()
+	recreation := c.inflight[hash]
+	delete(c.inflight, hash)
+	recreation.err = err
+	c.mutex.Unlock()
+	close(recreation.done)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return statedb, func() { c.release(entry) }, nil
+}
+
+// recreate builds the state of the block, caching the states of every block
+// recreated on the way. The returned entry is referenced, unless the state was
+// found on disk in which case it is nil.
+func (c *RecreatedStateCache) recreate(ctx context.Context, header *types.Header, maxDepthInL2Gas int64) (*state.StateDB, *stateCacheEntry, error) {
+	var held *stateCacheEntry // the entry of the state being built on, if any
+	defer func() { c.release(held) }()
+
+	stateFor := func(header *types.Header) (*state.StateDB, error) {
+		entry := c.acquire(header.Hash())
+		statedb, err := state.New(header.Root, c.db, nil)
+		if err != nil {
+			c.release(entry)
+			return nil, err
+		}
+		held = entry
+		return statedb, nil
+	}
+	var statedb *state.StateDB
+	lastHeader := c.nearestCachedAncestor(ctx, header, maxDepthInL2Gas)
+	if lastHeader != nil {
+		statedb, _ = stateFor(lastHeader)
+	}
+	if statedb == nil || held == nil {
+		c.release(held)
+		held = nil
+		var err error
+		statedb, lastHeader, err = FindLastAvailableState(ctx, c.bc, stateFor, header, nil, maxDepthInL2Gas)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if lastHeader.Hash() == header.Hash() {
+		entry := held
+		held = nil
+		return statedb, entry, nil
+	}
+	returnedBlockNumber := header.Number.Uint64()
+	blockToRecreate := lastHeader.Number.Uint64() + 1
+	prevHash := lastHeader.Hash()
+	for ctx.Err() == nil {
+		var block *types.Block
+		var err error
+		statedb, block, err = AdvanceStateByBlock(ctx, c.bc, statedb, header, blockToRecreate, prevHash, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		entry, err := c.add(statedb, block.Header())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed committing state for block %d : %w", blockToRecreate, err)
+		}
+		c.release(held)
+		held = entry
+		prevHash = block.Hash()
+		if blockToRecreate >= returnedBlockNumber {
+			if block.Hash() != header.Hash() {
+				return nil, nil, fmt.Errorf("blockHash doesn't match when recreating number: %d expected: %v got: %v", blockToRecreate, header.Hash(), block.Hash())
+			}
+			held = nil
+			return statedb, entry, nil
+		}
+		blockToRecreate++
+	}
+	return nil, nil, ctx.Err()
+}
+
+// nearestCachedAncestor looks for the closest cached state the block can be
+// recreated from, which FindLastAvailableState wouldn't prefer over a state
+// checkpoint. The search stops at the checkpoint, at the oldest cached block
+// or once the blocks to recreate exceed maxDepthInL2Gas.
+func (c *RecreatedStateCache) nearestCachedAncestor(ctx context.Context, header *types.Header, maxDepthInL2Gas int64) *types.Header {
+	if maxDepthInL2Gas <= 0 && maxDepthInL2Gas != InfiniteMaxRecreateStateDepth {
+		return nil
+	}
+	c.mutex.Lock()
+	var lowest uint64
+	for _, entry := range c.entries {
+		if number := entry.header.Number.Uint64(); lowest == 0 || number < lowest {
+			lowest = number
+		}
+	}
+	c.mutex.Unlock()
+	if lowest == 0 || lowest >= header.Number.Uint64() {
+		return nil
+	}
+	if checkpoint := c.bc.NearestStateCheckpoint(header.Number.Uint64()); checkpoint != nil && checkpoint.Number.Uint64() > lowest {
+		lowest = checkpoint.Number.Uint64()
+	}
+	var l2GasUsed uint64
+	for current := header; current.Number.Uint64() > lowest && ctx.Err() == nil; {
+		if maxDepthInL2Gas > 0 {
+			receipts := c.bc.GetReceiptsByHash(current.Hash())
+			if receipts == nil {
+				return nil
+			}
+			for _, receipt := range receipts {
+				l2GasUsed += receipt.GasUsed - receipt.GasUsedForL1
+			}
+			if l2GasUsed > uint64(maxDepthInL2Gas) {
+				return nil
+			}
+		}
+		current = c.bc.GetHeader(current.ParentHash, current.Number.Uint64()-1)
+		if current == nil {
+			return nil
+		}
+		c.mutex.Lock()
+		_, cached := c.entries[current.Hash()]
+		c.mutex.Unlock()
+		if cached {
+			return current
+		}
+	}
+	return nil
+}
+
+// add commits a recreated state and caches it, returning its entry referenced for the caller.
+// The committed nodes can't be garbage collected before the root is referenced, since
+// dereferencing other roots only frees the nodes no other node points to.
+func (c *RecreatedStateCache) add(statedb *state.StateDB, header *types.Header) (*stateCacheEntry, error) {
+	root, err := statedb.Commit(true)
+	if err != nil {
+		return nil, err
+	}
+	if root != header.Root {
+		return nil, fmt.Errorf("bad root hash expected: %v got: %v", header.Root, root)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	hash := header.Hash()
+	entry := c.entries[hash]
+	if entry == nil {
+		if err := c.db.TrieDB().Reference(root, common.Hash{}); err != nil {
+			return nil, err
+		}
+		entry = &stateCacheEntry{header: header, refs: 1}
+		entry.elem = c.lru.PushFront(entry)
+		c.entries[hash] = entry
+	} else {
+		c.lru.MoveToFront(entry.elem)
+	}
+	entry.refs++
+	c.evictLockHeld()
+	return entry, nil
+}
+
+// acquire references the cached entry of the block, if there's one
+func (c *RecreatedStateCache) acquire(hash common.Hash) *stateCacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry := c.entries[hash]
+	if entry != nil {
+		entry.refs++
+		c.lru.MoveToFront(entry.elem)
+	}
+	return entry
+}
+
+func (c *RecreatedStateCache) release(entry *stateCacheEntry) {
+	if entry == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.dereferenceLockHeld(entry)
+	c.evictLockHeld()
+}
+
+// lock must be held when calling that
+func (c *RecreatedStateCache) dereferenceLockHeld(entry *stateCacheEntry) {
+	entry.refs--
+	if entry.refs == 0 {
+		_ = c.db.TrieDB().Dereference(entry.header.Root)
+	}
+}
+
+// evictLockHeld drops the least recently used entries until the dirty nodes fit
+// the limit. The nodes of the entries still in use are only freed once released.
+// lock must be held when calling that
+func (c *RecreatedStateCache) evictLockHeld() {
+	size, _ := c.db.TrieDB().Size()
+	for size > c.limit && c.lru.Len() > 0 {
+		entry := c.lru.Remove(c.lru.Back()).(*stateCacheEntry)
+		delete(c.entries, entry.header.Hash())
+		c.dereferenceLockHeld(entry)
+		size, _ = c.db.TrieDB().Size()
+	}
+	stateCacheSizeGauge.Update(int64(size))
+	stateCacheEntriesGauge.Update(int64(c.lru.Len()))
+}
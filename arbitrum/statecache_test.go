@@ -0,0 +1,145 @@
+package arbitrum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func checkNonce(t *testing.T, statedb *state.StateDB, want uint64) {
+	t.Helper()
+	if nonce := statedb.GetNonce(testAddress); nonce != want {
+		t.Errorf("have nonce %d, want %d", nonce, want)
+	}
+}
+
+func TestRecreatedStateCacheHitAndMiss(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(t, 8, nil)
+		cache = NewRecreatedStateCache(chain.bc, chain.db, 16)
+	)
+	// a miss recreates and caches every state on the way
+	statedb, release, err := cache.StateFor(ctx, chain.blocks[4].Header(), InfiniteMaxRecreateStateDepth)
+	if err != nil {
+		t.Fatalf("failed to recreate state: %v", err)
+	}
+	checkNonce(t, statedb, 5)
+	if len(cache.entries) != 5 {
+		t.Fatalf("have %d cached states, want 5", len(cache.entries))
+	}
+
+	// a hit shares the cached state
+	hit, releaseHit, err := cache.StateFor(ctx, chain.blocks[4].Header(), InfiniteMaxRecreateStateDepth)
+	if err != nil {
+		t.Fatalf("failed to get cached state: %v", err)
+	}
+	checkNonce(t, hit, 5)
+	entry := cache.entries[chain.blocks[4].Hash()]
+	if entry.refs != 3 {
+		t.Errorf("have %d references, want one for the cache and one per user", entry.refs)
+	}
+	releaseHit()
+	release()
+	if entry.refs != 1 {
+		t.Errorf("have %d references after release, want the cache's", entry.refs)
+	}
+
+	// later states continue from the nearest cached one, within the depth limit
+	header := chain.blocks[6].Header()
+	if ancestor := cache.nearestCachedAncestor(ctx, header, InfiniteMaxRecreateStateDepth); ancestor == nil || ancestor.Hash() != chain.blocks[4].Hash() {
+		t.Errorf("have nearest cached ancestor %v, want block 5", ancestor)
+	}
+	for _, depth := range []int64{0, -2} {
+		if ancestor := cache.nearestCachedAncestor(ctx, header, depth); ancestor != nil {
+			t.Errorf("depth %d: searched for a cached ancestor", depth)
+		}
+	}
+	if ancestor := cache.nearestCachedAncestor(ctx, header, 1); ancestor != nil {
+		t.Error("cached ancestor found beyond the depth limit")
+	}
+	statedb, release, err = cache.StateFor(ctx, header, InfiniteMaxRecreateStateDepth)
+	if err != nil {
+		t.Fatalf("failed to recreate state: %v", err)
+	}
+	defer release()
+	checkNonce(t, statedb, 7)
+	if len(cache.entries) != 7 {
+		t.Fatalf("have %d cached states, want 7", len(cache.entries))
+	}
+}
+
+func TestRecreatedStateCacheEviction(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		chain = newTestChain(t, 4, nil)
+		cache = NewRecreatedStateCache(chain.bc, chain.db, 0) // evicts any state not in use
+	)
+	var releases []tracers.StateReleaseFunc
+	for i, block := range chain.blocks {
+		statedb, release, err := cache.StateFor(ctx, block.Header(), InfiniteMaxRecreateStateDepth)
+		if err != nil {
+			t.Fatalf("failed to recreate state %d: %v", i, err)
+		}
+		releases = append(releases, release)
+		if len(cache.entries) != 0 {
+			t.Errorf("have %d cached states, want all evicted", len(cache.entries))
+		}
+		// states in use outlive their eviction
+		checkNonce(t, statedb, uint64(i+1))
+	}
+	if size, _ := cache.db.TrieDB().Size(); size == 0 {
+		t.Fatal("states in use were freed")
+	}
+	for _, release := range releases {
+		release()
+	}
+	if size, _ := cache.db.TrieDB().Size(); size != 0 {
+		t.Errorf("have %v of states left after release, want none", size)
+	}
+}
+
+func TestAPIBackendReleasesCachedState(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		chain  = newTestChain(t, 4, nil)
+		config = DefaultConfig
+		header = chain.blocks[1].Header()
+	)
+	config.MaxRecreateStateDepth = InfiniteMaxRecreateStateDepth
+	backend := chain.apiBackend(config)
+	backend.stateCache = NewRecreatedStateCache(chain.bc, chain.db, 16)
+	// drop the state from the chain's trie database, so that it's recreated
+	if err := chain.bc.StateCache().TrieDB().Dereference(header.Root); err != nil {
+		t.Fatalf("failed to dereference state: %v", err)
+	}
+	statedb, _, release, err := backend.StateAndHeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	checkNonce(t, statedb, 2)
+	entry := backend.stateCache.entries[header.Hash()]
+	if entry == nil {
+		t.Fatal("state wasn't recreated by the cache")
+	}
+	if entry.refs != 2 {
+		t.Errorf("have %d references, want one for the cache and one for the caller", entry.refs)
+	}
+	release()
+	if entry.refs != 1 {
+		t.Errorf("have %d references after release, want the cache's", entry.refs)
+	}
+
+	// states available in the chain aren't cached
+	_, _, release, err = backend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get state: %v", err)
+	}
+	release()
+	if len(backend.stateCache.entries) != 2 {
+		t.Errorf("have %d cached states, want the 2 recreated", len(backend.stateCache.entries))
+	}
+}
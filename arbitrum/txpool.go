@@ -1,17 +1,287 @@
 package arbitrum
 
-// PublicNetAPI offers network related RPC methods
-type PublicTxPoolAPI struct{}
+import (
+	"sort"
+	"sync"
+	"time"
 
-// NewPublicNetAPI creates a new net API instance.
-func NewPublicTxPoolAPI() *PublicTxPoolAPI {
-	return &PublicTxPoolAPI{}
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxSubmittedTxs bounds the number of tracked transactions, dropping the oldest ones beyond it
+const maxSubmittedTxs = 4096
+
+var submittedTxsGauge = metrics.NewRegisteredGauge("arb/apibackend/submittedtxs", nil)
+
+type submittedTx struct {
+	tx   *types.Transaction
+	from common.Address
+	time time.Time
+}
+
+// submittedTxPool tracks the transactions this node forwarded for sequencing,
+// until they're included or expire, so that they can be served as the
+// contents of a transaction pool. Arbitrum doesn't have a pool otherwise.
+//
+// Transactions are considered included once the sender's nonce in the state of
+// a new chain head moves past theirs, which also drops any transaction they
+// replaced. Pruning only happens on new heads and submissions, so reads may
+// briefly serve transactions that were just included.
+type submittedTxPool struct {
+	bc       *core.BlockChain
+	signer   types.Signer
+	lifetime time.Duration
+
+	mutex     sync.Mutex
+	all       map[common.Hash]*submittedTx
+	byAccount map[common.Address]map[uint64]*submittedTx
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newSubmittedTxPool(bc *core.BlockChain, lifetime time.Duration) *submittedTxPool {
+	return &submittedTxPool{
+		bc:        bc,
+		signer:    types.LatestSigner(bc.Config()),
+		lifetime:  lifetime,
+		all:       make(map[common.Hash]*submittedTx),
+		byAccount: make(map[common.Address]map[uint64]*submittedTx),
+		quit:      make(chan struct{}),
+	}
+}
+
+// start prunes the included transactions on every new chain head until stop is called
+func (p *submittedTxPool) start() {
+	if p.lifetime <= 0 {
+		return
+	}
+	heads := make(chan core.ChainHeadEvent, 10)
+	sub := p.bc.SubscribeChainHeadEvent(heads)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-heads:
+				p.pruneIncluded(head.Block.Root())
+			case <-sub.Err():
+				return
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (p *submittedTxPool) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// add tracks the transactions, replacing any tracked one with the same sender and nonce
+func (p *submittedTxPool) add(txs types.Transactions) {
+	if p.lifetime <= 0 {
+		return
+	}
+	now := time.Now()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, tx := range txs {
+		from, err := types.Sender(p.signer, tx)
+		if err != nil {
+			log.Debug("Not tracking submitted transaction", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		nonces := p.byAccount[from]
+		if nonces == nil {
+			nonces = make(map[uint64]*submittedTx)
+			p.byAccount[from] = nonces
+		}
+		if replaced := nonces[tx.Nonce()]; replaced != nil {
+			delete(p.all, replaced.tx.Hash())
+		}
+		entry := &submittedTx{tx: tx, from: from, time: now}
+		nonces[tx.Nonce()] = entry
+		p.all[tx.Hash()] = entry
+	}
+	p.pruneLockHeld()
+}
+
+// pruneIncluded drops the transactions included as of the state root, along
+// with the expired ones. The state is read without holding the lock.
+func (p *submittedTxPool) pruneIncluded(root common.Hash) {
+	statedb, err := p.bc.StateAt(root)
+	if err != nil {
+		log.Warn("Failed to get the head state pruning submitted transactions", "root", root, "err", err)
+		return
+	}
+	p.mutex.Lock()
+	accounts := make([]common.Address, 0, len(p.byAccount))
+	for from := range p.byAccount {
+		accounts = append(accounts, from)
+	}
+	p.mutex.Unlock()
+
+	stateNonces := make(map[common.Address]uint64, len(accounts))
+	for _, from := range accounts {
+		stateNonces[from] = statedb.GetNonce(from)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for from, stateNonce := range stateNonces {
+		for nonce, entry := range p.byAccount[from] {
+			if nonce < stateNonce {
+				p.removeLockHeld(entry)
+			}
+		}
+	}
+	p.pruneLockHeld()
+}
+
+// pruneLockHeld drops the expired transactions and the oldest ones beyond maxSubmittedTxs.
+// lock must be held when calling that
+func (p *submittedTxPool) pruneLockHeld() {
+	expiry := time.Now().Add(-p.lifetime)
+	for _, entry := range p.all {
+		if entry.time.Before(expiry) {
+			p.removeLockHeld(entry)
+		}
+	}
+	if len(p.all) > maxSubmittedTxs {
+		entries := make([]*submittedTx, 0, len(p.all))
+		for _, entry := range p.all {
+			entries = append(entries, entry)
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+		for _, entry := range entries[:len(entries)-maxSubmittedTxs] {
+			p.removeLockHeld(entry)
+		}
+	}
+	submittedTxsGauge.Update(int64(len(p.all)))
+}
+
+// lock must be held when calling that
+func (p *submittedTxPool) removeLockHeld(entry *submittedTx) {
+	delete(p.all, entry.tx.Hash())
+	nonces := p.byAccount[entry.from]
+	delete(nonces, entry.tx.Nonce())
+	if len(nonces) == 0 {
+		delete(p.byAccount, entry.from)
+	}
+}
+
+// Get returns the tracked transaction with the hash, if any
+func (p *submittedTxPool) Get(hash common.Hash) *types.Transaction {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if entry := p.all[hash]; entry != nil {
+		return entry.tx
+	}
+	return nil
 }
 
-// Version returns the current ethereum protocol version.
-func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*struct{} {
-	return map[string]map[string]map[string]*struct{}{
-		"pending": make(map[string]map[string]*struct{}),
-		"queued":  make(map[string]map[string]*struct{}),
+// Nonce returns the next nonce of the account, accounting for the tracked
+// transactions continuing from its nonce in the state
+func (p *submittedTxPool) Nonce(addr common.Address, stateNonce uint64) uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	nonce := stateNonce
+	for p.byAccount[addr][nonce] != nil {
+		nonce++
+	}
+	return nonce
+}
+
+// Content returns the tracked transactions of every account sorted by nonce,
+// split into the pending ones, which continue from the account's nonce in the
+// latest state, and the queued ones, which follow a nonce gap.
+func (p *submittedTxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	p.mutex.Lock()
+	tracked := make(map[common.Address]types.Transactions, len(p.byAccount))
+	for from := range p.byAccount {
+		tracked[from] = p.txsFromLockHeld(from)
+	}
+	p.mutex.Unlock()
+
+	pending := make(map[common.Address]types.Transactions)
+	queued := make(map[common.Address]types.Transactions)
+	statedb, _ := p.bc.State()
+	for from, txs := range tracked {
+		var stateNonce uint64
+		if statedb != nil {
+			stateNonce = statedb.GetNonce(from)
+		}
+		accountPending, accountQueued := splitByNonce(txs, stateNonce)
+		if len(accountPending) > 0 {
+			pending[from] = accountPending
+		}
+		if len(accountQueued) > 0 {
+			queued[from] = accountQueued
+		}
+	}
+	return pending, queued
+}
+
+// ContentFrom returns the pending and queued tracked transactions of the account
+func (p *submittedTxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	p.mutex.Lock()
+	txs := p.txsFromLockHeld(addr)
+	p.mutex.Unlock()
+
+	var stateNonce uint64
+	if statedb, err := p.bc.State(); err == nil {
+		stateNonce = statedb.GetNonce(addr)
+	}
+	return splitByNonce(txs, stateNonce)
+}
+
+// txsFromLockHeld returns the tracked transactions of the account sorted by nonce.
+// lock must be held when calling that
+func (p *submittedTxPool) txsFromLockHeld(addr common.Address) types.Transactions {
+	nonces := p.byAccount[addr]
+	txs := make(types.Transactions, 0, len(nonces))
+	for _, entry := range nonces {
+		txs = append(txs, entry.tx)
+	}
+	sort.Sort(types.TxByNonce(txs))
+	return txs
+}
+
+// splitByNonce splits the transactions sorted by nonce into the pending ones, continuing
+// from the state nonce, and the queued ones, skipping those already included
+func splitByNonce(txs types.Transactions, stateNonce uint64) (types.Transactions, types.Transactions) {
+	for len(txs) > 0 && txs[0].Nonce() < stateNonce {
+		txs = txs[1:]
+	}
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	next := stateNonce
+	var pending types.Transactions
+	for len(txs) > 0 && txs[0].Nonce() == next {
+		pending = append(pending, txs[0])
+		txs = txs[1:]
+		next++
+	}
+	return pending, txs
+}
+
+// Stats returns the number of pending and queued tracked transactions
+func (p *submittedTxPool) Stats() (int, int) {
+	pending, queued := p.Content()
+	var pendingCount, queuedCount int
+	for _, txs := range pending {
+		pendingCount += len(txs)
+	}
+	for _, txs := range queued {
+		queuedCount += len(txs)
 	}
+	return pendingCount, queuedCount
 }
@@ -0,0 +1,117 @@
+package arbitrum
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func newSubmittedTestTx(t *testing.T, chain *testChain, nonce uint64) *types.Transaction {
+	t.Helper()
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x02}, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee), nil), chain.signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func checkNonces(t *testing.T, txs types.Transactions, want ...uint64) {
+	t.Helper()
+	if len(txs) != len(want) {
+		t.Fatalf("have %d transactions, want nonces %v", len(txs), want)
+	}
+	for i, tx := range txs {
+		if tx.Nonce() != want[i] {
+			t.Errorf("transaction %d: have nonce %d, want %d", i, tx.Nonce(), want[i])
+		}
+	}
+}
+
+func TestSubmittedTxPool(t *testing.T) {
+	chain := newTestChain(t, 4, nil) // testAddress has nonce 4
+	pool := newSubmittedTxPool(chain.bc, time.Hour)
+	txs := types.Transactions{newSubmittedTestTx(t, chain, 2), newSubmittedTestTx(t, chain, 3), newSubmittedTestTx(t, chain, 4), newSubmittedTestTx(t, chain, 5), newSubmittedTestTx(t, chain, 7)}
+	pool.add(txs)
+
+	// reads don't prune, but serve included transactions as neither pending nor queued
+	if pool.Get(txs[0].Hash()) == nil {
+		t.Error("submitted transaction not found")
+	}
+	pending, queued := pool.ContentFrom(testAddress)
+	checkNonces(t, pending, 4, 5)
+	checkNonces(t, queued, 7)
+	if nonce := pool.Nonce(testAddress, 4); nonce != 6 {
+		t.Errorf("have pool nonce %d, want 6", nonce)
+	}
+
+	// the state of a head drops the transactions it included
+	pool.pruneIncluded(chain.blocks[2].Root())
+	if pool.Get(txs[0].Hash()) != nil || pool.Get(txs[1].Hash()) == nil {
+		t.Error("pruned transactions other than the included one")
+	}
+	pool.pruneIncluded(chain.blocks[3].Root())
+	if len(pool.all) != 3 {
+		t.Errorf("have %d tracked transactions, want 3", len(pool.all))
+	}
+	pendingAll, queuedAll := pool.Content()
+	checkNonces(t, pendingAll[testAddress], 4, 5)
+	checkNonces(t, queuedAll[testAddress], 7)
+
+	// replacing a transaction tracks the replacement only
+	replacement, err := types.SignTx(types.NewTransaction(5, common.Address{0x03}, big.NewInt(1), params.TxGas, big.NewInt(2*params.InitialBaseFee), nil), chain.signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.add(types.Transactions{replacement})
+	if pool.Get(txs[3].Hash()) != nil || pool.Get(replacement.Hash()) == nil {
+		t.Error("replaced transaction still tracked")
+	}
+
+	// expired transactions are dropped on the next prune
+	pool.all[txs[4].Hash()].time = time.Now().Add(-2 * time.Hour)
+	pool.pruneIncluded(chain.blocks[3].Root())
+	if pool.Get(txs[4].Hash()) != nil {
+		t.Error("expired transaction still tracked")
+	}
+}
+
+func TestSubmittedTxPoolPrunesOnNewHead(t *testing.T) {
+	var (
+		chain  = newTestChain(t, 2, nil)
+		blocks = newTestChain(t, 4, nil).blocks // the same chain, two blocks longer
+		pool   = newSubmittedTxPool(chain.bc, time.Hour)
+	)
+	pool.start()
+	defer pool.stop()
+
+	included, pending := newSubmittedTestTx(t, chain, 2), newSubmittedTestTx(t, chain, 4)
+	pool.add(types.Transactions{included, pending})
+	if _, err := chain.bc.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("failed to extend chain: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); pool.Get(included.Hash()) != nil; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("included transaction not pruned on new head")
+		}
+	}
+	if pool.Get(pending.Hash()) == nil {
+		t.Error("pending transaction pruned")
+	}
+}
+
+func TestSubmittedTxPoolDisabled(t *testing.T) {
+	chain := newTestChain(t, 1, nil)
+	pool := newSubmittedTxPool(chain.bc, 0)
+	pool.start()
+	defer pool.stop()
+
+	tx := newSubmittedTestTx(t, chain, 1)
+	pool.add(types.Transactions{tx})
+	if pool.Get(tx.Hash()) != nil {
+		t.Error("transaction tracked with tracking disabled")
+	}
+}
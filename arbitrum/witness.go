@@ -0,0 +1,282 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// WitnessVersion is the version of the encoding written by EncodeBlockWitness
+const WitnessVersion = 1
+
+// BlockWitness is everything needed to execute a block without access to a database
+type BlockWitness struct {
+	Block     *types.Block
+	Headers   []*types.Header        // headers accessed by the block, in order up to its parent
+	Preimages map[common.Hash][]byte // trie nodes and code read while executing the block
+	UserWasms state.UserWasms        // Stylus programs executed by the block
+}
+
+// Parent returns the header of the block the witness executes on top of
+func (w *BlockWitness) Parent() *types.Header {
+	return w.Headers[len(w.Headers)-1]
+}
+
+// BlockRange returns the range of blocks whose headers the witness carries
+func (w *BlockWitness) BlockRange() (uint64, uint64) {
+	return w.Headers[0].Number.Uint64(), w.Parent().Number.Uint64()
+}
+
+// PreimageMap returns the preimages along with the RLP encoded headers, as
+// produced by RecordingDatabase.PreimagesFromRecording
+func (w *BlockWitness) PreimageMap() (map[common.Hash][]byte, error) {
+	preimages := make(map[common.Hash][]byte, len(w.Preimages)+len(w.Headers))
+	for hash, preimage := range w.Preimages {
+		preimages[hash] = preimage
+	}
+	for _, header := range w.Headers {
+		enc, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, fmt.Errorf("error RLP encoding header: %w", err)
+		}
+		preimages[header.Hash()] = enc
+	}
+	return preimages, nil
+}
+
+type witnessEnvelope struct {
+	Version uint64
+	Payload rlp.RawValue
+}
+
+type witnessPayloadV1 struct {
+	Block     *types.Block
+	Headers   []*types.Header
+	Preimages [][]byte // sorted by hash
+	UserWasms []witnessWasm
+}
+
+type witnessWasm struct {
+	ModuleHash common.Hash
+	Module     []byte
+	Asm        []witnessAsm
+}
+
+type witnessAsm struct {
+	Target string
+	Asm    []byte
+}
+
+// EncodeBlockWitness serializes a witness. The encoding is deterministic and
+// prefixed with its version, so that witnesses can be stored and compared.
+func EncodeBlockWitness(w *BlockWitness) ([]byte, error) {
+	if w.Block == nil || len(w.Headers) == 0 {
+		return nil, errors.New("incomplete block witness")
+	}
+	payload := witnessPayloadV1{
+		Block:     w.Block,
+		Headers:   w.Headers,
+		Preimages: make([][]byte, 0, len(w.Preimages)),
+		UserWasms: make([]witnessWasm, 0, len(w.UserWasms)),
+	}
+	hashes := make([]common.Hash, 0, len(w.Preimages))
+	for hash := range w.Preimages {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	for _, hash := range hashes {
+		payload.Preimages = append(payload.Preimages, w.Preimages[hash])
+	}
+	for moduleHash, wasm := range w.UserWasms {
+		entry := witnessWasm{ModuleHash: moduleHash, Module: wasm.Module}
+		for target, asm := range wasm.Asm {
+			entry.Asm = append(entry.Asm, witnessAsm{Target: string(target), Asm: asm})
+		}
+		sort.Slice(entry.Asm, func(i, j int) bool { return entry.Asm[i].Target < entry.Asm[j].Target })
+		payload.UserWasms = append(payload.UserWasms, entry)
+	}
+	sort.Slice(payload.UserWasms, func(i, j int) bool {
+		return bytes.Compare(payload.UserWasms[i].ModuleHash[:], payload.UserWasms[j].ModuleHash[:]) < 0
+	})
+	enc, err := rlp.EncodeToBytes(&payload)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(&witnessEnvelope{Version: WitnessVersion, Payload: enc})
+}
+
+// DecodeBlockWitness deserializes a witness written by EncodeBlockWitness,
+// checking that its headers link up to the block.
+func DecodeBlockWitness(data []byte) (*BlockWitness, error) {
+	var envelope witnessEnvelope
+	if err := rlp.DecodeBytes(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid block witness: %w", err)
+	}
+	if envelope.Version != WitnessVersion {
+		return nil, fmt.Errorf("unsupported block witness version %d, expected %d", envelope.Version, WitnessVersion)
+	}
+	var payload witnessPayloadV1
+	if err := rlp.DecodeBytes(envelope.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid block witness: %w", err)
+	}
+	if len(payload.Headers) == 0 {
+		return nil, errors.New("block witness has no headers")
+	}
+	for i := 1; i < len(payload.Headers); i++ {
+		if payload.Headers[i].ParentHash != payload.Headers[i-1].Hash() {
+			return nil, fmt.Errorf("block witness header %d doesn't link to its parent", payload.Headers[i].Number)
+		}
+	}
+	if parent := payload.Headers[len(payload.Headers)-1]; payload.Block.ParentHash() != parent.Hash() {
+		return nil, fmt.Errorf("block witness headers don't link to block %d", payload.Block.NumberU64())
+	}
+	witness := &BlockWitness{
+		Block:     payload.Block,
+		Headers:   payload.Headers,
+		Preimages: make(map[common.Hash][]byte, len(payload.Preimages)),
+		UserWasms: make(state.UserWasms, len(payload.UserWasms)),
+	}
+	for _, preimage := range payload.Preimages {
+		witness.Preimages[crypto.Keccak256Hash(preimage)] = preimage
+	}
+	for _, entry := range payload.UserWasms {
+		wasm := state.ActivatedWasm{Asm: make(map[rawdb.WasmTarget][]byte, len(entry.Asm)), Module: entry.Module}
+		for _, asm := range entry.Asm {
+			wasm.Asm[rawdb.WasmTarget(asm.Target)] = asm.Asm
+		}
+		witness.UserWasms[entry.ModuleHash] = wasm
+	}
+	return witness, nil
+}
+
+// ExecuteBlock applies the transactions of a block on top of the statedb,
+// using the chain context to resolve the headers the block accesses and
+// its engine to resolve the author. Arbitrum blocks carry no rewards, so
+// the engine isn't asked to finalize the block.
+// The caller is expected to check the resulting root.
+func ExecuteBlock(config *params.ChainConfig, chain core.ChainContext, statedb *state.StateDB, block *types.Block, cfg vm.Config) (types.Receipts, error) {
+	var (
+		receipts types.Receipts
+		usedGas  uint64
+		header   = block.Header()
+		gp       = new(core.GasPool).AddGas(block.GasLimit())
+	)
+	for i, tx := range block.Transactions() {
+		statedb.SetTxContext(tx.Hash(), i)
+		receipt, _, err := core.ApplyTransaction(config, chain, nil, gp, statedb, header, tx, &usedGas, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// RecordBlockWitness executes a block on top of a recording of its parent's
+// state, collecting the witness needed to execute it again without a database.
+func (r *RecordingDatabase) RecordBlockWitness(ctx context.Context, block *types.Block) (*BlockWitness, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("cannot record the witness of the genesis block")
+	}
+	parent := r.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block %d not found", block.NumberU64())
+	}
+	statedb, chainContext, recordingKV, err := r.PrepareRecording(ctx, parent, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Dereference(parent)
+
+	if _, err := ExecuteBlock(r.bc.Config(), chainContext, statedb, block, vm.Config{}); err != nil {
+		return nil, err
+	}
+	if root := statedb.IntermediateRoot(true); root != block.Root() {
+		return nil, fmt.Errorf("bad root hash for block %d expected: %v got: %v", block.NumberU64(), block.Root(), root)
+	}
+	headers, err := r.headersFromRecording(chainContext)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockWitness{
+		Block:     block,
+		Headers:   headers,
+		Preimages: recordingKV.GetRecordedEntries(),
+		UserWasms: statedb.UserWasms(),
+	}, nil
+}
+
+// witnessChainContext resolves headers from the preimages of a witness
+type witnessChainContext struct {
+	db     *state.StatelessDatabase
+	engine consensus.Engine
+}
+
+func (c *witnessChainContext) Engine() consensus.Engine {
+	return c.engine
+}
+
+func (c *witnessChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	enc, err := c.db.Preimage(hash)
+	if err != nil {
+		return nil
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(enc, header); err != nil || header.Number.Uint64() != number {
+		log.Warn("Invalid header preimage in witness", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return header
+}
+
+// ExecuteBlockWitness executes the block of a witness purely from the witness,
+// without access to a database, and checks the resulting state root. The engine
+// is that of the chain, or a faker resolving the same authors. If the witness
+// is incomplete, the error names the first preimage or user wasm missing.
+func ExecuteBlockWitness(config *params.ChainConfig, engine consensus.Engine, witness *BlockWitness, cfg vm.Config) (types.Receipts, error) {
+	if engine == nil {
+		return nil, errors.New("no consensus engine to execute the block witness with")
+	}
+	preimages, err := witness.PreimageMap()
+	if err != nil {
+		return nil, err
+	}
+	db := state.NewStatelessDatabase(preimages, witness.UserWasms)
+	statedb, err := state.NewDeterministic(witness.Parent().Root, db)
+	if err != nil {
+		if missing := db.MissingErr(); missing != nil {
+			return nil, missing
+		}
+		return nil, err
+	}
+	block := witness.Block
+	receipts, err := ExecuteBlock(config, &witnessChainContext{db, engine}, statedb, block, cfg)
+	root := statedb.IntermediateRoot(true)
+	if missing := db.MissingErr(); missing != nil {
+		return nil, missing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	if root != block.Root() {
+		return nil, fmt.Errorf("bad root hash for block %d expected: %v got: %v", block.NumberU64(), block.Root(), root)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,160 @@
+package arbitrum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestBlockWitnessEncoding(t *testing.T) {
+	chain := newRewardlessTestChain(t, 3, nil)
+	preimages := make(map[common.Hash][]byte)
+	for _, preimage := range [][]byte{{0x01}, {0x02, 0x03}, {}} {
+		preimages[crypto.Keccak256Hash(preimage)] = preimage
+	}
+	witness := &BlockWitness{
+		Block:     chain.blocks[2],
+		Headers:   []*types.Header{chain.blocks[0].Header(), chain.blocks[1].Header()},
+		Preimages: preimages,
+		UserWasms: state.UserWasms{
+			common.HexToHash("0x02"): {Asm: map[rawdb.WasmTarget][]byte{rawdb.TargetWavm: {0xa}, rawdb.TargetArm64: {0xb}}, Module: []byte{0xc}},
+			common.HexToHash("0x01"): {Asm: map[rawdb.WasmTarget][]byte{rawdb.TargetAmd64: {0xd}}, Module: []byte{0xe}},
+		},
+	}
+	enc, err := EncodeBlockWitness(witness)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	decoded, err := DecodeBlockWitness(enc)
+	if err != nil {
+		t.Fatalf("failed to decode witness: %v", err)
+	}
+	if decoded.Block.Hash() != witness.Block.Hash() {
+		t.Errorf("have block %v, want %v", decoded.Block.Hash(), witness.Block.Hash())
+	}
+	if len(decoded.Headers) != len(witness.Headers) {
+		t.Fatalf("have %d headers, want %d", len(decoded.Headers), len(witness.Headers))
+	}
+	for i, header := range decoded.Headers {
+		if header.Hash() != witness.Headers[i].Hash() {
+			t.Errorf("header %d: have %v, want %v", i, header.Hash(), witness.Headers[i].Hash())
+		}
+	}
+	if !reflect.DeepEqual(decoded.Preimages, witness.Preimages) {
+		t.Errorf("have preimages %x, want %x", decoded.Preimages, witness.Preimages)
+	}
+	if !reflect.DeepEqual(decoded.UserWasms, witness.UserWasms) {
+		t.Errorf("have user wasms %v, want %v", decoded.UserWasms, witness.UserWasms)
+	}
+	if first, last := decoded.BlockRange(); first != 1 || last != 2 {
+		t.Errorf("have block range [%d, %d], want [1, 2]", first, last)
+	}
+	// the encoding doesn't depend on map iteration order
+	reencoded, err := EncodeBlockWitness(decoded)
+	if err != nil {
+		t.Fatalf("failed to encode decoded witness: %v", err)
+	}
+	if !bytes.Equal(reencoded, enc) {
+		t.Error("encoding of the decoded witness differs")
+	}
+
+	// witnesses of other versions or whose headers don't link up are rejected
+	var envelope witnessEnvelope
+	if err := rlp.DecodeBytes(enc, &envelope); err != nil {
+		t.Fatalf("failed to decode envelope: %v", err)
+	}
+	envelope.Version++
+	future, _ := rlp.EncodeToBytes(&envelope)
+	if _, err := DecodeBlockWitness(future); err == nil || !strings.Contains(err.Error(), "unsupported block witness version") {
+		t.Errorf("have error %v decoding a future version", err)
+	}
+	unlinked := *witness
+	unlinked.Headers = []*types.Header{chain.blocks[1].Header(), chain.blocks[0].Header()}
+	if enc, err := EncodeBlockWitness(&unlinked); err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	} else if _, err := DecodeBlockWitness(enc); err == nil {
+		t.Error("decoded a witness with unlinked headers")
+	}
+	if _, err := EncodeBlockWitness(&BlockWitness{Block: witness.Block}); err == nil {
+		t.Error("encoded a witness without headers")
+	}
+}
+
+func TestRecordBlockWitness(t *testing.T) {
+	var (
+		chain = newRewardlessTestChain(t, 3, nil)
+		block = chain.blocks[2]
+		api   = NewArbDebugAPI(chain.apiBackend(DefaultConfig))
+	)
+	enc, err := api.RecordBlockWitness(context.Background(), rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+	if err != nil {
+		t.Fatalf("failed to record witness: %v", err)
+	}
+	witness, err := DecodeBlockWitness(enc)
+	if err != nil {
+		t.Fatalf("failed to decode recorded witness: %v", err)
+	}
+	if witness.Block.Hash() != block.Hash() || witness.Parent().Hash() != block.ParentHash() {
+		t.Fatalf("have witness of block %v on %v, want %v on %v", witness.Block.Hash(), witness.Parent().Hash(), block.Hash(), block.ParentHash())
+	}
+	preimages, err := witness.PreimageMap()
+	if err != nil {
+		t.Fatalf("failed to get preimages: %v", err)
+	}
+	if _, ok := preimages[witness.Parent().Root]; !ok {
+		t.Error("witness lacks the root node of the parent state")
+	}
+	if _, ok := preimages[block.ParentHash()]; !ok {
+		t.Error("witness lacks the parent header")
+	}
+	// the recording database is the backend's, and releases the parent's state
+	if refs := api.b.b.RecordingDatabase().ReferenceCount(); refs != 0 {
+		t.Errorf("have %d references to recorded states, want 0", refs)
+	}
+	if _, err := api.RecordBlockWitness(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Error("recorded a witness of the genesis block")
+	}
+}
+
+func TestExecuteBlockWitness(t *testing.T) {
+	var (
+		chain  = newRewardlessTestChain(t, 3, nil)
+		block  = chain.blocks[2]
+		config = chain.bc.Config()
+	)
+	witness, err := NewRecordingDatabase(&DefaultRecordingDatabaseConfig, chain.db, chain.bc).RecordBlockWitness(context.Background(), block)
+	if err != nil {
+		t.Fatalf("failed to record witness: %v", err)
+	}
+	receipts, err := ExecuteBlockWitness(config, chain.bc.Engine(), witness, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to execute witness: %v", err)
+	}
+	if len(receipts) != len(block.Transactions()) || types.DeriveSha(receipts, trie.NewStackTrie(nil)) != block.ReceiptHash() {
+		t.Errorf("have %d receipts not matching the block's", len(receipts))
+	}
+	if _, err := ExecuteBlockWitness(config, nil, witness, vm.Config{}); err == nil {
+		t.Error("executed a witness without an engine")
+	}
+
+	// the witness is incomplete without the root node of the parent state
+	root := witness.Parent().Root
+	delete(witness.Preimages, root)
+	var missing *state.MissingPreimageError
+	if _, err := ExecuteBlockWitness(config, chain.bc.Engine(), witness, vm.Config{}); !errors.As(err, &missing) || missing.Hash != root {
+		t.Errorf("have error %v, want the state root %v missing", err, root)
+	}
+}
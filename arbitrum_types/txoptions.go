@@ -3,6 +3,7 @@ package arbitrum_types
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -68,27 +69,85 @@ func (r RootHashOrSlots) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.SlotValue)
 }
 
+// AccountConditions are conditions on the state of an account. Nonce and
+// CodeHash must match exactly, while the balance must lie within the bounds.
+type AccountConditions struct {
+	Nonce      *hexutil.Uint64 `json:"nonce,omitempty"`
+	BalanceMin *hexutil.Big    `json:"balanceMin,omitempty"`
+	BalanceMax *hexutil.Big    `json:"balanceMax,omitempty"`
+	CodeHash   *common.Hash    `json:"codeHash,omitempty"`
+}
+
 type ConditionalOptions struct {
-	KnownAccounts  map[common.Address]RootHashOrSlots `json:"knownAccounts"`
-	BlockNumberMin *hexutil.Uint64                    `json:"blockNumberMin,omitempty"`
-	BlockNumberMax *hexutil.Uint64                    `json:"blockNumberMax,omitempty"`
-	TimestampMin   *hexutil.Uint64                    `json:"timestampMin,omitempty"`
-	TimestampMax   *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+	KnownAccounts    map[common.Address]RootHashOrSlots   `json:"knownAccounts"`
+	AccountsState    map[common.Address]AccountConditions `json:"accountsState,omitempty"`
+	BlockNumberMin   *hexutil.Uint64                      `json:"blockNumberMin,omitempty"`
+	BlockNumberMax   *hexutil.Uint64                      `json:"blockNumberMax,omitempty"`
+	L2BlockNumberMin *hexutil.Uint64                      `json:"l2BlockNumberMin,omitempty"`
+	L2BlockNumberMax *hexutil.Uint64                      `json:"l2BlockNumberMax,omitempty"`
+	TimestampMin     *hexutil.Uint64                      `json:"timestampMin,omitempty"`
+	TimestampMax     *hexutil.Uint64                      `json:"timestampMax,omitempty"`
 }
 
-func (o *ConditionalOptions) Check(l1BlockNumber uint64, l2Timestamp uint64, statedb *state.StateDB) error {
+// CheckedSlots returns how many state entries checking the options reads, where
+// each storage slot, storage root and account with conditions counts as one.
+func (o *ConditionalOptions) CheckedSlots() int {
+	slots := len(o.AccountsState)
+	for _, rootHashOrSlots := range o.KnownAccounts {
+		if rootHashOrSlots.RootHash != nil {
+			slots++
+		} else {
+			slots += len(rootHashOrSlots.SlotValue)
+		}
+	}
+	return slots
+}
+
+// CheckLimits rejects options checking more than maxSlots state entries (0 = unlimited).
+func (o *ConditionalOptions) CheckLimits(maxSlots int) error {
+	if maxSlots > 0 {
+		if slots := o.CheckedSlots(); slots > maxSlots {
+			return NewLimitExceededError(fmt.Sprintf("conditional options check %d slots, exceeding the limit of %d", slots, maxSlots))
+		}
+	}
+	return nil
+}
+
+// CheckAt checks the options against the L1 block number, the L2 block number and
+// timestamp of the block the tx would be included in, and the state.
+func (o *ConditionalOptions) CheckAt(l1BlockNumber uint64, l2BlockNumber uint64, l2Timestamp uint64, statedb *state.StateDB) error {
 	if o.BlockNumberMin != nil && l1BlockNumber < uint64(*o.BlockNumberMin) {
 		return NewRejectedError("BlockNumberMin condition not met")
 	}
 	if o.BlockNumberMax != nil && l1BlockNumber > uint64(*o.BlockNumberMax) {
 		return NewRejectedError("BlockNumberMax condition not met")
 	}
+	if o.L2BlockNumberMin != nil && l2BlockNumber < uint64(*o.L2BlockNumberMin) {
+		return NewRejectedError("L2BlockNumberMin condition not met")
+	}
+	if o.L2BlockNumberMax != nil && l2BlockNumber > uint64(*o.L2BlockNumberMax) {
+		return NewRejectedError("L2BlockNumberMax condition not met")
+	}
 	if o.TimestampMin != nil && l2Timestamp < uint64(*o.TimestampMin) {
 		return NewRejectedError("TimestampMin condition not met")
 	}
 	if o.TimestampMax != nil && l2Timestamp > uint64(*o.TimestampMax) {
 		return NewRejectedError("TimestampMax condition not met")
 	}
+	for address, conditions := range o.AccountsState {
+		if conditions.Nonce != nil && statedb.GetNonce(address) != uint64(*conditions.Nonce) {
+			return NewRejectedError("Nonce condition not met")
+		}
+		if conditions.BalanceMin != nil && statedb.GetBalance(address).Cmp(conditions.BalanceMin.ToInt()) < 0 {
+			return NewRejectedError("BalanceMin condition not met")
+		}
+		if conditions.BalanceMax != nil && statedb.GetBalance(address).Cmp(conditions.BalanceMax.ToInt()) > 0 {
+			return NewRejectedError("BalanceMax condition not met")
+		}
+		if conditions.CodeHash != nil && statedb.GetCodeHash(address) != *conditions.CodeHash {
+			return NewRejectedError("CodeHash condition not met")
+		}
+	}
 	for address, rootHashOrSlots := range o.KnownAccounts {
 		if rootHashOrSlots.RootHash != nil {
 			trie, err := statedb.StorageTrie(address)
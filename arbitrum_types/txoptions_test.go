@@ -0,0 +1,103 @@
+package arbitrum_types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testAccount = common.Address{0x01}
+	testCode    = []byte{0x60, 0x00}
+)
+
+func newTestState(t *testing.T) *state.StateDB {
+	t.Helper()
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.SetNonce(testAccount, 3)
+	statedb.SetBalance(testAccount, big.NewInt(100))
+	statedb.SetCode(testAccount, testCode)
+	return statedb
+}
+
+func isRejected(err error) bool {
+	var rejected *rejectedError
+	return errors.As(err, &rejected)
+}
+
+func uint64Ptr(n uint64) *hexutil.Uint64 {
+	return (*hexutil.Uint64)(&n)
+}
+
+func TestCheckAccountsState(t *testing.T) {
+	statedb := newTestState(t)
+	codeHash := crypto.Keccak256Hash(testCode)
+	otherHash := common.Hash{0x01}
+	for i, test := range []struct {
+		conditions AccountConditions
+		met        bool
+	}{
+		{AccountConditions{}, true},
+		{AccountConditions{Nonce: uint64Ptr(3), BalanceMin: (*hexutil.Big)(big.NewInt(100)), BalanceMax: (*hexutil.Big)(big.NewInt(100)), CodeHash: &codeHash}, true},
+		{AccountConditions{Nonce: uint64Ptr(2)}, false},
+		{AccountConditions{BalanceMin: (*hexutil.Big)(big.NewInt(101))}, false},
+		{AccountConditions{BalanceMax: (*hexutil.Big)(big.NewInt(99))}, false},
+		{AccountConditions{CodeHash: &otherHash}, false},
+	} {
+		options := &ConditionalOptions{AccountsState: map[common.Address]AccountConditions{testAccount: test.conditions}}
+		err := options.CheckAt(0, 0, 0, statedb)
+		if test.met && err != nil {
+			t.Errorf("test %d: conditions not met: %v", i, err)
+		}
+		if !test.met && !isRejected(err) {
+			t.Errorf("test %d: have error %v, want rejection", i, err)
+		}
+	}
+}
+
+func TestCheckL2BlockNumber(t *testing.T) {
+	statedb := newTestState(t)
+	options := &ConditionalOptions{L2BlockNumberMin: uint64Ptr(10), L2BlockNumberMax: uint64Ptr(20)}
+	for number, met := range map[uint64]bool{9: false, 10: true, 20: true, 21: false} {
+		err := options.CheckAt(0, number, 0, statedb)
+		if met && err != nil {
+			t.Errorf("L2 block %d: conditions not met: %v", number, err)
+		}
+		if !met && !isRejected(err) {
+			t.Errorf("L2 block %d: have error %v, want rejection", number, err)
+		}
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	root := common.Hash{0x01}
+	options := &ConditionalOptions{
+		KnownAccounts: map[common.Address]RootHashOrSlots{
+			{0x01}: {RootHash: &root},
+			{0x02}: {SlotValue: map[common.Hash]common.Hash{{0x01}: {}, {0x02}: {}}},
+		},
+		AccountsState: map[common.Address]AccountConditions{{0x03}: {}},
+	}
+	if slots := options.CheckedSlots(); slots != 4 {
+		t.Fatalf("have %d checked slots, want 4", slots)
+	}
+	for _, maxSlots := range []int{0, 4, 5} {
+		if err := options.CheckLimits(maxSlots); err != nil {
+			t.Errorf("limit %d: have error %v", maxSlots, err)
+		}
+	}
+	var limitExceeded *limitExceededError
+	if err := options.CheckLimits(3); !errors.As(err, &limitExceeded) {
+		t.Errorf("have error %v, want limit exceeded", err)
+	}
+}
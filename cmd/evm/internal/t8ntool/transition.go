@@ -180,6 +180,8 @@ func Transition(ctx *cli.Context) error {
 
 	vmConfig := vm.Config{
 		Tracer: tracer,
+		// Arbitrum: run Stylus programs with the reference executor
+		NewWasmExecutor: func(*vm.EVM) vm.WasmExecutor { return vm.NewReferenceWasmExecutor() },
 	}
 	// Construct the chainconfig
 	var chainConfig *params.ChainConfig
@@ -219,6 +219,8 @@ func runCmd(ctx *cli.Context) error {
 		BlockNumber: new(big.Int).SetUint64(genesisConfig.Number),
 		EVMConfig: vm.Config{
 			Tracer: tracer,
+			// Arbitrum: run Stylus programs with the reference executor
+			NewWasmExecutor: func(*vm.EVM) vm.WasmExecutor { return vm.NewReferenceWasmExecutor() },
 		},
 	}
 
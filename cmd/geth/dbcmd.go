@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -42,6 +43,26 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	wasmTargetsFlag = &cli.StringSliceFlag{
+		Name:  "targets",
+		Usage: "Comma separated targets to keep activated asm for (wavm, arm64, amd64), validators need wavm",
+		Value: cli.NewStringSlice(string(rawdb.TargetWavm), string(rawdb.TargetArm64), string(rawdb.TargetAmd64)),
+	}
+	wasmCompilerVersionFlag = &cli.UintFlag{
+		Name:  "compiler-version",
+		Usage: "Compiler version that activated asm must have been produced with (0 = any)",
+	}
+	wasmUnversionedFlag = &cli.BoolFlag{
+		Name:  "unversioned",
+		Usage: "Treat asm written before versions were recorded as stale",
+	}
+	wasmDryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only report what would be deleted",
+	}
+)
+
 var (
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -69,6 +90,7 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbPruneWasmCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -193,6 +215,22 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabasePathFlags),
 		Description: "Shows metadata about the chain status.",
 	}
+	dbPruneWasmCmd = &cli.Command{
+		Action: pruneWasm,
+		Name:   "prune-wasm",
+		Usage:  "Delete orphaned, unreferenced and stale Stylus activations",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			wasmTargetsFlag,
+			wasmCompilerVersionFlag,
+			wasmUnversionedFlag,
+			wasmDryRunFlag,
+		}, utils.NetworkFlags, utils.DatabasePathFlags),
+		Description: `This command iterates the activated Stylus programs in the database and deletes:
+  - asm and metadata whose module is missing
+  - modules released by the canonical chain, along with their asm
+  - asm produced for targets not listed or another compiler version (the module is kept)`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -340,6 +378,30 @@ func checkStateContent(ctx *cli.Context) error {
 	return nil
 }
 
+func pruneWasm(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	var targets []rawdb.WasmTarget
+	for _, name := range ctx.StringSlice(wasmTargetsFlag.Name) {
+		target := rawdb.WasmTarget(name)
+		if !target.IsValid() {
+			return fmt.Errorf("invalid wasm target %q", name)
+		}
+		targets = append(targets, target)
+	}
+	db := utils.MakeChainDatabase(ctx, stack, ctx.Bool(wasmDryRunFlag.Name))
+	defer db.Close()
+
+	_, err := pruner.PruneWasm(db, pruner.WasmConfig{
+		Targets:         targets,
+		CompilerVersion: uint32(ctx.Uint(wasmCompilerVersionFlag.Name)),
+		Unversioned:     ctx.Bool(wasmUnversionedFlag.Name),
+		DryRun:          ctx.Bool(wasmDryRunFlag.Name),
+	})
+	return err
+}
+
 func showLeveldbStats(db ethdb.KeyValueStater) {
 	if stats, err := db.Stat("leveldb.stats"); err != nil {
 		log.Warn("Failed to read database stats", "error", err)
@@ -105,6 +105,10 @@ var (
 		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
 		utils.CachePreimagesFlag,
+		utils.CacheCheckpointBlocksFlag,
+		utils.CacheCheckpointGasFlag,
+		utils.CacheCheckpointRetentionFlag,
+		utils.CacheCheckpointMaxAgeFlag,
 		utils.CacheLogSizeFlag,
 		utils.FDLimitFlag,
 		utils.CryptoKZGFlag,
@@ -225,6 +229,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See witnesscmd.go
+		witnessCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -0,0 +1,162 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/arbitrum"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	witnessCommand = &cli.Command{
+		Name:        "witness",
+		Usage:       "A set of commands for block execution witnesses",
+		Description: "",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "record",
+				Usage:     "Record the execution witness of a block to a file",
+				ArgsUsage: "<blockNum|blockHash> <filename>",
+				Action:    recordWitness,
+				Flags:     flags.Merge([]cli.Flag{utils.CacheFlag}, utils.NetworkFlags, utils.DatabasePathFlags),
+				Description: `
+geth witness record <blockNum|blockHash> <filename>
+This command executes the block on top of a recording of its parent's state and
+writes the versioned encoding of its witness (preimages, headers, Stylus programs
+and the block itself) to the file.
+`,
+			},
+			{
+				Name:      "execute",
+				Usage:     "Execute a block from its witness, without a database",
+				ArgsUsage: "<filename>",
+				Action:    executeWitness,
+				Flags:     []cli.Flag{witnessChainConfigFlag},
+				Description: `
+geth witness execute --chainconfig <config.json> <filename>
+This command decodes a witness written by "geth witness record", executes its
+block solely from the witness and checks the resulting state root. If the
+witness is incomplete, the first missing preimage or Stylus program is reported.
+`,
+			},
+		},
+	}
+	witnessChainConfigFlag = &cli.StringFlag{
+		Name:     "chainconfig",
+		Usage:    "JSON file holding the chain config the block is executed with",
+		Required: true,
+	}
+)
+
+func recordWitness(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("expected 2 arguments (block and filename), got %d", ctx.NArg())
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer chain.Stop()
+
+	block, err := witnessBlock(chain, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	recordingDb := arbitrum.NewRecordingDatabase(&arbitrum.DefaultRecordingDatabaseConfig, db, chain)
+	witness, err := recordingDb.RecordBlockWitness(context.Background(), block)
+	if err != nil {
+		return err
+	}
+	enc, err := arbitrum.EncodeBlockWitness(witness)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ctx.Args().Get(1), enc, 0644); err != nil {
+		return err
+	}
+	first, last := witness.BlockRange()
+	log.Info("Recorded block witness", "number", block.NumberU64(), "hash", block.Hash(),
+		"preimages", len(witness.Preimages), "wasms", len(witness.UserWasms), "headers", fmt.Sprintf("%d-%d", first, last),
+		"size", common.StorageSize(len(enc)), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func executeWitness(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected 1 argument (filename), got %d", ctx.NArg())
+	}
+	configJson, err := os.ReadFile(ctx.String(witnessChainConfigFlag.Name))
+	if err != nil {
+		return err
+	}
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(configJson, config); err != nil {
+		return fmt.Errorf("invalid chain config: %w", err)
+	}
+	enc, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	witness, err := arbitrum.DecodeBlockWitness(enc)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	// Arbitrum blocks are authored by their coinbase, which is all the faker resolves
+	receipts, err := arbitrum.ExecuteBlockWitness(config, ethash.NewFaker(), witness, vm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to execute block %d from witness: %w", witness.Block.NumberU64(), err)
+	}
+	log.Info("Executed block from witness", "number", witness.Block.NumberU64(), "hash", witness.Block.Hash(),
+		"root", witness.Block.Root(), "txs", len(receipts), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// witnessBlock resolves a block number or hash argument
+func witnessBlock(chain *core.BlockChain, arg string) (*types.Block, error) {
+	var block *types.Block
+	if hashish(arg) {
+		block = chain.GetBlockByHash(common.HexToHash(arg))
+	} else {
+		number, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		block = chain.GetBlockByNumber(number)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", arg)
+	}
+	return block, nil
+}
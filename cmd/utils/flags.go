@@ -444,6 +444,27 @@ var (
 		Usage:    "Enable recording the SHA3/keccak preimages of trie keys",
 		Category: flags.PerfCategory,
 	}
+	// Arbitrum: state checkpoints bounding the recreation of pruned states
+	CacheCheckpointBlocksFlag = &cli.Uint64Flag{
+		Name:     "cache.checkpoint.blocks",
+		Usage:    "Number of blocks after which a state checkpoint is persisted (0 = disabled)",
+		Category: flags.PerfCategory,
+	}
+	CacheCheckpointGasFlag = &cli.Uint64Flag{
+		Name:     "cache.checkpoint.gas",
+		Usage:    "Amount of L2 gas after which a state checkpoint is persisted (0 = disabled)",
+		Category: flags.PerfCategory,
+	}
+	CacheCheckpointRetentionFlag = &cli.Uint64Flag{
+		Name:     "cache.checkpoint.retention",
+		Usage:    "Number of most recent state checkpoints retained (0 = all)",
+		Category: flags.PerfCategory,
+	}
+	CacheCheckpointMaxAgeFlag = &cli.DurationFlag{
+		Name:     "cache.checkpoint.maxage",
+		Usage:    "Block age after which a state checkpoint is no longer retained (0 = forever)",
+		Category: flags.PerfCategory,
+	}
 	CacheLogSizeFlag = &cli.IntFlag{
 		Name:     "cache.blocklogs",
 		Usage:    "Size (in number of blocks) of the log cache for filtering",
@@ -1713,6 +1734,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheLogSizeFlag.Name) {
 		cfg.FilterLogCacheSize = ctx.Int(CacheLogSizeFlag.Name)
 	}
+	if ctx.IsSet(CacheCheckpointBlocksFlag.Name) {
+		cfg.StateCheckpointBlocks = ctx.Uint64(CacheCheckpointBlocksFlag.Name)
+	}
+	if ctx.IsSet(CacheCheckpointGasFlag.Name) {
+		cfg.StateCheckpointGas = ctx.Uint64(CacheCheckpointGasFlag.Name)
+	}
+	if ctx.IsSet(CacheCheckpointRetentionFlag.Name) {
+		cfg.StateCheckpointRetention = ctx.Uint64(CacheCheckpointRetentionFlag.Name)
+	}
+	if ctx.IsSet(CacheCheckpointMaxAgeFlag.Name) {
+		cfg.StateCheckpointMaxAge = ctx.Duration(CacheCheckpointMaxAgeFlag.Name)
+	}
 	if !ctx.Bool(SnapshotFlag.Name) {
 		// If snap-sync is requested, this flag is also required
 		if cfg.SyncMode == downloader.SnapSync {
@@ -2149,6 +2182,12 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
+
+		// Arbitrum
+		StateCheckpointBlocks:    ctx.Uint64(CacheCheckpointBlocksFlag.Name),
+		StateCheckpointGas:       ctx.Uint64(CacheCheckpointGasFlag.Name),
+		StateCheckpointRetention: ctx.Uint64(CacheCheckpointRetentionFlag.Name),
+		StateCheckpointMaxAge:    ctx.Duration(CacheCheckpointMaxAgeFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
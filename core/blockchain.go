@@ -144,6 +144,12 @@ type CacheConfig struct {
 	TriesInMemory uint64        // Height difference before which a trie may not be garbage-collected
 	TrieRetention time.Duration // Time limit before which a trie may not be garbage-collected
 
+	// Arbitrum: persist state checkpoints to bound the recreation of historical states
+	StateCheckpointBlocks    uint64        // Number of blocks after which a state checkpoint is persisted (0 = disabled)
+	StateCheckpointGas       uint64        // Amount of L2 gas after which a state checkpoint is persisted (0 = disabled)
+	StateCheckpointRetention uint64        // Number of most recent state checkpoints retained (0 = all)
+	StateCheckpointMaxAge    time.Duration // Block age after which a state checkpoint is no longer retained (0 = forever)
+
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -190,6 +196,11 @@ type BlockChain struct {
 	triedb        *trie.Database                   // The database handler for maintaining trie nodes.
 	stateCache    state.Database                   // State database to reuse between imports (contains state cache)
 
+	// Arbitrum: state checkpoints
+	checkpointLock sync.RWMutex
+	checkpoints    []rawdb.StateCheckpoint // retained state checkpoints, oldest first
+	checkpointGas  uint64                  // L2 gas used since the last state checkpoint
+
 	// txLookupLimit is the maximum number of blocks from head whose tx indices
 	// are reserved:
 	//  * 0:   means no limit and regenerate any missing indexes
@@ -305,6 +316,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
+	bc.checkpoints = rawdb.ReadStateCheckpoints(db)
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
@@ -1448,6 +1460,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 	bc.triegc.Push(trieGcEntry{root, block.Header().Time}, -int64(block.NumberU64()))
 
+	// Arbitrum: persist the state if it's due for a checkpoint
+	if err := bc.maybeCheckpointState(block, receipts, root); err != nil {
+		return err
+	}
+
 	blockLimit := int64(block.NumberU64()) - int64(bc.cacheConfig.TriesInMemory)   // only cleared if below that
 	timeLimit := time.Now().Unix() - int64(bc.cacheConfig.TrieRetention.Seconds()) // only cleared if less than that
 
@@ -1538,6 +1555,8 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
+		// Arbitrum: only the canonical chain decides which Stylus modules are released
+		state.WriteWasmReleases(bc.db, block.NumberU64(), block.Hash())
 	}
 	bc.futureBlocks.Remove(block.Hash())
 
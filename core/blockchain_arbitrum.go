@@ -20,6 +20,8 @@ package core
 import (
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -77,3 +79,74 @@ func (bc *BlockChain) RecoverState(block *types.Block) error {
 	_, err := bc.recoverAncestors(block)
 	return err
 }
+
+// maybeCheckpointState persists the state of the block if enough blocks or L2 gas
+// went by since the last state checkpoint, and applies the retention policy to the
+// retained checkpoints. The L2 gas isn't tracked across restarts.
+func (bc *BlockChain) maybeCheckpointState(block *types.Block, receipts []*types.Receipt, root common.Hash) error {
+	blocks, gas := bc.cacheConfig.StateCheckpointBlocks, bc.cacheConfig.StateCheckpointGas
+	if blocks == 0 && gas == 0 {
+		return nil
+	}
+	for _, receipt := range receipts {
+		bc.checkpointGas += receipt.GasUsedForL2()
+	}
+	number := block.NumberU64()
+	lastNumber := bc.chainConfig.ArbitrumChainParams.GenesisBlockNum
+	bc.checkpointLock.RLock()
+	if len(bc.checkpoints) > 0 {
+		lastNumber = bc.checkpoints[len(bc.checkpoints)-1].Number
+	}
+	bc.checkpointLock.RUnlock()
+	if (blocks == 0 || number < lastNumber+blocks) && (gas == 0 || bc.checkpointGas < gas) {
+		return nil
+	}
+	if err := bc.triedb.Commit(root, false); err != nil {
+		return err
+	}
+	bc.checkpointGas = 0
+
+	bc.checkpointLock.Lock()
+	defer bc.checkpointLock.Unlock()
+	checkpoints := append(bc.checkpoints, rawdb.StateCheckpoint{
+		Number: number,
+		Hash:   block.Hash(),
+		Root:   root,
+		Time:   block.Time(),
+	})
+	if retention := bc.cacheConfig.StateCheckpointRetention; retention > 0 && uint64(len(checkpoints)) > retention {
+		checkpoints = checkpoints[uint64(len(checkpoints))-retention:]
+	}
+	if maxAge := uint64(bc.cacheConfig.StateCheckpointMaxAge.Seconds()); maxAge > 0 {
+		for len(checkpoints) > 1 && checkpoints[0].Time+maxAge < block.Time() {
+			checkpoints = checkpoints[1:]
+		}
+	}
+	// expired checkpoints are reclaimed by the next offline pruning
+	bc.checkpoints = append([]rawdb.StateCheckpoint(nil), checkpoints...)
+	rawdb.WriteStateCheckpoints(bc.db, bc.checkpoints)
+	log.Info("Persisted state checkpoint", "number", number, "hash", block.Hash(), "root", root, "retained", len(bc.checkpoints))
+	return nil
+}
+
+// StateCheckpoints returns the retained state checkpoints, oldest first.
+func (bc *BlockChain) StateCheckpoints() []rawdb.StateCheckpoint {
+	bc.checkpointLock.RLock()
+	defer bc.checkpointLock.RUnlock()
+	return append([]rawdb.StateCheckpoint(nil), bc.checkpoints...)
+}
+
+// NearestStateCheckpoint returns the header of the latest canonical state
+// checkpoint at or below the block number, or nil if there's none.
+func (bc *BlockChain) NearestStateCheckpoint(number uint64) *types.Header {
+	bc.checkpointLock.RLock()
+	defer bc.checkpointLock.RUnlock()
+	for i := len(bc.checkpoints) - 1; i >= 0; i-- {
+		checkpoint := bc.checkpoints[i]
+		if checkpoint.Number > number || rawdb.ReadCanonicalHash(bc.db, checkpoint.Number) != checkpoint.Hash {
+			continue
+		}
+		return bc.GetHeader(checkpoint.Hash, checkpoint.Number)
+	}
+	return nil
+}
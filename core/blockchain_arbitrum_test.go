@@ -0,0 +1,286 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/pruner"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	wasmActivator = common.Address{0xaa}
+	wasmReleaser  = common.Address{0xbb}
+	wasmModule    = common.HexToHash("0x01")
+)
+
+// wasmReferenceHook activates or releases wasmModule in the transactions of blocks
+// mined by wasmActivator or wasmReleaser respectively.
+type wasmReferenceHook struct {
+	vm.DefaultTxProcessor
+	evm *vm.EVM
+}
+
+func (h *wasmReferenceHook) EndTxHook(totalGasUsed uint64, evmSuccess bool) {
+	switch h.evm.Context.Coinbase {
+	case wasmActivator:
+		h.evm.StateDB.ActivateWasm(wasmModule, map[rawdb.WasmTarget][]byte{rawdb.LocalTarget(): {0xa}}, []byte{0xb}, 1)
+	case wasmReleaser:
+		h.evm.StateDB.ReleaseWasm(wasmModule)
+	}
+}
+
+func TestWasmReferencesFollowCanonicalChain(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer   = types.LatestSigner(gspec.Config)
+		coinbase = []common.Address{wasmActivator, wasmReleaser, wasmActivator, wasmReleaser}
+		vmConfig = vm.Config{
+			NewProcessingHook: func(evm *vm.EVM) vm.TxProcessingHook {
+				return &wasmReferenceHook{vm.NewDefaultTxProcessor(evm), evm}
+			},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), len(coinbase), func(i int, block *BlockGen) {
+		block.SetCoinbase(coinbase[i])
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, nil, gspec, nil, ethash.NewFaker(), vmConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// activated, released and activated again
+	if n, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if !rawdb.HasActivationReference(db, wasmModule) {
+		t.Fatal("module activated again isn't referenced")
+	}
+
+	// re-executing the historical release mustn't unreference the module
+	statedb, err := state.New(blocks[0].Root(), chain.StateCache(), nil)
+	if err != nil {
+		t.Fatalf("failed to recreate state: %v", err)
+	}
+	if _, _, _, err := chain.Processor().Process(blocks[1], statedb, vmConfig); err != nil {
+		t.Fatalf("failed to re-execute block: %v", err)
+	}
+	if _, err := statedb.Commit(true); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if !rawdb.HasActivationReference(db, wasmModule) {
+		t.Fatal("re-executing a historical release unreferenced the module")
+	}
+
+	// released by the canonical head, then reorged out
+	if n, err := chain.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if rawdb.HasActivationReference(db, wasmModule) {
+		t.Fatal("released module is still referenced")
+	}
+	if err := chain.ReorgToOldBlock(blocks[2]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	if !rawdb.HasActivationReference(db, wasmModule) {
+		t.Fatal("module released by a reorged out block isn't referenced")
+	}
+}
+
+func TestWasmReorgedActivationIsntPruned(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer   = types.LatestSigner(gspec.Config)
+		vmConfig = vm.Config{
+			NewProcessingHook: func(evm *vm.EVM) vm.TxProcessingHook {
+				return &wasmReferenceHook{vm.NewDefaultTxProcessor(evm), evm}
+			},
+		}
+	)
+	generate := func(coinbase []common.Address) []*types.Block {
+		_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), len(coinbase), func(i int, block *BlockGen) {
+			block.SetCoinbase(coinbase[i])
+			tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		})
+		return blocks
+	}
+	var (
+		canon = generate([]common.Address{{0x01}, {0x01}})
+		fork  = generate([]common.Address{wasmActivator, {0x02}, {0x02}})
+	)
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, nil, nil, gspec, nil, ethash.NewFaker(), vmConfig, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// the activation is imported as a side block, and only made canonical by a reorg
+	if n, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if n, err := chain.InsertChain(fork[:1]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if chain.CurrentBlock().Hash() != canon[1].Hash() {
+		t.Fatal("activation was imported as the head")
+	}
+	if n, err := chain.InsertChain(fork[1:]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if chain.CurrentBlock().Hash() != fork[2].Hash() {
+		t.Fatal("fork didn't become canonical")
+	}
+	if !rawdb.HasActivationReference(db, wasmModule) {
+		t.Fatal("module activated by a reorged in block isn't referenced")
+	}
+	config := pruner.WasmConfig{Targets: []rawdb.WasmTarget{rawdb.LocalTarget()}}
+	if _, err := pruner.PruneWasm(db, config); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if len(rawdb.ReadActivatedModule(db, wasmModule)) == 0 || len(rawdb.ReadActivatedAsm(db, rawdb.LocalTarget(), wasmModule)) == 0 {
+		t.Fatal("pruned a module activated by a reorged in block")
+	}
+}
+
+func TestStateCheckpoints(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(1000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	cacheConfig := *defaultCacheConfig
+	cacheConfig.SnapshotLimit = 0
+	cacheConfig.StateCheckpointBlocks = 3
+	cacheConfig.StateCheckpointRetention = 2
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, &cacheConfig, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+
+	// checkpoints every 3 blocks, retaining the 2 most recent
+	checkpoints := chain.StateCheckpoints()
+	if len(checkpoints) != 2 || checkpoints[0].Number != 6 || checkpoints[1].Number != 9 {
+		t.Fatalf("have checkpoints %v, want blocks 6 and 9", checkpoints)
+	}
+	if stored := rawdb.ReadStateCheckpoints(db); len(stored) != len(checkpoints) {
+		t.Errorf("have %d stored checkpoints, want %d", len(stored), len(checkpoints))
+	}
+	// only the states of checkpoints are on disk
+	diskdb := state.NewDatabase(db)
+	for _, number := range []uint64{6, 9} {
+		if _, err := state.New(blocks[number-1].Root(), diskdb, nil); err != nil {
+			t.Errorf("state of checkpoint %d isn't on disk: %v", number, err)
+		}
+	}
+	if _, err := state.New(blocks[7].Root(), diskdb, nil); err == nil {
+		t.Error("state of block 8 is on disk")
+	}
+
+	for number, want := range map[uint64]uint64{5: 0, 6: 6, 8: 6, 9: 9, 10: 9} {
+		header := chain.NearestStateCheckpoint(number)
+		switch {
+		case want == 0 && header != nil:
+			t.Errorf("block %d: have checkpoint %d, want none", number, header.Number)
+		case want != 0 && (header == nil || header.Hash() != blocks[want-1].Hash()):
+			t.Errorf("block %d: have checkpoint %v, want %d", number, header, want)
+		}
+	}
+
+	// checkpoints reorged out of the canonical chain aren't used
+	if err := chain.ReorgToOldBlock(blocks[6]); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+	if header := chain.NearestStateCheckpoint(9); header == nil || header.Hash() != blocks[5].Hash() {
+		t.Errorf("have checkpoint %v after reorg, want 6", header)
+	}
+}
+
+func TestStateCheckpointGasIgnoresL1Gas(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	cacheConfig := *defaultCacheConfig
+	cacheConfig.StateCheckpointGas = 2 * params.TxGas
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), &cacheConfig, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// the gas charged for L1 may exceed the gas used, leaving no L2 gas
+	genesis := chain.Genesis()
+	receipts := []*types.Receipt{{GasUsed: params.TxGas, GasUsedForL1: 30000}, {GasUsed: params.TxGas}}
+	if err := chain.maybeCheckpointState(genesis, receipts, genesis.Root()); err != nil {
+		t.Fatalf("failed to checkpoint state: %v", err)
+	}
+	if chain.checkpointGas != params.TxGas {
+		t.Errorf("have %d L2 gas since the last checkpoint, want %d", chain.checkpointGas, params.TxGas)
+	}
+	if checkpoints := chain.StateCheckpoints(); len(checkpoints) != 0 {
+		t.Errorf("have checkpoints %v, want none", checkpoints)
+	}
+}
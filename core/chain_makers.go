@@ -137,6 +137,12 @@ func (b *BlockGen) AddTxWithVMConfig(tx *types.Transaction, config vm.Config) {
 	b.addTx(nil, config, tx)
 }
 
+// AddTxWithChainAndVMConfig adds a transaction to the generated block like
+// AddTxWithChain, customizing the evm interpreter with the provided vm config.
+func (b *BlockGen) AddTxWithChainAndVMConfig(bc *BlockChain, tx *types.Transaction, config vm.Config) {
+	b.addTx(bc, config, tx)
+}
+
 // GetBalance returns the balance of the given address at the generated block.
 func (b *BlockGen) GetBalance(addr common.Address) *big.Int {
 	return b.statedb.GetBalance(addr)
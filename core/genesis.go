@@ -372,6 +372,12 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 	if compatErr != nil && ((head.Number.Uint64() != 0 && compatErr.RewindToBlock != 0) || (head.Time != 0 && compatErr.RewindToTime != 0)) {
 		return newcfg, stored, compatErr
 	}
+	// Arbitrum: Stylus pricing changes with the ArbOS version rather than at a block
+	if head.Number.Uint64() != 0 {
+		if err := storedcfg.CheckStylusGasSchedulesCompatible(newcfg, types.DeserializeHeaderExtraInformation(head).ArbOSFormatVersion); err != nil {
+			return newcfg, stored, err
+		}
+	}
 	// Don't overwrite if the old is identical to the new
 	if newData, _ := json.Marshal(newcfg); !bytes.Equal(storedData, newData) {
 		rawdb.WriteChainConfig(db, stored, newcfg)
@@ -0,0 +1,57 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// StateCheckpoint is a block whose state was persisted to bound state recreation.
+type StateCheckpoint struct {
+	Number uint64
+	Hash   common.Hash
+	Root   common.Hash
+	Time   uint64 // timestamp of the block
+}
+
+// ReadStateCheckpoints retrieves the retained state checkpoints, oldest first.
+func ReadStateCheckpoints(db ethdb.KeyValueReader) []StateCheckpoint {
+	data, _ := db.Get(stateCheckpointsKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var checkpoints []StateCheckpoint
+	if err := rlp.DecodeBytes(data, &checkpoints); err != nil {
+		log.Error("Invalid state checkpoints", "err", err)
+		return nil
+	}
+	return checkpoints
+}
+
+// WriteStateCheckpoints stores the retained state checkpoints, oldest first.
+func WriteStateCheckpoints(db ethdb.KeyValueWriter, checkpoints []StateCheckpoint) {
+	data, err := rlp.EncodeToBytes(checkpoints)
+	if err != nil {
+		log.Crit("Failed to encode state checkpoints", "err", err)
+	}
+	if err := db.Put(stateCheckpointsKey, data); err != nil {
+		log.Crit("Failed to store state checkpoints", "err", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RetryableTxEntry locates a transaction submitting or redeeming a retryable ticket,
+// along with its outcome.
+type RetryableTxEntry struct {
+	TxType      uint8
+	TxHash      common.Hash
+	BlockHash   common.Hash
+	BlockNumber uint64
+	TxIndex     uint64
+	Status      uint64
+	GasUsed     uint64
+}
+
+// ReadRetryableTxEntries retrieves the indexed transactions of a retryable ticket,
+// ordered by block and index. Entries of blocks that were reorged out may remain.
+func ReadRetryableTxEntries(db ethdb.Iteratee, ticketId common.Hash) []RetryableTxEntry {
+	prefix := append(append([]byte{}, retryableTxPrefix...), ticketId.Bytes()...)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var entries []RetryableTxEntry
+	for it.Next() {
+		var entry RetryableTxEntry
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil {
+			log.Error("Invalid retryable index entry", "ticket", ticketId, "err", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// WriteRetryableTxEntry stores a transaction of a retryable ticket into the index.
+func WriteRetryableTxEntry(db ethdb.KeyValueWriter, ticketId common.Hash, entry RetryableTxEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode retryable index entry", "err", err)
+	}
+	if err := db.Put(retryableTxKey(ticketId, entry.BlockNumber, entry.TxIndex), data); err != nil {
+		log.Crit("Failed to store retryable index entry", "err", err)
+	}
+}
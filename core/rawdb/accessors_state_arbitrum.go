@@ -17,20 +17,334 @@
 package rawdb
 
 import (
+	"bytes"
+	"runtime"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
-// Stores the activated asm and module for a given codeHash
-func WriteActivation(db ethdb.KeyValueWriter, moduleHash common.Hash, asm, module []byte) {
-	key := ActivatedAsmKey(moduleHash)
-	if err := db.Put(key[:], asm); err != nil {
-		log.Crit("Failed to store activated wasm asm", "err", err)
+// ActivationVersion identifies the compiler build and target an asm was produced for.
+type ActivationVersion struct {
+	Target   string
+	Compiler uint32
+	AsmHash  common.Hash `rlp:"optional"` // keccak of the asm, used to detect corruption
+}
+
+// Matches reports whether the asm is the one the version was recorded for.
+// Versions recorded without an asm hash match any asm.
+func (v *ActivationVersion) Matches(asm []byte) bool {
+	return v.AsmHash == (common.Hash{}) || v.AsmHash == crypto.Keccak256Hash(asm)
+}
+
+// LocalTarget returns the native target of the running host, or an empty
+// target if asm can't be produced for this architecture.
+func LocalTarget() WasmTarget {
+	target := WasmTarget(runtime.GOARCH)
+	if !target.IsValid() {
+		return ""
 	}
+	return target
+}
 
-	key = ActivatedModuleKey(moduleHash)
+// Stores the activated asm of each target and the module for a given moduleHash.
+// References are tracked separately, see WriteActivationReference.
+func WriteActivation(db ethdb.KeyValueWriter, moduleHash common.Hash, asmMap map[WasmTarget][]byte, module []byte, compilerVersion uint32) {
+	for target, asm := range asmMap {
+		WriteActivatedAsm(db, target, moduleHash, asm, compilerVersion)
+	}
+
+	key := ActivatedModuleKey(moduleHash)
 	if err := db.Put(key[:], module); err != nil {
 		log.Crit("Failed to store activated wasm module", "err", err)
 	}
 }
+
+// WriteActivatedAsm stores the asm of the given module for a single target along with its version.
+// The asm of unknown targets can't be stored and is dropped.
+func WriteActivatedAsm(db ethdb.KeyValueWriter, target WasmTarget, moduleHash common.Hash, asm []byte, compilerVersion uint32) {
+	key, err := ActivatedAsmKey(target, moduleHash)
+	if err != nil {
+		log.Error("Dropping activated wasm asm", "moduleHash", moduleHash, "err", err)
+		return
+	}
+	versionKey, _ := ActivatedVersionKey(target, moduleHash)
+	if err := db.Put(key[:], asm); err != nil {
+		log.Crit("Failed to store activated wasm asm", "err", err)
+	}
+	version := ActivationVersion{Target: string(target), Compiler: compilerVersion, AsmHash: crypto.Keccak256Hash(asm)}
+	data, err := rlp.EncodeToBytes(&version)
+	if err != nil {
+		log.Crit("Failed to encode activation version", "err", err)
+	}
+	if err := db.Put(versionKey[:], data); err != nil {
+		log.Crit("Failed to store activation version", "err", err)
+	}
+}
+
+// ReadActivatedAsm retrieves the activated asm of the given module for a target, if any.
+// Asm stored before it was keyed by target is returned if it was produced for the target.
+func ReadActivatedAsm(db ethdb.KeyValueReader, target WasmTarget, moduleHash common.Hash) []byte {
+	key, err := ActivatedAsmKey(target, moduleHash)
+	if err != nil {
+		return nil
+	}
+	if data, _ := db.Get(key[:]); len(data) > 0 {
+		return data
+	}
+	if legacyAsmTarget(db, moduleHash) != target {
+		return nil
+	}
+	legacyKey := LegacyActivatedAsmKey(moduleHash)
+	data, _ := db.Get(legacyKey[:])
+	return data
+}
+
+// ReadActivatedModule retrieves the activated module of the given module hash, if any.
+func ReadActivatedModule(db ethdb.KeyValueReader, moduleHash common.Hash) []byte {
+	key := ActivatedModuleKey(moduleHash)
+	data, _ := db.Get(key[:])
+	return data
+}
+
+// ReadActivationVersion retrieves the version the asm of a target was produced with.
+// Activations written before versions were recorded return nil.
+func ReadActivationVersion(db ethdb.KeyValueReader, target WasmTarget, moduleHash common.Hash) *ActivationVersion {
+	key, err := ActivatedVersionKey(target, moduleHash)
+	if err != nil {
+		return nil
+	}
+	if version := readActivationVersion(db, key[:]); version != nil {
+		return version
+	}
+	legacyKey := LegacyActivatedVersionKey(moduleHash)
+	if version := readActivationVersion(db, legacyKey[:]); version != nil && WasmTarget(version.Target) == target {
+		return version
+	}
+	return nil
+}
+
+func readActivationVersion(db ethdb.KeyValueReader, key []byte) *ActivationVersion {
+	data, _ := db.Get(key)
+	if len(data) == 0 {
+		return nil
+	}
+	var version ActivationVersion
+	if err := rlp.DecodeBytes(data, &version); err != nil {
+		log.Error("Invalid activation version RLP", "key", key, "err", err)
+		return nil
+	}
+	return &version
+}
+
+// legacyAsmTarget returns the target asm stored before it was keyed by target was
+// produced for. Asm without a recorded version is assumed to have been produced locally.
+func legacyAsmTarget(db ethdb.KeyValueReader, moduleHash common.Hash) WasmTarget {
+	key := LegacyActivatedVersionKey(moduleHash)
+	if version := readActivationVersion(db, key[:]); version != nil {
+		return WasmTarget(version.Target)
+	}
+	return LocalTarget()
+}
+
+// activationReferenced is the reference value of a module used by a live program.
+var activationReferenced = []byte{1}
+
+// ActivationRelease identifies the block which released a module.
+type ActivationRelease struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// HasActivationReference checks whether a live program references the given
+// module, as far as the canonical chain goes. A module released by a block
+// which was since reorged out is still referenced.
+func HasActivationReference(db ethdb.Reader, moduleHash common.Hash) bool {
+	key := ActivatedReferenceKey(moduleHash)
+	value, _ := db.Get(key[:])
+	if len(value) == 0 {
+		return false
+	}
+	release := decodeActivationRelease(moduleHash, value)
+	return release == nil || ReadCanonicalHash(db, release.Number) != release.Hash
+}
+
+// WriteActivationReference marks the given module as referenced by a live program.
+func WriteActivationReference(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	key := ActivatedReferenceKey(moduleHash)
+	if err := db.Put(key[:], activationReferenced); err != nil {
+		log.Crit("Failed to store activation reference", "err", err)
+	}
+}
+
+// WriteActivationRelease marks the given module as no longer referenced as of the
+// given block, making it eligible for pruning while the block is canonical.
+func WriteActivationRelease(db ethdb.KeyValueWriter, moduleHash common.Hash, number uint64, hash common.Hash) {
+	data, err := rlp.EncodeToBytes(&ActivationRelease{Number: number, Hash: hash})
+	if err != nil {
+		log.Crit("Failed to encode activation release", "err", err)
+	}
+	key := ActivatedReferenceKey(moduleHash)
+	if err := db.Put(key[:], data); err != nil {
+		log.Crit("Failed to store activation release", "err", err)
+	}
+}
+
+// DeleteActivationReference marks the given module as no longer referenced,
+// making it eligible for pruning regardless of the canonical chain.
+func DeleteActivationReference(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	key := ActivatedReferenceKey(moduleHash)
+	if err := db.Delete(key[:]); err != nil {
+		log.Crit("Failed to delete activation reference", "err", err)
+	}
+}
+
+// decodeActivationRelease returns the release stored as the reference value of a
+// module, or nil if the module is referenced.
+func decodeActivationRelease(moduleHash common.Hash, value []byte) *ActivationRelease {
+	if bytes.Equal(value, activationReferenced) {
+		return nil
+	}
+	release := new(ActivationRelease)
+	if err := rlp.DecodeBytes(value, release); err != nil {
+		log.Warn("Invalid activation release RLP", "moduleHash", moduleHash, "err", err)
+		return nil
+	}
+	return release
+}
+
+// DeleteActivatedAsm removes the asm of the given module for a target along with its version.
+// The module itself is kept so the asm can be rebuilt.
+func DeleteActivatedAsm(db ethdb.KeyValueWriter, target WasmTarget, moduleHash common.Hash) {
+	asmKey, err := ActivatedAsmKey(target, moduleHash)
+	if err != nil {
+		return // nothing can be stored for unknown targets
+	}
+	versionKey, _ := ActivatedVersionKey(target, moduleHash)
+	for _, key := range []WasmTargetKey{asmKey, versionKey} {
+		if err := db.Delete(key[:]); err != nil {
+			log.Crit("Failed to delete activated wasm asm", "err", err)
+		}
+	}
+}
+
+// DeleteLegacyActivatedAsm removes the asm of the given module stored before it was keyed by target.
+func DeleteLegacyActivatedAsm(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	for _, key := range []WasmKey{LegacyActivatedAsmKey(moduleHash), LegacyActivatedVersionKey(moduleHash)} {
+		if err := db.Delete(key[:]); err != nil {
+			log.Crit("Failed to delete activated wasm asm", "err", err)
+		}
+	}
+}
+
+// DeleteActivation removes every entry stored for the given module.
+func DeleteActivation(db ethdb.KeyValueWriter, moduleHash common.Hash) {
+	for target := range wasmTargetIDs {
+		DeleteActivatedAsm(db, target, moduleHash)
+	}
+	DeleteLegacyActivatedAsm(db, moduleHash)
+	for _, key := range []WasmKey{ActivatedModuleKey(moduleHash), ActivatedReferenceKey(moduleHash)} {
+		if err := db.Delete(key[:]); err != nil {
+			log.Crit("Failed to delete activated wasm module", "err", err)
+		}
+	}
+}
+
+// ActivationAsm describes the asm stored for a single target.
+type ActivationAsm struct {
+	Size    int
+	Version *ActivationVersion
+	Legacy  bool // whether the asm was stored before it was keyed by target
+}
+
+// ActivationEntry describes the entries stored for a single module hash.
+type ActivationEntry struct {
+	Asm        map[WasmTarget]*ActivationAsm
+	ModuleSize int
+	Referenced bool               // whether the module is marked as used by a live program
+	Release    *ActivationRelease // the block which released the module, if any
+	Versioned  bool               // whether any asm version was recorded for the module
+}
+
+// HasModule reports whether the module itself is stored.
+func (e *ActivationEntry) HasModule() bool {
+	return e.ModuleSize > 0
+}
+
+// ReadActivationEntries iterates over all activation entries in the database,
+// grouping them by module hash. Asm stored before it was keyed by target is
+// reported under the target it was produced for.
+func ReadActivationEntries(db ethdb.Iteratee) (map[common.Hash]*ActivationEntry, error) {
+	var (
+		entries   = make(map[common.Hash]*ActivationEntry)
+		legacyAsm = make(map[common.Hash]int)
+		legacyVer = make(map[common.Hash]*ActivationVersion)
+		versions  = make(map[common.Hash]map[WasmTarget]*ActivationVersion)
+	)
+	entry := func(moduleHash common.Hash) *ActivationEntry {
+		if e, ok := entries[moduleHash]; ok {
+			return e
+		}
+		e := &ActivationEntry{Asm: make(map[WasmTarget]*ActivationAsm)}
+		entries[moduleHash] = e
+		return e
+	}
+	it := db.NewIterator(activationPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if ok, target, moduleHash := IsActivatedAsmKey(key); ok {
+			if target == "" {
+				legacyAsm[moduleHash] = len(value)
+				entry(moduleHash)
+			} else {
+				entry(moduleHash).Asm[target] = &ActivationAsm{Size: len(value)}
+			}
+		} else if ok, moduleHash := IsActivatedModuleKey(key); ok {
+			entry(moduleHash).ModuleSize = len(value)
+		} else if ok, target, moduleHash := IsActivatedVersionKey(key); ok {
+			var version ActivationVersion
+			if err := rlp.DecodeBytes(value, &version); err != nil {
+				log.Warn("Invalid activation version RLP", "moduleHash", moduleHash, "err", err)
+				continue
+			}
+			entry(moduleHash).Versioned = true
+			if target == "" {
+				legacyVer[moduleHash] = &version
+			} else {
+				if versions[moduleHash] == nil {
+					versions[moduleHash] = make(map[WasmTarget]*ActivationVersion)
+				}
+				versions[moduleHash][target] = &version
+			}
+		} else if ok, moduleHash := IsActivatedReferenceKey(key); ok {
+			e := entry(moduleHash)
+			e.Release = decodeActivationRelease(moduleHash, value)
+			e.Referenced = e.Release == nil
+		}
+	}
+	for moduleHash, targets := range versions {
+		for target, version := range targets {
+			if asm, ok := entries[moduleHash].Asm[target]; ok {
+				asm.Version = version
+			}
+		}
+	}
+	for moduleHash, size := range legacyAsm {
+		target := LocalTarget()
+		if version := legacyVer[moduleHash]; version != nil {
+			target = WasmTarget(version.Target)
+		}
+		if _, ok := entries[moduleHash].Asm[target]; ok {
+			// the targeted asm supersedes the legacy one
+			entries[moduleHash].Asm[""] = &ActivationAsm{Size: size, Version: legacyVer[moduleHash], Legacy: true}
+			continue
+		}
+		entries[moduleHash].Asm[target] = &ActivationAsm{Size: size, Version: legacyVer[moduleHash], Legacy: true}
+	}
+	return entries, it.Error()
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that asm of unknown targets is neither stored nor looked up.
+func TestActivatedAsmUnknownTarget(t *testing.T) {
+	db := NewMemoryDatabase()
+	moduleHash := common.HexToHash("0x01")
+
+	for _, target := range []WasmTarget{"", "riscv64"} {
+		if _, err := ActivatedAsmKey(target, moduleHash); !errors.Is(err, errUnknownWasmTarget) {
+			t.Errorf("target %q: have %v, want unknown target", target, err)
+		}
+	}
+	WriteActivation(db, moduleHash, map[WasmTarget][]byte{"riscv64": {0xa}, TargetWavm: {0xb}}, []byte{0xc}, 1)
+	if asm := ReadActivatedAsm(db, TargetWavm, moduleHash); !bytes.Equal(asm, []byte{0xb}) {
+		t.Errorf("have wavm asm %x, want 0b", asm)
+	}
+	entries, err := ReadActivationEntries(db)
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if asm := entries[moduleHash].Asm; len(asm) != 1 || asm[TargetWavm] == nil {
+		t.Errorf("have asm stored for %v, want wavm only", asm)
+	}
+
+	// legacy asm isn't attributed to an unknown local target
+	legacyKey := LegacyActivatedAsmKey(moduleHash)
+	db.Put(legacyKey[:], []byte{0xd})
+	if asm := ReadActivatedAsm(db, "", moduleHash); asm != nil {
+		t.Errorf("have asm %x for the empty target", asm)
+	}
+	if version := ReadActivationVersion(db, "", moduleHash); version != nil {
+		t.Errorf("have version %v for the empty target", version)
+	}
+	DeleteActivatedAsm(db, "", moduleHash)
+}
@@ -20,28 +20,106 @@ package rawdb
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
 var (
-	activatedAsmPrefix    = []byte{0x00, 'w', 'a'} // (prefix, moduleHash) -> stylus asm
-	activatedModulePrefix = []byte{0x00, 'w', 'm'} // (prefix, moduleHash) -> stylus module
+	activationPrefix         = []byte{0x00, 'w'}      // common prefix of all activation entries
+	activatedAsmPrefix       = []byte{0x00, 'w', 'a'} // (prefix, target, moduleHash) -> stylus asm
+	activatedModulePrefix    = []byte{0x00, 'w', 'm'} // (prefix, moduleHash) -> stylus module
+	activatedVersionPrefix   = []byte{0x00, 'w', 'v'} // (prefix, target, moduleHash) -> compiler and target of the asm
+	activatedReferencePrefix = []byte{0x00, 'w', 'r'} // (prefix, moduleHash) -> marker that a live program uses the module
+
+	retryableIndexPrefix = []byte{0x00, 'r'}      // common prefix of all retryable index entries
+	retryableTxPrefix    = []byte{0x00, 'r', 't'} // (prefix, ticketId, num (uint64 big endian), tx index (uint64 big endian)) -> retryable tx entry
+
+	// RetryableIndexTablePrefix is the prefix of the chain indexer metadata of the retryable index
+	RetryableIndexTablePrefix = []byte{0x00, 'r', 'i'}
+
+	// stateCheckpointsKey tracks the retained state checkpoints, oldest first
+	stateCheckpointsKey = []byte("ArbStateCheckpoints")
 )
 
 // WasmKeyLen = CompiledWasmCodePrefix + moduleHash
 const WasmKeyLen = 3 + 32
 
+// WasmTargetKeyLen = CompiledWasmCodePrefix + target + moduleHash
+const WasmTargetKeyLen = 3 + 1 + 32
+
 type WasmKey = [WasmKeyLen]byte
 
-func ActivatedAsmKey(moduleHash common.Hash) WasmKey {
-	return newWasmKey(activatedAsmPrefix, moduleHash)
+type WasmTargetKey = [WasmTargetKeyLen]byte
+
+// WasmTarget identifies the architecture an asm was compiled for.
+type WasmTarget string
+
+const (
+	TargetWavm  WasmTarget = "wavm"
+	TargetArm64 WasmTarget = "arm64"
+	TargetAmd64 WasmTarget = "amd64"
+)
+
+// byte used to key each target's asm
+var wasmTargetIDs = map[WasmTarget]byte{
+	TargetWavm:  'w',
+	TargetArm64: 'r',
+	TargetAmd64: 'x',
+}
+
+var errUnknownWasmTarget = errors.New("unknown wasm target")
+
+// WasmTargets returns every target asm can be stored for.
+func WasmTargets() []WasmTarget {
+	return []WasmTarget{TargetWavm, TargetArm64, TargetAmd64}
+}
+
+// IsValid reports whether asm can be stored for the target.
+func (t WasmTarget) IsValid() bool {
+	_, ok := wasmTargetIDs[t]
+	return ok
+}
+
+func wasmTargetFromID(id byte) (WasmTarget, bool) {
+	for target, targetID := range wasmTargetIDs {
+		if targetID == id {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// ActivatedAsmKey returns the key of the asm of a module for a target, failing for unknown targets.
+func ActivatedAsmKey(target WasmTarget, moduleHash common.Hash) (WasmTargetKey, error) {
+	return newWasmTargetKey(activatedAsmPrefix, target, moduleHash)
 }
 
 func ActivatedModuleKey(moduleHash common.Hash) WasmKey {
 	return newWasmKey(activatedModulePrefix, moduleHash)
 }
 
+// ActivatedVersionKey returns the key of the version of the asm of a module for a target,
+// failing for unknown targets.
+func ActivatedVersionKey(target WasmTarget, moduleHash common.Hash) (WasmTargetKey, error) {
+	return newWasmTargetKey(activatedVersionPrefix, target, moduleHash)
+}
+
+func ActivatedReferenceKey(moduleHash common.Hash) WasmKey {
+	return newWasmKey(activatedReferencePrefix, moduleHash)
+}
+
+// LegacyActivatedAsmKey is the key asm was stored under before it was keyed by target.
+func LegacyActivatedAsmKey(moduleHash common.Hash) WasmKey {
+	return newWasmKey(activatedAsmPrefix, moduleHash)
+}
+
+// LegacyActivatedVersionKey is the key versions were stored under before asm was keyed by target.
+func LegacyActivatedVersionKey(moduleHash common.Hash) WasmKey {
+	return newWasmKey(activatedVersionPrefix, moduleHash)
+}
+
 // key = prefix + moduleHash
 func newWasmKey(prefix []byte, moduleHash common.Hash) WasmKey {
 	var key WasmKey
@@ -50,17 +128,63 @@ func newWasmKey(prefix []byte, moduleHash common.Hash) WasmKey {
 	return key
 }
 
-func IsActivatedAsmKey(key []byte) (bool, common.Hash) {
-	return extractWasmKey(activatedAsmPrefix, key)
+// key = prefix + target + moduleHash
+func newWasmTargetKey(prefix []byte, target WasmTarget, moduleHash common.Hash) (WasmTargetKey, error) {
+	var key WasmTargetKey
+	id, ok := wasmTargetIDs[target]
+	if !ok {
+		return key, fmt.Errorf("%w: %q", errUnknownWasmTarget, target)
+	}
+	copy(key[:3], prefix)
+	key[3] = id
+	copy(key[4:], moduleHash[:])
+	return key, nil
+}
+
+// IsActivatedAsmKey reports whether the key holds an asm, in either the targeted or legacy layout.
+// The target is empty for legacy keys.
+func IsActivatedAsmKey(key []byte) (bool, WasmTarget, common.Hash) {
+	return extractWasmTargetKey(activatedAsmPrefix, key)
 }
 
 func IsActivatedModuleKey(key []byte) (bool, common.Hash) {
 	return extractWasmKey(activatedModulePrefix, key)
 }
 
+// IsActivatedVersionKey reports whether the key holds an asm version, in either the targeted or legacy layout.
+// The target is empty for legacy keys.
+func IsActivatedVersionKey(key []byte) (bool, WasmTarget, common.Hash) {
+	return extractWasmTargetKey(activatedVersionPrefix, key)
+}
+
+func IsActivatedReferenceKey(key []byte) (bool, common.Hash) {
+	return extractWasmKey(activatedReferencePrefix, key)
+}
+
 func extractWasmKey(prefix, key []byte) (bool, common.Hash) {
 	if !bytes.HasPrefix(key, prefix) || len(key) != WasmKeyLen {
 		return false, common.Hash{}
 	}
 	return true, common.BytesToHash(key[len(prefix):])
 }
+
+func extractWasmTargetKey(prefix, key []byte) (bool, WasmTarget, common.Hash) {
+	if ok, moduleHash := extractWasmKey(prefix, key); ok {
+		return true, "", moduleHash
+	}
+	if !bytes.HasPrefix(key, prefix) || len(key) != WasmTargetKeyLen {
+		return false, "", common.Hash{}
+	}
+	target, ok := wasmTargetFromID(key[len(prefix)])
+	if !ok {
+		return false, "", common.Hash{}
+	}
+	return true, target, common.BytesToHash(key[len(prefix)+1:])
+}
+
+// retryableTxKey = retryableTxPrefix + ticketId + num (uint64 big endian) + tx index (uint64 big endian)
+func retryableTxKey(ticketId common.Hash, number uint64, index uint64) []byte {
+	key := append(append([]byte{}, retryableTxPrefix...), ticketId.Bytes()...)
+	key = append(key, encodeBlockNumber(number)...)
+	return append(key, encodeBlockNumber(index)...)
+}
@@ -2,25 +2,119 @@ package state
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
-func (db *cachingDB) ActivatedAsm(moduleHash common.Hash) ([]byte, error) {
-	if asm, _ := db.activatedAsmCache.Get(moduleHash); len(asm) > 0 {
+var (
+	wasmRecompileMissingCounter = metrics.NewRegisteredCounter("arb/state/wasm/recompile/missing", nil)
+	wasmRecompileCorruptCounter = metrics.NewRegisteredCounter("arb/state/wasm/recompile/corrupt", nil)
+	wasmRecompileFailedCounter  = metrics.NewRegisteredCounter("arb/state/wasm/recompile/failed", nil)
+	wasmRecompileTimer          = metrics.NewRegisteredTimer("arb/state/wasm/recompile/time", nil)
+)
+
+// WasmRecompiler rebuilds the asm of an activated module for the given target,
+// returning the asm along with the version of the compiler that produced it.
+type WasmRecompiler func(target rawdb.WasmTarget, moduleHash common.Hash, module []byte) (asm []byte, compilerVersion uint32, err error)
+
+// wasmRecompilerConfig is the installed recompiler, and whether the asm it rebuilds is written back to disk
+type wasmRecompilerConfig struct {
+	recompile WasmRecompiler
+	persist   bool
+}
+
+// activatedAsmCacheKey keys the asm cache, as a module has a different asm per target
+type activatedAsmCacheKey struct {
+	moduleHash common.Hash
+	target     rawdb.WasmTarget
+}
+
+// SetWasmRecompiler installs the hook used to rebuild asm that is missing or corrupted on disk.
+// The rebuilt asm is only written back if persist is set, which read-only databases must not be.
+func (db *cachingDB) SetWasmRecompiler(recompiler WasmRecompiler, persist bool) {
+	if recompiler == nil {
+		db.wasmRecompiler.Store(nil)
+		return
+	}
+	db.wasmRecompiler.Store(&wasmRecompilerConfig{recompile: recompiler, persist: persist})
+}
+
+func (db *cachingDB) ActivatedAsm(target rawdb.WasmTarget, moduleHash common.Hash) ([]byte, error) {
+	cacheKey := activatedAsmCacheKey{moduleHash, target}
+	if asm, _ := db.activatedAsmCache.Get(cacheKey); len(asm) > 0 {
 		return asm, nil
 	}
-	wasmKey := rawdb.ActivatedAsmKey(moduleHash)
-	asm, err := db.disk.Get(wasmKey[:])
+	if !target.IsValid() {
+		return nil, fmt.Errorf("invalid wasm target %q", target)
+	}
+	asm := rawdb.ReadActivatedAsm(db.disk, target, moduleHash)
+	corrupt := false
+	if len(asm) > 0 {
+		version := rawdb.ReadActivationVersion(db.disk, target, moduleHash)
+		if version == nil || version.Matches(asm) {
+			db.activatedAsmCache.Add(cacheKey, asm)
+			return asm, nil
+		}
+		corrupt = true
+	}
+	return db.recompileAsm(target, moduleHash, corrupt)
+}
+
+// recompileAsm rebuilds the asm of a target from the stored module, rewriting it to disk
+// if the recompiler persists it. Concurrent recompilations of the same asm are shared.
+func (db *cachingDB) recompileAsm(target rawdb.WasmTarget, moduleHash common.Hash, corrupt bool) ([]byte, error) {
+	recompiler := db.wasmRecompiler.Load()
+	if recompiler == nil {
+		if corrupt {
+			return nil, errors.New("corrupted")
+		}
+		return nil, errors.New("not found")
+	}
+	asm, err, _ := db.wasmRecompiles.Do(string(target)+moduleHash.Hex(), func() (interface{}, error) {
+		return db.recompileAsmOnce(recompiler, target, moduleHash, corrupt)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if len(asm) > 0 {
-		db.activatedAsmCache.Add(moduleHash, asm)
+	return asm.([]byte), nil
+}
+
+func (db *cachingDB) recompileAsmOnce(recompiler *wasmRecompilerConfig, target rawdb.WasmTarget, moduleHash common.Hash, corrupt bool) ([]byte, error) {
+	// another recompilation may have just finished
+	if asm, _ := db.activatedAsmCache.Get(activatedAsmCacheKey{moduleHash, target}); len(asm) > 0 {
 		return asm, nil
 	}
-	return nil, errors.New("not found")
+	module, err := db.ActivatedModule(moduleHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load module for recompilation: %w", err)
+	}
+	if corrupt {
+		wasmRecompileCorruptCounter.Inc(1)
+	} else {
+		wasmRecompileMissingCounter.Inc(1)
+	}
+	start := time.Now()
+	asm, compilerVersion, err := recompiler.recompile(target, moduleHash, module)
+	if err == nil && len(asm) == 0 {
+		err = errors.New("empty asm")
+	}
+	if err != nil {
+		wasmRecompileFailedCounter.Inc(1)
+		return nil, fmt.Errorf("failed to recompile: %w", err)
+	}
+	wasmRecompileTimer.UpdateSince(start)
+	log.Info("Recompiled activated wasm", "target", target, "moduleHash", moduleHash, "corrupt", corrupt, "elapsed", time.Since(start))
+
+	if recompiler.persist {
+		rawdb.WriteActivatedAsm(db.disk, target, moduleHash, asm, compilerVersion)
+	}
+	db.activatedAsmCache.Add(activatedAsmCacheKey{moduleHash, target}, asm)
+	return asm, nil
 }
 
 func (db *cachingDB) ActivatedModule(moduleHash common.Hash) ([]byte, error) {
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestActivatedAsmRecompilation(t *testing.T) {
+	var (
+		disk       = rawdb.NewMemoryDatabase()
+		moduleHash = common.HexToHash("0x01")
+		target     = rawdb.TargetAmd64
+		asm        = []byte{0xa, 0xa}
+		calls      int
+	)
+	rawdb.WriteActivation(disk, moduleHash, map[rawdb.WasmTarget][]byte{target: asm}, []byte{0xb}, 1)
+
+	// Corrupt the asm on disk, which must be detected without a recompiler
+	key, _ := rawdb.ActivatedAsmKey(target, moduleHash)
+	disk.Put(key[:], []byte{0xc})
+	db := NewDatabase(disk)
+	if _, err := db.ActivatedAsm(target, moduleHash); err == nil {
+		t.Fatal("loaded corrupted asm")
+	}
+
+	db.SetWasmRecompiler(func(target rawdb.WasmTarget, hash common.Hash, module []byte) ([]byte, uint32, error) {
+		calls++
+		if !bytes.Equal(module, []byte{0xb}) {
+			return nil, 0, errors.New("unexpected module")
+		}
+		return asm, 2, nil
+	}, true)
+	for i := 0; i < 2; i++ {
+		got, err := db.ActivatedAsm(target, moduleHash)
+		if err != nil {
+			t.Fatalf("failed to recompile asm: %v", err)
+		}
+		if !bytes.Equal(got, asm) {
+			t.Fatalf("unexpected asm: have %x, want %x", got, asm)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single recompilation, got %d", calls)
+	}
+	if got := rawdb.ReadActivatedAsm(disk, target, moduleHash); !bytes.Equal(got, asm) {
+		t.Fatalf("recompiled asm not rewritten: %x", got)
+	}
+	if version := rawdb.ReadActivationVersion(disk, target, moduleHash); version == nil || version.Compiler != 2 {
+		t.Fatalf("unexpected version after recompilation: %+v", version)
+	}
+
+	// A missing asm is rebuilt for a fresh database too
+	rawdb.DeleteActivatedAsm(disk, target, moduleHash)
+	db = NewDatabase(disk)
+	db.SetWasmRecompiler(func(rawdb.WasmTarget, common.Hash, []byte) ([]byte, uint32, error) {
+		calls++
+		return asm, 2, nil
+	}, true)
+	if _, err := db.ActivatedAsm(target, moduleHash); err != nil {
+		t.Fatalf("failed to recompile missing asm: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected missing asm to be recompiled, got %d calls", calls)
+	}
+}
+
+func TestActivatedAsmRecompilationShared(t *testing.T) {
+	var (
+		disk       = rawdb.NewMemoryDatabase()
+		moduleHash = common.HexToHash("0x01")
+		target     = rawdb.TargetAmd64
+		asm        = []byte{0xa, 0xa}
+		calls      atomic.Int32
+		release    = make(chan struct{})
+	)
+	rawdb.WriteActivation(disk, moduleHash, map[rawdb.WasmTarget][]byte{}, []byte{0xb}, 1)
+
+	// concurrent loads of the missing asm wait for a single recompilation
+	db := NewDatabase(disk)
+	db.SetWasmRecompiler(func(rawdb.WasmTarget, common.Hash, []byte) ([]byte, uint32, error) {
+		calls.Add(1)
+		<-release
+		return asm, 2, nil
+	}, false)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := db.ActivatedAsm(target, moduleHash); err != nil || !bytes.Equal(got, asm) {
+				t.Errorf("have asm %x and error %v, want %x", got, err, asm)
+			}
+		}()
+	}
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // let the other loads join the recompilation
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("have %d recompilations, want 1", n)
+	}
+	// asm that isn't persisted is only served from memory
+	if got := rawdb.ReadActivatedAsm(disk, target, moduleHash); got != nil {
+		t.Errorf("recompiled asm written to disk: %x", got)
+	}
+	if _, err := db.ActivatedAsm(target, moduleHash); err != nil || calls.Load() != 1 {
+		t.Errorf("cached asm recompiled: %v", err)
+	}
+}
@@ -13,3 +13,20 @@ func (ch wasmActivation) revert(s *StateDB) {
 func (ch wasmActivation) dirtied() *common.Address {
 	return nil
 }
+
+type wasmReferenceChange struct {
+	moduleHash common.Hash
+	released   bool // whether the module was released before the change
+}
+
+func (ch wasmReferenceChange) revert(s *StateDB) {
+	if ch.released {
+		s.releasedWasms[ch.moduleHash] = struct{}{}
+	} else {
+		delete(s.releasedWasms, ch.moduleHash)
+	}
+}
+
+func (ch wasmReferenceChange) dirtied() *common.Address {
+	return nil
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -488,6 +489,17 @@ func (p *Pruner) Prune(inputRoots []common.Hash) error {
 		}
 		roots = append(roots, root)
 	}
+	// Arbitrum: keep the state of the retained checkpoints
+	for _, checkpoint := range rawdb.ReadStateCheckpoints(p.db) {
+		if !rawdb.HasLegacyTrieNode(p.db, checkpoint.Root) {
+			log.Warn("State checkpoint missing, not retaining it", "number", checkpoint.Number, "root", checkpoint.Root)
+			continue
+		}
+		if !slices.Contains(roots, checkpoint.Root) {
+			log.Info("Retaining state checkpoint", "number", checkpoint.Number, "root", checkpoint.Root)
+			roots = append(roots, checkpoint.Root)
+		}
+	}
 	if len(roots) == 0 {
 		return errors.New("no pruning target roots found")
 	}
@@ -0,0 +1,140 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/exp/slices"
+)
+
+// WasmConfig selects which Stylus activation entries PruneWasm removes.
+type WasmConfig struct {
+	Targets         []rawdb.WasmTarget // targets to keep asm for
+	CompilerVersion uint32             // compiler version asm must have been produced with, 0 accepts any
+	Unversioned     bool               // whether to treat asm written before versions were recorded as stale
+	DryRun          bool               // whether to only report what would be deleted
+}
+
+// WasmStats summarizes the outcome of a PruneWasm run.
+type WasmStats struct {
+	Orphaned     int                // entries left behind without their module
+	Unreferenced int                // modules no live program references anymore
+	Stale        int                // asm produced for an unwanted target or another compiler
+	Kept         int                // modules left untouched
+	Size         common.StorageSize // total size of the deleted values
+}
+
+// PruneWasm deletes Stylus activation entries that are no longer useful:
+//
+//   - orphaned asm, versions and references whose module is missing
+//   - modules, along with their asm, that no live program references anymore
+//   - asm produced for targets other than the configured ones or with another
+//     compiler version, keeping the module so that the asm can be rebuilt
+//
+// Only modules whose release was recorded by a block of the canonical chain are
+// considered unreferenced. Modules without a release, including those written
+// before references were tracked or whose reference record is missing, are kept.
+func PruneWasm(db ethdb.Database, config WasmConfig) (*WasmStats, error) {
+	start := time.Now()
+	entries, err := rawdb.ReadActivationEntries(db)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		stats = new(WasmStats)
+		batch = db.NewBatch()
+	)
+	for moduleHash, entry := range entries {
+		switch {
+		case !entry.HasModule():
+			stats.Orphaned++
+			stats.Size += asmSize(entry)
+			rawdb.DeleteActivation(batch, moduleHash)
+
+		case entry.Versioned && isReleased(db, entry.Release):
+			stats.Unreferenced++
+			stats.Size += asmSize(entry) + common.StorageSize(entry.ModuleSize)
+			rawdb.DeleteActivation(batch, moduleHash)
+
+		default:
+			stale := false
+			for target, asm := range entry.Asm {
+				if !isStaleAsm(target, asm.Version, &config) {
+					continue
+				}
+				stale = true
+				stats.Size += common.StorageSize(asm.Size)
+				if asm.Legacy {
+					rawdb.DeleteLegacyActivatedAsm(batch, moduleHash)
+				} else {
+					rawdb.DeleteActivatedAsm(batch, target, moduleHash)
+				}
+			}
+			if stale {
+				stats.Stale++
+			} else {
+				stats.Kept++
+			}
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if !config.DryRun {
+				if err := batch.Write(); err != nil {
+					return nil, err
+				}
+			}
+			batch.Reset()
+		}
+	}
+	if batch.ValueSize() > 0 && !config.DryRun {
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Pruned stylus activations", "orphaned", stats.Orphaned, "unreferenced", stats.Unreferenced,
+		"stale", stats.Stale, "kept", stats.Kept, "size", stats.Size, "dryrun", config.DryRun,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return stats, nil
+}
+
+// isReleased reports whether a module's release was recorded by a block which
+// is still canonical. A module without a recorded release is kept.
+func isReleased(db ethdb.Reader, release *rawdb.ActivationRelease) bool {
+	return release != nil && rawdb.ReadCanonicalHash(db, release.Number) == release.Hash
+}
+
+func isStaleAsm(target rawdb.WasmTarget, version *rawdb.ActivationVersion, config *WasmConfig) bool {
+	if !slices.Contains(config.Targets, target) {
+		return true
+	}
+	if version == nil {
+		return config.Unversioned
+	}
+	return config.CompilerVersion != 0 && version.Compiler != config.CompilerVersion
+}
+
+func asmSize(entry *rawdb.ActivationEntry) common.StorageSize {
+	var size int
+	for _, asm := range entry.Asm {
+		size += asm.Size
+	}
+	return common.StorageSize(size)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestPruneWasm(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		live    = common.HexToHash("0x01")
+		dead    = common.HexToHash("0x02")
+		stale   = common.HexToHash("0x03")
+		orphan  = common.HexToHash("0x04")
+		legacy  = common.HexToHash("0x05")
+		reorged = common.HexToHash("0x06")
+		unknown = common.HexToHash("0x07")
+		asm     = map[rawdb.WasmTarget][]byte{rawdb.TargetAmd64: {0xa}, rawdb.TargetArm64: {0xc}}
+	)
+	for _, moduleHash := range []common.Hash{live, dead, stale, orphan, reorged, unknown} {
+		version := uint32(2)
+		if moduleHash == stale {
+			version = 1
+		}
+		rawdb.WriteActivation(db, moduleHash, asm, []byte{0xb}, version)
+		if moduleHash != unknown {
+			rawdb.WriteActivationReference(db, moduleHash)
+		}
+	}
+	// dead was released by a canonical block, reorged by one which was reorged out,
+	// while unknown has no reference record at all
+	rawdb.WriteCanonicalHash(db, common.HexToHash("0xb1"), 1)
+	rawdb.WriteActivationRelease(db, dead, 1, common.HexToHash("0xb1"))
+	rawdb.WriteActivationRelease(db, reorged, 1, common.HexToHash("0xb2"))
+	moduleKey := rawdb.ActivatedModuleKey(orphan)
+	db.Delete(moduleKey[:])
+	asmKey, moduleKey := rawdb.LegacyActivatedAsmKey(legacy), rawdb.ActivatedModuleKey(legacy)
+	db.Put(asmKey[:], []byte{0xa})
+	db.Put(moduleKey[:], []byte{0xb})
+
+	config := WasmConfig{Targets: []rawdb.WasmTarget{rawdb.TargetAmd64, rawdb.TargetArm64}, CompilerVersion: 2, DryRun: true}
+	stats, err := PruneWasm(db, config)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	if len(rawdb.ReadActivatedAsm(db, rawdb.TargetAmd64, dead)) == 0 {
+		t.Fatal("dry run deleted an activation")
+	}
+	config.DryRun = false
+	config.Targets = config.Targets[:1]
+	if stats, err = PruneWasm(db, config); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	// the arm64 asm of the live module and the legacy asm (unless produced on amd64) are stale
+	if stats.Orphaned != 1 || stats.Unreferenced != 1 || stats.Stale+stats.Kept != 5 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for _, test := range []struct {
+		moduleHash common.Hash
+		target     rawdb.WasmTarget
+		asm        bool
+		module     bool
+	}{
+		{live, rawdb.TargetAmd64, true, true},
+		{live, rawdb.TargetArm64, false, true},
+		{dead, rawdb.TargetAmd64, false, false},
+		{stale, rawdb.TargetAmd64, false, true},
+		{orphan, rawdb.TargetAmd64, false, false},
+		{legacy, rawdb.TargetAmd64, rawdb.LocalTarget() == rawdb.TargetAmd64, true},
+		{reorged, rawdb.TargetAmd64, true, true},
+		{unknown, rawdb.TargetAmd64, true, true},
+	} {
+		if has := len(rawdb.ReadActivatedAsm(db, test.target, test.moduleHash)) > 0; has != test.asm {
+			t.Errorf("module %x: %v asm present %v, want %v", test.moduleHash, test.target, has, test.asm)
+		}
+		if has := len(rawdb.ReadActivatedModule(db, test.moduleHash)) > 0; has != test.module {
+			t.Errorf("module %x: module present %v, want %v", test.moduleHash, has, test.module)
+		}
+	}
+}
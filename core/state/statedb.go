@@ -70,6 +70,8 @@ type StateDB struct {
 	everWasmPages          uint16                         // largest number of pages ever allocated during this tx's execution
 	deterministic          bool                           // whether the order in which deletes are committed should be deterministic
 	activatedWasms         map[common.Hash]*ActivatedWasm // newly activated WASMs
+	releasedWasms          map[common.Hash]struct{}       // modules no longer referenced by any program
+	wasmReleases           map[common.Hash]struct{}       // committed releases, see WriteWasmReleases
 
 	db         Database
 	prefetcher *triePrefetcher
@@ -153,6 +155,7 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 		openWasmPages:          0,
 		everWasmPages:          0,
 		activatedWasms:         make(map[common.Hash]*ActivatedWasm),
+		releasedWasms:          make(map[common.Hash]struct{}),
 
 		db:                   db,
 		trie:                 tr,
@@ -734,6 +737,7 @@ func (s *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		unexpectedBalanceDelta: new(big.Int).Set(s.unexpectedBalanceDelta),
 		activatedWasms:         make(map[common.Hash]*ActivatedWasm, len(s.activatedWasms)),
+		releasedWasms:          make(map[common.Hash]struct{}, len(s.releasedWasms)),
 		openWasmPages:          s.openWasmPages,
 		everWasmPages:          s.everWasmPages,
 
@@ -818,6 +822,15 @@ func (s *StateDB) Copy() *StateDB {
 		// It's fine to skip a deep copy since activations are immutable.
 		state.activatedWasms[moduleHash] = info
 	}
+	for moduleHash := range s.releasedWasms {
+		state.releasedWasms[moduleHash] = struct{}{}
+	}
+	if s.wasmReleases != nil {
+		state.wasmReleases = make(map[common.Hash]struct{}, len(s.wasmReleases))
+		for moduleHash := range s.wasmReleases {
+			state.wasmReleases[moduleHash] = struct{}{}
+		}
+	}
 
 	// If there's a prefetcher running, make an inactive copy of it that can
 	// only access data but does not actively preload (since the user will not
@@ -1078,13 +1091,27 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (common.Hash, error) {
 		s.stateObjectsDirty = make(map[common.Address]struct{})
 	}
 
-	// Arbitrum: write Stylus programs to disk
+	// Arbitrum: write Stylus programs to disk along with their references, which
+	// keep them from being pruned whichever chain the state ends up in. Releases
+	// are left to the canonical chain since committing a historical or side state
+	// mustn't make a module prunable.
 	for moduleHash, info := range s.activatedWasms {
-		rawdb.WriteActivation(codeWriter, moduleHash, info.Asm, info.Module)
+		rawdb.WriteActivation(codeWriter, moduleHash, info.Asm, info.Module, info.compilerVersion)
+		rawdb.WriteActivationReference(codeWriter, moduleHash)
+		delete(s.wasmReleases, moduleHash)
 	}
 	if len(s.activatedWasms) > 0 {
 		s.activatedWasms = make(map[common.Hash]*ActivatedWasm)
 	}
+	if len(s.releasedWasms) > 0 && s.wasmReleases == nil {
+		s.wasmReleases = make(map[common.Hash]struct{})
+	}
+	for moduleHash := range s.releasedWasms {
+		s.wasmReleases[moduleHash] = struct{}{}
+	}
+	if len(s.releasedWasms) > 0 {
+		s.releasedWasms = make(map[common.Hash]struct{})
+	}
 
 	if codeWriter.ValueSize() > 0 {
 		if err := codeWriter.Write(); err != nil {
@@ -25,7 +25,9 @@ import (
 	"errors"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 var (
@@ -45,8 +47,10 @@ var (
 )
 
 type ActivatedWasm struct {
-	Asm    []byte
+	Asm    map[rawdb.WasmTarget][]byte
 	Module []byte
+
+	compilerVersion uint32 // version of the compiler that produced the asm
 }
 
 // checks if a valid Stylus prefix is present
@@ -71,28 +75,66 @@ func NewStylusPrefix(dictionary byte) []byte {
 	return append(prefix, dictionary)
 }
 
-func (s *StateDB) ActivateWasm(moduleHash common.Hash, asm, module []byte) {
+// ActivateWasm records a newly activated program, marking its module as referenced.
+// The asmMap holds the asm produced for each target by the compiler identified by compilerVersion.
+func (s *StateDB) ActivateWasm(moduleHash common.Hash, asmMap map[rawdb.WasmTarget][]byte, module []byte, compilerVersion uint32) {
+	if _, released := s.releasedWasms[moduleHash]; released {
+		delete(s.releasedWasms, moduleHash)
+		s.journal.append(wasmReferenceChange{
+			moduleHash: moduleHash,
+			released:   true,
+		})
+	}
 	_, exists := s.activatedWasms[moduleHash]
 	if exists {
 		return
 	}
 	s.activatedWasms[moduleHash] = &ActivatedWasm{
-		Asm:    asm,
-		Module: module,
+		Asm:             asmMap,
+		Module:          module,
+		compilerVersion: compilerVersion,
 	}
 	s.journal.append(wasmActivation{
 		moduleHash: moduleHash,
 	})
 }
 
-func (s *StateDB) GetActivatedAsm(moduleHash common.Hash) []byte {
+// ReleaseWasm records that no live program references the given module anymore,
+// allowing its activation to be garbage collected. Callers must only release a
+// module once the last program using it has been deactivated.
+func (s *StateDB) ReleaseWasm(moduleHash common.Hash) {
+	if _, released := s.releasedWasms[moduleHash]; released {
+		return
+	}
+	s.releasedWasms[moduleHash] = struct{}{}
+	s.journal.append(wasmReferenceChange{
+		moduleHash: moduleHash,
+		released:   false,
+	})
+}
+
+// WriteWasmReleases persists the releases of the modules no longer referenced by
+// the committed state, recording the given block as the one releasing them. The
+// references of the modules it activated are written when committing. It must
+// only be called once the block becomes the canonical head: replaying an older
+// release would otherwise unreference a module that was activated again.
+func (s *StateDB) WriteWasmReleases(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	for moduleHash := range s.wasmReleases {
+		rawdb.WriteActivationRelease(db, moduleHash, number, hash)
+	}
+	s.wasmReleases = nil
+}
+
+func (s *StateDB) GetActivatedAsm(target rawdb.WasmTarget, moduleHash common.Hash) []byte {
 	info, exists := s.activatedWasms[moduleHash]
 	if exists {
-		return info.Asm
+		if asm, ok := info.Asm[target]; ok {
+			return asm
+		}
 	}
-	asm, err := s.db.ActivatedAsm(moduleHash)
+	asm, err := s.db.ActivatedAsm(target, moduleHash)
 	if err != nil {
-		s.setError(fmt.Errorf("failed to load asm for %x: %v", moduleHash, err))
+		s.setError(fmt.Errorf("failed to load %v asm for %x: %v", target, moduleHash, err))
 	}
 	return asm
 }
@@ -176,10 +218,15 @@ func (s *StateDB) StartRecording() {
 	s.userWasms = make(UserWasms)
 }
 
-func (s *StateDB) RecordProgram(moduleHash common.Hash) {
+// RecordProgram records the module and the asm of each of the given targets
+func (s *StateDB) RecordProgram(targets []rawdb.WasmTarget, moduleHash common.Hash) {
 	if s.userWasms != nil {
+		asmMap := make(map[rawdb.WasmTarget][]byte, len(targets))
+		for _, target := range targets {
+			asmMap[target] = s.GetActivatedAsm(target, moduleHash)
+		}
 		s.userWasms[moduleHash] = ActivatedWasm{
-			Asm:    s.GetActivatedAsm(moduleHash),
+			Asm:    asmMap,
 			Module: s.GetActivatedModule(moduleHash),
 		}
 	}
@@ -0,0 +1,234 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// MissingPreimageError is reported when executing from a witness that lacks a preimage
+type MissingPreimageError struct {
+	Hash common.Hash
+}
+
+func (e *MissingPreimageError) Error() string {
+	return fmt.Sprintf("missing preimage for hash %v", e.Hash)
+}
+
+// MissingUserWasmError is reported when executing from a witness that lacks a Stylus program
+type MissingUserWasmError struct {
+	ModuleHash common.Hash
+	Target     rawdb.WasmTarget
+}
+
+func (e *MissingUserWasmError) Error() string {
+	if e.Target == "" {
+		return fmt.Sprintf("missing user wasm module for module hash %v", e.ModuleHash)
+	}
+	return fmt.Sprintf("missing user wasm asm for module hash %v target %v", e.ModuleHash, e.Target)
+}
+
+// StatelessDatabase is a Database serving the state solely from a preimage map
+// and a set of user wasms, the data a recording collects, so that a block can be
+// executed without access to a full database. The trie nodes and code are looked
+// up by hash, and anything else is reported as not found.
+//
+// The trie nodes, code and user wasms the execution needs but can't find are
+// remembered, as the StateDB doesn't surface the hash it was missing. Other
+// reads of the key-value store, such as probes for data that may not exist,
+// aren't held against the witness.
+type StatelessDatabase struct {
+	Database
+	kv *preimageKV
+}
+
+// NewStatelessDatabase creates a Database backed by the given preimages and user wasms.
+func NewStatelessDatabase(preimages map[common.Hash][]byte, userWasms UserWasms) *StatelessDatabase {
+	kv := &preimageKV{
+		KeyValueStore: memorydb.New(),
+		preimages:     preimages,
+		userWasms:     userWasms,
+	}
+	return &StatelessDatabase{
+		Database: NewDatabase(rawdb.NewDatabase(kv)),
+		kv:       kv,
+	}
+}
+
+// Preimage returns the preimage of the hash, remembering it as missing if it's not available.
+func (db *StatelessDatabase) Preimage(hash common.Hash) ([]byte, error) {
+	return db.kv.preimage(hash)
+}
+
+// OpenTrie opens the main account trie, remembering the nodes it's missing.
+func (db *StatelessDatabase) OpenTrie(root common.Hash) (Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, db.kv.check(err)
+	}
+	return &statelessTrie{tr, db.kv}, nil
+}
+
+// OpenStorageTrie opens the storage trie of an account, remembering the nodes it's missing.
+func (db *StatelessDatabase) OpenStorageTrie(stateRoot common.Hash, addrHash, root common.Hash) (Trie, error) {
+	tr, err := db.Database.OpenStorageTrie(stateRoot, addrHash, root)
+	if err != nil {
+		return nil, db.kv.check(err)
+	}
+	return &statelessTrie{tr, db.kv}, nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *StatelessDatabase) CopyTrie(t Trie) Trie {
+	if tr, ok := t.(*statelessTrie); ok {
+		return &statelessTrie{db.Database.CopyTrie(tr.Trie), tr.kv}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// ContractCode retrieves a particular contract's code, remembering it as missing if it's not available.
+func (db *StatelessDatabase) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	code, err := db.Database.ContractCode(addrHash, codeHash)
+	if err != nil {
+		return nil, db.kv.fail(&MissingPreimageError{codeHash})
+	}
+	return code, nil
+}
+
+// ContractCodeSize retrieves a particular contract's code size, remembering the code as missing if it's not available.
+func (db *StatelessDatabase) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	size, err := db.Database.ContractCodeSize(addrHash, codeHash)
+	if err != nil {
+		return 0, db.kv.fail(&MissingPreimageError{codeHash})
+	}
+	return size, nil
+}
+
+// MissingErr returns an error describing the first preimage or user wasm that was
+// looked up but missing, or nil if every lookup succeeded.
+func (db *StatelessDatabase) MissingErr() error {
+	db.kv.lock.Lock()
+	defer db.kv.lock.Unlock()
+	return db.kv.missing
+}
+
+// preimageKV serves reads from the preimages and user wasms, keeping any
+// writes made during execution in memory.
+type preimageKV struct {
+	ethdb.KeyValueStore
+	preimages map[common.Hash][]byte
+	userWasms UserWasms
+
+	lock    sync.Mutex
+	missing error
+}
+
+func (kv *preimageKV) fail(err error) error {
+	kv.lock.Lock()
+	defer kv.lock.Unlock()
+	if kv.missing == nil {
+		kv.missing = err
+	}
+	return err
+}
+
+func (kv *preimageKV) preimage(hash common.Hash) ([]byte, error) {
+	if preimage, ok := kv.preimages[hash]; ok {
+		return preimage, nil
+	}
+	return nil, kv.fail(&MissingPreimageError{hash})
+}
+
+// check remembers the trie node an error reports missing, if any.
+func (kv *preimageKV) check(err error) error {
+	var missing *trie.MissingNodeError
+	if errors.As(err, &missing) {
+		kv.fail(&MissingPreimageError{missing.NodeHash})
+	}
+	return err
+}
+
+func (kv *preimageKV) Has(key []byte) (bool, error) {
+	value, _ := kv.get(key)
+	return len(value) > 0, nil
+}
+
+func (kv *preimageKV) Get(key []byte) ([]byte, error) {
+	value, err := kv.get(key)
+	if err != nil {
+		return nil, kv.fail(err)
+	}
+	if len(value) == 0 {
+		return nil, errors.New("not found")
+	}
+	return value, nil
+}
+
+// get looks up the value of a key. Trie nodes and code are served by hash, but
+// only reported missing by the trie and code reads needing them, as other data
+// is read by hash too. Missing user wasms are reported here, as their keys are
+// only read when executing a program.
+func (kv *preimageKV) get(key []byte) ([]byte, error) {
+	if value, err := kv.KeyValueStore.Get(key); err == nil {
+		return value, nil
+	}
+	if len(key) == common.HashLength {
+		return kv.preimages[common.BytesToHash(key)], nil
+	}
+	if len(key) == len(rawdb.CodePrefix)+common.HashLength && bytes.HasPrefix(key, rawdb.CodePrefix) {
+		return kv.preimages[common.BytesToHash(key[len(rawdb.CodePrefix):])], nil
+	}
+	if ok, target, moduleHash := rawdb.IsActivatedAsmKey(key); ok && target != "" {
+		if asm := kv.userWasms[moduleHash].Asm[target]; len(asm) > 0 {
+			return asm, nil
+		}
+		return nil, &MissingUserWasmError{moduleHash, target}
+	}
+	if ok, moduleHash := rawdb.IsActivatedModuleKey(key); ok {
+		if module := kv.userWasms[moduleHash].Module; len(module) > 0 {
+			return module, nil
+		}
+		return nil, &MissingUserWasmError{ModuleHash: moduleHash}
+	}
+	return nil, nil
+}
+
+// statelessTrie remembers the nodes missing from the preimages as they're read.
+type statelessTrie struct {
+	Trie
+	kv *preimageKV
+}
+
+func (t *statelessTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	value, err := t.Trie.GetStorage(addr, key)
+	return value, t.kv.check(err)
+}
+
+func (t *statelessTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	account, err := t.Trie.GetAccount(address)
+	return account, t.kv.check(err)
+}
+
+func (t *statelessTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return t.kv.check(t.Trie.UpdateStorage(addr, key, value))
+}
+
+func (t *statelessTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	return t.kv.check(t.Trie.UpdateAccount(address, account))
+}
+
+func (t *statelessTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return t.kv.check(t.Trie.DeleteStorage(addr, key))
+}
+
+func (t *statelessTrie) DeleteAccount(address common.Address) error {
+	return t.kv.check(t.Trie.DeleteAccount(address))
+}
@@ -0,0 +1,124 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestStatelessDatabase(t *testing.T) {
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		db    = NewDatabase(disk)
+		addr  = common.HexToAddress("0x01")
+		code  = []byte{0x60, 0x00}
+		value = common.HexToHash("0x02")
+	)
+	state, _ := New(common.Hash{}, db, nil)
+	state.SetBalance(addr, big.NewInt(3))
+	state.SetCode(addr, code)
+	state.SetState(addr, common.Hash{}, value)
+	root, _ := state.Commit(false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// collect the trie nodes and code as the recording would
+	preimages := make(map[common.Hash][]byte)
+	it := disk.NewIterator(nil, nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) == common.HashLength || bytes.HasPrefix(key, rawdb.CodePrefix) {
+			value := common.CopyBytes(it.Value())
+			preimages[crypto.Keccak256Hash(value)] = value
+		}
+	}
+	it.Release()
+
+	stateless := NewStatelessDatabase(preimages, nil)
+	state, err := New(root, stateless, nil)
+	if err != nil {
+		t.Fatalf("failed to open stateless state: %v", err)
+	}
+	if balance := state.GetBalance(addr); balance.Uint64() != 3 {
+		t.Fatalf("unexpected balance: %v", balance)
+	}
+	if got := state.GetCode(addr); !bytes.Equal(got, code) {
+		t.Fatalf("unexpected code: %x", got)
+	}
+	if got := state.GetState(addr, common.Hash{}); got != value {
+		t.Fatalf("unexpected storage: %v", got)
+	}
+	if err := stateless.MissingErr(); err != nil {
+		t.Fatalf("unexpected missing preimage: %v", err)
+	}
+
+	// dropping the code must be reported precisely
+	codeHash := crypto.Keccak256Hash(code)
+	delete(preimages, codeHash)
+	stateless = NewStatelessDatabase(preimages, nil)
+	state, _ = New(root, stateless, nil)
+	state.GetCode(addr)
+	var missing *MissingPreimageError
+	if err := stateless.MissingErr(); !errors.As(err, &missing) || missing.Hash != codeHash {
+		t.Fatalf("expected missing code preimage, got %v", err)
+	}
+
+	// so must the root of the storage trie, which the account references
+	preimages[codeHash] = code
+	var storageRoot common.Hash
+	for hash := range preimages {
+		if hash != root && hash != codeHash && bytes.Contains(preimages[root], hash[:]) {
+			storageRoot = hash
+		}
+	}
+	if storageRoot == (common.Hash{}) {
+		t.Fatal("no storage trie referenced by the account")
+	}
+	storageRootNode := preimages[storageRoot]
+	delete(preimages, storageRoot)
+	stateless = NewStatelessDatabase(preimages, nil)
+	state, _ = New(root, stateless, nil)
+	state.GetState(addr, common.Hash{})
+	if err := stateless.MissingErr(); !errors.As(err, &missing) || missing.Hash != storageRoot {
+		t.Fatalf("expected missing trie node %v, got %v", storageRoot, err)
+	}
+	preimages[storageRoot] = storageRootNode
+
+	// and the state root
+	rootNode := preimages[root]
+	delete(preimages, root)
+	stateless = NewStatelessDatabase(preimages, nil)
+	if _, err := New(root, stateless, nil); err == nil {
+		t.Fatal("opened a state without its root")
+	}
+	if err := stateless.MissingErr(); !errors.As(err, &missing) || missing.Hash != root {
+		t.Fatalf("expected missing state root %v, got %v", root, err)
+	}
+	preimages[root] = rootNode
+
+	// reads and probes of other keys aren't held against the witness
+	stateless = NewStatelessDatabase(preimages, nil)
+	other := crypto.Keccak256Hash([]byte("other"))
+	if _, err := stateless.DiskDB().Get(other[:]); err == nil {
+		t.Fatal("read a key that isn't there")
+	}
+	if ok, _ := stateless.DiskDB().Has(other[:]); ok {
+		t.Fatal("found a key that isn't there")
+	}
+	asmKey, err := rawdb.ActivatedAsmKey(rawdb.TargetWavm, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := stateless.DiskDB().Has(asmKey[:]); ok {
+		t.Fatal("found a user wasm that isn't there")
+	}
+	if err := stateless.MissingErr(); err != nil {
+		t.Fatalf("unexpected missing preimage: %v", err)
+	}
+}
@@ -341,6 +341,10 @@ func (st *StateTransition) preCheck() error {
 // However if any consensus issue encountered, return the error directly with
 // nil evm execution result.
 func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
+	// Arbitrum: let tracers read the state before ArbOS changes it
+	if tracer, ok := st.evm.Config.Tracer.(vm.ArbitrumTxEnvLogger); ok {
+		tracer.CaptureArbitrumTxEnv(st.evm)
+	}
 	endTxNow, startHookUsedGas, err, returnData := st.evm.ProcessingHook.StartTxHook()
 	if endTxNow {
 		return &ExecutionResult{
@@ -18,6 +18,18 @@ func (tx *Transaction) SkipAccountChecks() bool {
 	return tx.inner.skipAccountChecks()
 }
 
+// RetryableTicketId returns the id of the retryable ticket the transaction submits
+// or redeems, if any. The id of a ticket is the hash of the transaction submitting it.
+func (tx *Transaction) RetryableTicketId() (common.Hash, bool) {
+	switch inner := tx.inner.(type) {
+	case *ArbitrumSubmitRetryableTx:
+		return tx.Hash(), true
+	case *ArbitrumRetryTx:
+		return inner.TicketId, true
+	}
+	return common.Hash{}, false
+}
+
 type fallbackError struct {
 }
 
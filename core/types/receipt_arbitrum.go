@@ -16,6 +16,11 @@
 
 package types
 
+// GasUsedForL2 returns the part of the gas used executing the transaction, which
+// is 0 rather than wrapping around if the gas charged for L1 exceeds the gas used.
 func (r *Receipt) GasUsedForL2() uint64 {
+	if r.GasUsed < r.GasUsedForL1 {
+		return 0
+	}
 	return r.GasUsed - r.GasUsedForL1
 }
@@ -141,7 +141,11 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Random != nil, blockCtx.Time, blockCtx.ArbOSVersion),
 	}
-	evm.ProcessingHook = DefaultTxProcessor{evm: evm}
+	if config.NewProcessingHook != nil {
+		evm.ProcessingHook = config.NewProcessingHook(evm)
+	} else {
+		evm.ProcessingHook = NewDefaultTxProcessor(evm)
+	}
 	evm.interpreter = NewEVMInterpreter(evm)
 	return evm
 }
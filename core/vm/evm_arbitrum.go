@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // Depth returns the current depth
@@ -37,6 +38,11 @@ func (evm *EVM) DecrementDepth() {
 	evm.depth -= 1
 }
 
+// StylusGasSchedule returns the Stylus pricing of the ArbOS version the EVM runs at
+func (evm *EVM) StylusGasSchedule() *params.StylusGasSchedule {
+	return evm.chainRules.StylusGasSchedule
+}
+
 type TxProcessingHook interface {
 	StartTxHook() (bool, uint64, error, []byte) // return 4-tuple rather than *struct to avoid an import cycle
 	GasChargingHook(gasRemaining *uint64) (common.Address, error)
@@ -56,7 +62,18 @@ type TxProcessingHook interface {
 }
 
 type DefaultTxProcessor struct {
-	evm *EVM
+	evm  *EVM
+	wasm WasmExecutor
+}
+
+// NewDefaultTxProcessor creates the processing hook used when ArbOS isn't installed,
+// which only executes Stylus programs if the config provides an executor
+func NewDefaultTxProcessor(evm *EVM) DefaultTxProcessor {
+	var executor WasmExecutor
+	if evm.Config.NewWasmExecutor != nil {
+		executor = evm.Config.NewWasmExecutor(evm)
+	}
+	return DefaultTxProcessor{evm: evm, wasm: executor}
 }
 
 func (p DefaultTxProcessor) StartTxHook() (bool, uint64, error, []byte) {
@@ -102,6 +119,8 @@ func (p DefaultTxProcessor) MsgIsNonMutating() bool {
 }
 
 func (p DefaultTxProcessor) ExecuteWASM(scope *ScopeContext, input []byte, interpreter *EVMInterpreter) ([]byte, error) {
-	log.Crit("tried to execute WASM with default processing hook")
-	return nil, nil
+	if p.wasm == nil {
+		log.Crit("tried to execute WASM with default processing hook")
+	}
+	return p.wasm.ExecuteWASM(scope, input, interpreter)
 }
@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -28,8 +29,9 @@ import (
 // StateDB is an EVM database for full state querying.
 type StateDB interface {
 	// Arbitrum: manage compiled wasms
-	ActivateWasm(moduleHash common.Hash, asm, module []byte)
-	GetActivatedAsm(moduleHash common.Hash) (asm []byte)
+	ActivateWasm(moduleHash common.Hash, asmMap map[rawdb.WasmTarget][]byte, module []byte, compilerVersion uint32)
+	ReleaseWasm(moduleHash common.Hash)
+	GetActivatedAsm(target rawdb.WasmTarget, moduleHash common.Hash) (asm []byte)
 	GetActivatedModule(moduleHash common.Hash) (module []byte)
 
 	// Arbitrum: track stylus's memory footprint
@@ -30,6 +30,14 @@ type Config struct {
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
 	ExtraEips               []int     // Additional EIPS that are to be enabled
+
+	// Arbitrum: creates the processing hook of the evm, which is the DefaultTxProcessor when nil
+	NewProcessingHook func(evm *EVM) TxProcessingHook
+
+	// Arbitrum: creates the executor of Stylus programs for the default processing hook,
+	// which refuses to execute them when nil. The reference executor isn't consensus
+	// compatible with ArbOS, so only tests and tools should opt into it.
+	NewWasmExecutor func(evm *EVM) WasmExecutor
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -49,3 +49,11 @@ type EVMLogger interface {
 	CaptureState(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error)
 	CaptureFault(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error)
 }
+
+// Arbitrum: ArbitrumTxEnvLogger is implemented by loggers reading the state ArbOS
+// accesses outside of EVM execution. CaptureArbitrumTxEnv is called before the
+// processing hook starts the transaction, so that the storage hooks, which fire
+// before ArbOS writes, can read the values being overwritten.
+type ArbitrumTxEnvLogger interface {
+	CaptureArbitrumTxEnv(env *EVM)
+}
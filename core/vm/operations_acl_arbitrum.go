@@ -26,29 +26,29 @@ import (
 
 // Computes the cost of doing a state load in wasm
 // Note: the code here is adapted from gasSLoadEIP2929
-func WasmStateLoadCost(db StateDB, program common.Address, key common.Hash) uint64 {
+func WasmStateLoadCost(schedule *params.StylusGasSchedule, db StateDB, program common.Address, key common.Hash) uint64 {
 	// Check slot presence in the access list
 	if _, slotPresent := db.SlotInAccessList(program, key); !slotPresent {
 		// If the caller cannot afford the cost, this change will be rolled back
 		// If he does afford it, we can skip checking the same thing later on, during execution
 		db.AddSlotToAccessList(program, key)
-		return params.ColdSloadCostEIP2929
+		return schedule.ColdSloadCost
 	}
-	return params.WarmStorageReadCostEIP2929
+	return schedule.WarmStorageReadCost
 }
 
 // Computes the cost of doing a state store in wasm
-// Note: the code here is adapted from makeGasSStoreFunc with the most recent parameters as of The Merge
+// Note: the code here is adapted from makeGasSStoreFunc
 // Note: the sentry check must be done by the caller
-func WasmStateStoreCost(db StateDB, program common.Address, key, value common.Hash) uint64 {
-	clearingRefund := params.SstoreClearsScheduleRefundEIP3529
+func WasmStateStoreCost(schedule *params.StylusGasSchedule, db StateDB, program common.Address, key, value common.Hash) uint64 {
+	clearingRefund := schedule.SstoreClearsScheduleRefund
 
 	cost := uint64(0)
 	current := db.GetState(program, key)
 
 	// Check slot presence in the access list
 	if addrPresent, slotPresent := db.SlotInAccessList(program, key); !slotPresent {
-		cost = params.ColdSloadCostEIP2929
+		cost = schedule.ColdSloadCost
 		// If the caller cannot afford the cost, this change will be rolled back
 		db.AddSlotToAccessList(program, key)
 		if !addrPresent {
@@ -59,19 +59,19 @@ func WasmStateStoreCost(db StateDB, program common.Address, key, value common.Ha
 	if current == value { // noop (1)
 		// EIP 2200 original clause:
 		//		return params.SloadGasEIP2200, nil
-		return cost + params.WarmStorageReadCostEIP2929 // SLOAD_GAS
+		return cost + schedule.WarmStorageReadCost // SLOAD_GAS
 	}
 	original := db.GetCommittedState(program, key)
 	if original == current {
 		if original == (common.Hash{}) { // create slot (2.1.1)
-			return cost + params.SstoreSetGasEIP2200
+			return cost + schedule.SstoreSetGas
 		}
 		if value == (common.Hash{}) { // delete slot (2.1.2b)
 			db.AddRefund(clearingRefund)
 		}
 		// EIP-2200 original clause:
 		//		return params.SstoreResetGasEIP2200, nil // write existing slot (2.1.2)
-		return cost + (params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929) // write existing slot (2.1.2)
+		return cost + (schedule.SstoreResetGas - schedule.ColdSloadCost) // write existing slot (2.1.2)
 	}
 	if original != (common.Hash{}) {
 		if current == (common.Hash{}) { // recreate slot (2.2.1.1)
@@ -84,27 +84,27 @@ func WasmStateStoreCost(db StateDB, program common.Address, key, value common.Ha
 		if original == (common.Hash{}) { // reset to original inexistent slot (2.2.2.1)
 			// EIP 2200 Original clause:
 			//evm.StateDB.AddRefund(params.SstoreSetGasEIP2200 - params.SloadGasEIP2200)
-			db.AddRefund(params.SstoreSetGasEIP2200 - params.WarmStorageReadCostEIP2929)
+			db.AddRefund(schedule.SstoreSetGas - schedule.WarmStorageReadCost)
 		} else { // reset to original existing slot (2.2.2.2)
 			// EIP 2200 Original clause:
 			//	evm.StateDB.AddRefund(params.SstoreResetGasEIP2200 - params.SloadGasEIP2200)
 			// - SSTORE_RESET_GAS redefined as (5000 - COLD_SLOAD_COST)
 			// - SLOAD_GAS redefined as WARM_STORAGE_READ_COST
 			// Final: (5000 - COLD_SLOAD_COST) - WARM_STORAGE_READ_COST
-			db.AddRefund((params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929) - params.WarmStorageReadCostEIP2929)
+			db.AddRefund((schedule.SstoreResetGas - schedule.ColdSloadCost) - schedule.WarmStorageReadCost)
 		}
 	}
 	// EIP-2200 original clause:
 	//return params.SloadGasEIP2200, nil // dirty update (2.2)
-	return cost + params.WarmStorageReadCostEIP2929 // dirty update (2.2)
+	return cost + schedule.WarmStorageReadCost // dirty update (2.2)
 }
 
 // Computes the cost of starting a call from wasm
 //
-// The code here is adapted from the following functions with the most recent parameters as of The Merge
+// The code here is adapted from the following functions
 //   - operations_acl.go makeCallVariantGasCallEIP2929()
 //   - gas_table.go      gasCall()
-func WasmCallCost(db StateDB, contract common.Address, value *big.Int, budget uint64) (uint64, error) {
+func WasmCallCost(schedule *params.StylusGasSchedule, db StateDB, contract common.Address, value *big.Int, budget uint64) (uint64, error) {
 	total := uint64(0)
 	apply := func(amount uint64) bool {
 		total += amount
@@ -112,13 +112,13 @@ func WasmCallCost(db StateDB, contract common.Address, value *big.Int, budget ui
 	}
 
 	// EIP 2929: the static cost
-	if apply(params.WarmStorageReadCostEIP2929) {
+	if apply(schedule.WarmStorageReadCost) {
 		return total, ErrOutOfGas
 	}
 
 	// EIP 2929: first dynamic cost if cold (makeCallVariantGasCallEIP2929)
 	warmAccess := db.AddressInAccessList(contract)
-	coldCost := params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+	coldCost := schedule.ColdAccountAccessCost - schedule.WarmStorageReadCost
 	if !warmAccess {
 		db.AddAddressToAccessList(contract)
 
@@ -130,12 +130,12 @@ func WasmCallCost(db StateDB, contract common.Address, value *big.Int, budget ui
 	// gasCall()
 	transfersValue := value.Sign() != 0
 	if transfersValue && db.Empty(contract) {
-		if apply(params.CallNewAccountGas) {
+		if apply(schedule.CallNewAccountGas) {
 			return total, ErrOutOfGas
 		}
 	}
 	if transfersValue {
-		if apply(params.CallValueTransferGas) {
+		if apply(schedule.CallValueTransferGas) {
 			return total, ErrOutOfGas
 		}
 	}
@@ -143,16 +143,16 @@ func WasmCallCost(db StateDB, contract common.Address, value *big.Int, budget ui
 }
 
 // Computes the cost of touching an account in wasm
-// Note: the code here is adapted from gasEip2929AccountCheck with the most recent parameters as of The Merge
-func WasmAccountTouchCost(db StateDB, addr common.Address, withCode bool) uint64 {
+// Note: the code here is adapted from gasEip2929AccountCheck
+func WasmAccountTouchCost(schedule *params.StylusGasSchedule, db StateDB, addr common.Address, withCode bool) uint64 {
 	cost := uint64(0)
 	if withCode {
-		cost = params.MaxCodeSize / 24576 * params.ExtcodeSizeGasEIP150
+		cost = schedule.CodeLoadGas
 	}
 
 	if !db.AddressInAccessList(addr) {
 		db.AddAddressToAccessList(addr)
-		return cost + params.ColdAccountAccessCostEIP2929
+		return cost + schedule.ColdAccountAccessCost
 	}
-	return cost + params.WarmStorageReadCostEIP2929
+	return cost + schedule.WarmStorageReadCost
 }
@@ -0,0 +1,274 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	stylusProgram = common.BytesToAddress([]byte("program"))
+	stylusTarget  = common.BytesToAddress([]byte("target"))
+	stylusKey     = common.BytesToHash([]byte("key"))
+)
+
+// stylusGasEnv is a pair of identical states, one charged by the Stylus schedule and the
+// other by the opcode gas functions of the EVM running at the same ArbOS version
+type stylusGasEnv struct {
+	schedule *params.StylusGasSchedule
+	wasm     *state.StateDB
+	evm      *EVM
+}
+
+func newStylusGasEnv(t *testing.T, arbosVersion uint64, setup func(*state.StateDB)) *stylusGasEnv {
+	t.Helper()
+	newState := func() *state.StateDB {
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(stylusProgram)
+		statedb.SetCode(stylusProgram, []byte{0xef, 0xf0, 0x00})
+		setup(statedb)
+		statedb.Finalise(true)
+		statedb.AddAddressToAccessList(stylusProgram)
+		return statedb
+	}
+	vmctx := BlockContext{
+		CanTransfer:  func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:     func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber:  new(big.Int),
+		Random:       &common.Hash{},
+		ArbOSVersion: arbosVersion,
+	}
+	evm := NewEVM(vmctx, TxContext{}, newState(), params.ArbitrumDevTestChainConfig(), Config{})
+	schedule := evm.StylusGasSchedule()
+	if schedule == nil {
+		t.Fatalf("no stylus gas schedule at ArbOS version %d", arbosVersion)
+	}
+	return &stylusGasEnv{schedule: schedule, wasm: newState(), evm: evm}
+}
+
+// opcodeCost charges the operation as the interpreter would, constant gas included
+func (env *stylusGasEnv) opcodeCost(t *testing.T, op OpCode, args ...*uint256.Int) uint64 {
+	t.Helper()
+	operation := env.evm.interpreter.table[op]
+	contract := NewContract(AccountRef(common.Address{}), AccountRef(stylusProgram), new(big.Int), 1_000_000)
+	stack := newstack()
+	defer returnStack(stack)
+	for i := len(args) - 1; i >= 0; i-- {
+		stack.push(args[i])
+	}
+	cost, err := operation.dynamicGas(env.evm, contract, stack, NewMemory(), 0)
+	if err != nil {
+		t.Fatalf("%v gas failed: %v", op, err)
+	}
+	return operation.constantGas + cost
+}
+
+func (env *stylusGasEnv) checkSideEffects(t *testing.T) {
+	t.Helper()
+	evmState := env.evm.StateDB.(*state.StateDB)
+	if have, want := env.wasm.GetRefund(), evmState.GetRefund(); have != want {
+		t.Errorf("refund mismatch: have %d, want %d", have, want)
+	}
+	for _, addr := range []common.Address{stylusProgram, stylusTarget} {
+		if have, want := env.wasm.AddressInAccessList(addr), evmState.AddressInAccessList(addr); have != want {
+			t.Errorf("access list mismatch for %v: have %v, want %v", addr, have, want)
+		}
+	}
+	_, have := env.wasm.SlotInAccessList(stylusProgram, stylusKey)
+	_, want := evmState.SlotInAccessList(stylusProgram, stylusKey)
+	if have != want {
+		t.Errorf("slot access list mismatch: have %v, want %v", have, want)
+	}
+}
+
+func forEachStylusGasSchedule(t *testing.T, run func(t *testing.T, arbosVersion uint64)) {
+	for _, version := range params.StylusGasScheduleVersions() {
+		version := version
+		t.Run(fmt.Sprintf("arbos%d", version), func(t *testing.T) { run(t, version) })
+	}
+}
+
+func TestWasmStateLoadCostMatchesSLoad(t *testing.T) {
+	forEachStylusGasSchedule(t, func(t *testing.T, version uint64) {
+		for _, warm := range []bool{false, true} {
+			env := newStylusGasEnv(t, version, func(*state.StateDB) {})
+			if warm {
+				env.wasm.AddSlotToAccessList(stylusProgram, stylusKey)
+				env.evm.StateDB.AddSlotToAccessList(stylusProgram, stylusKey)
+			}
+			have := WasmStateLoadCost(env.schedule, env.wasm, stylusProgram, stylusKey)
+			want := env.opcodeCost(t, SLOAD, new(uint256.Int).SetBytes(stylusKey[:]))
+			if have != want {
+				t.Errorf("warm %v: cost mismatch: have %d, want %d", warm, have, want)
+			}
+			env.checkSideEffects(t)
+		}
+	})
+}
+
+func TestWasmStateStoreCostMatchesSStore(t *testing.T) {
+	values := []common.Hash{{}, common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2})}
+	forEachStylusGasSchedule(t, func(t *testing.T, version uint64) {
+		for _, original := range values {
+			for _, current := range values {
+				for _, value := range values {
+					for _, warm := range []bool{false, true} {
+						name := fmt.Sprintf("%x -> %x -> %x warm %v", original[31], current[31], value[31], warm)
+						env := newStylusGasEnv(t, version, func(statedb *state.StateDB) {
+							statedb.SetState(stylusProgram, stylusKey, original)
+						})
+						for _, db := range []StateDB{env.wasm, env.evm.StateDB} {
+							db.SetState(stylusProgram, stylusKey, current)
+							// start from a positive refund so that recreating a slot can take it back
+							db.AddRefund(params.SstoreClearsScheduleRefundEIP2200)
+							if warm {
+								db.AddSlotToAccessList(stylusProgram, stylusKey)
+							}
+						}
+						have := WasmStateStoreCost(env.schedule, env.wasm, stylusProgram, stylusKey, value)
+						want := env.opcodeCost(t, SSTORE, new(uint256.Int).SetBytes(stylusKey[:]), new(uint256.Int).SetBytes(value[:]))
+						if have != want {
+							t.Errorf("%s: cost mismatch: have %d, want %d", name, have, want)
+						}
+						env.checkSideEffects(t)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestWasmCallCostMatchesCall(t *testing.T) {
+	forEachStylusGasSchedule(t, func(t *testing.T, version uint64) {
+		for _, exists := range []bool{false, true} {
+			for _, value := range []int64{0, 1} {
+				for _, warm := range []bool{false, true} {
+					name := fmt.Sprintf("exists %v value %d warm %v", exists, value, warm)
+					env := newStylusGasEnv(t, version, func(statedb *state.StateDB) {
+						if exists {
+							statedb.SetNonce(stylusTarget, 1)
+						}
+					})
+					if warm {
+						env.wasm.AddAddressToAccessList(stylusTarget)
+						env.evm.StateDB.AddAddressToAccessList(stylusTarget)
+					}
+					have, err := WasmCallCost(env.schedule, env.wasm, stylusTarget, big.NewInt(value), 1_000_000)
+					if err != nil {
+						t.Fatalf("%s: call cost failed: %v", name, err)
+					}
+					// requesting no gas for the callee leaves only the costs Stylus charges
+					want := env.opcodeCost(t, CALL,
+						new(uint256.Int),
+						new(uint256.Int).SetBytes(stylusTarget[:]),
+						uint256.NewInt(uint64(value)),
+						new(uint256.Int), new(uint256.Int), new(uint256.Int), new(uint256.Int),
+					)
+					if have != want {
+						t.Errorf("%s: cost mismatch: have %d, want %d", name, have, want)
+					}
+					env.checkSideEffects(t)
+				}
+			}
+		}
+	})
+}
+
+func TestWasmCallCostBudget(t *testing.T) {
+	forEachStylusGasSchedule(t, func(t *testing.T, version uint64) {
+		env := newStylusGasEnv(t, version, func(*state.StateDB) {})
+		full, err := WasmCallCost(env.schedule, env.wasm, stylusTarget, big.NewInt(1), 1_000_000)
+		if err != nil {
+			t.Fatalf("call cost failed: %v", err)
+		}
+		env = newStylusGasEnv(t, version, func(*state.StateDB) {})
+		if _, err := WasmCallCost(env.schedule, env.wasm, stylusTarget, big.NewInt(1), full-1); err != ErrOutOfGas {
+			t.Errorf("budget below the cost: have %v, want %v", err, ErrOutOfGas)
+		}
+	})
+}
+
+func TestWasmAccountTouchCostMatchesAccountCheck(t *testing.T) {
+	forEachStylusGasSchedule(t, func(t *testing.T, version uint64) {
+		for _, withCode := range []bool{false, true} {
+			for _, warm := range []bool{false, true} {
+				env := newStylusGasEnv(t, version, func(*state.StateDB) {})
+				if warm {
+					env.wasm.AddAddressToAccessList(stylusTarget)
+					env.evm.StateDB.AddAddressToAccessList(stylusTarget)
+				}
+				have := WasmAccountTouchCost(env.schedule, env.wasm, stylusTarget, withCode)
+				want := env.opcodeCost(t, EXTCODEHASH, new(uint256.Int).SetBytes(stylusTarget[:]))
+				if withCode {
+					// loading the code is priced as a pre-EIP-2929 EXTCODESIZE per 24kB of code
+					want += params.MaxCodeSize / 24576 * istanbulInstructionSet[EXTCODESIZE].constantGas
+				}
+				if have != want {
+					t.Errorf("code %v warm %v: cost mismatch: have %d, want %d", withCode, warm, have, want)
+				}
+				env.checkSideEffects(t)
+			}
+		}
+	})
+}
+
+func TestWasmCostsDefaultToMergeSchedule(t *testing.T) {
+	env := newStylusGasEnv(t, 0, func(*state.StateDB) {})
+	if *env.schedule != params.StylusGasScheduleMerge {
+		t.Fatalf("unexpected schedule at ArbOS version 0: %+v", env.schedule)
+	}
+	if have, want := WasmStateLoadCost(env.schedule, env.wasm, stylusProgram, stylusKey), params.ColdSloadCostEIP2929; have != want {
+		t.Errorf("state load cost mismatch: have %d, want %d", have, want)
+	}
+	if have, want := WasmAccountTouchCost(env.schedule, env.wasm, stylusTarget, false), params.ColdAccountAccessCostEIP2929; have != want {
+		t.Errorf("account touch cost mismatch: have %d, want %d", have, want)
+	}
+}
+
+func TestStylusGasScheduleOverride(t *testing.T) {
+	custom := params.StylusGasScheduleMerge
+	custom.ColdSloadCost *= 2
+	config := params.ArbitrumDevTestChainConfig()
+	config.ArbitrumChainParams.StylusGasSchedules = map[uint64]*params.StylusGasSchedule{20: &custom}
+	if err := config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+	for version, want := range map[uint64]*params.StylusGasSchedule{
+		19: &params.StylusGasScheduleMerge,
+		20: &custom,
+		21: &custom,
+	} {
+		rules := config.Rules(new(big.Int), true, 0, version)
+		if rules.StylusGasSchedule != want {
+			t.Errorf("ArbOS version %d: unexpected schedule %+v", version, rules.StylusGasSchedule)
+		}
+	}
+
+	custom.SstoreResetGas = custom.ColdSloadCost
+	if err := config.CheckConfigForkOrder(); err == nil {
+		t.Error("underflowing schedule accepted")
+	}
+}
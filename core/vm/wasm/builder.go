@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+// Builder assembles modules, mostly for tests which can't depend on a toolchain.
+// Functions are indexed in the order they're added, the imported ones first, so
+// all imports must be added before the first function.
+type Builder struct {
+	types     [][]byte
+	imports   [][]byte
+	functions []uint32
+	bodies    [][]byte
+	memory    []byte
+	exports   [][]byte
+	data      [][]byte
+}
+
+func (b *Builder) typeIndex(t FuncType) uint32 {
+	encoded := []byte{0x60}
+	encoded = append(encoded, ULEB(uint64(len(t.Params)))...)
+	encoded = append(encoded, valueTypeBytes(t.Params)...)
+	encoded = append(encoded, ULEB(uint64(len(t.Results)))...)
+	encoded = append(encoded, valueTypeBytes(t.Results)...)
+	for i, existing := range b.types {
+		if string(existing) == string(encoded) {
+			return uint32(i)
+		}
+	}
+	b.types = append(b.types, encoded)
+	return uint32(len(b.types) - 1)
+}
+
+// Import adds an imported function, returning its index
+func (b *Builder) Import(module, name string, t FuncType) uint32 {
+	if len(b.functions) > 0 {
+		panic("imports must be added before the functions")
+	}
+	encoded := append(encodeName(module), encodeName(name)...)
+	encoded = append(encoded, ExternFunc)
+	encoded = append(encoded, ULEB(uint64(b.typeIndex(t)))...)
+	b.imports = append(b.imports, encoded)
+	return uint32(len(b.imports) - 1)
+}
+
+// Function adds a function with the locals and code, which must include the final end.
+// It returns the function's index.
+func (b *Builder) Function(t FuncType, locals []ValueType, code ...[]byte) uint32 {
+	b.functions = append(b.functions, b.typeIndex(t))
+	body := ULEB(uint64(len(locals)))
+	for _, local := range locals {
+		body = append(body, 1, byte(local))
+	}
+	for _, part := range code {
+		body = append(body, part...)
+	}
+	b.bodies = append(b.bodies, append(ULEB(uint64(len(body))), body...))
+	return uint32(len(b.imports) + len(b.functions) - 1)
+}
+
+// Memory declares the memory with its initial size in pages
+func (b *Builder) Memory(pages uint32) {
+	b.memory = append([]byte{0}, ULEB(uint64(pages))...)
+}
+
+// Export exports an item under the name
+func (b *Builder) Export(name string, kind byte, index uint32) {
+	encoded := append(encodeName(name), kind)
+	b.exports = append(b.exports, append(encoded, ULEB(uint64(index))...))
+}
+
+// Data initializes the memory at the offset with the bytes
+func (b *Builder) Data(offset uint32, init []byte) {
+	encoded := []byte{0, opI32Const}
+	encoded = append(encoded, SLEB(int64(int32(offset)))...)
+	encoded = append(encoded, opEnd)
+	encoded = append(encoded, ULEB(uint64(len(init)))...)
+	b.data = append(b.data, append(encoded, init...))
+}
+
+// Bytes encodes the module
+func (b *Builder) Bytes() []byte {
+	module := append(append([]byte{}, magic...), 1, 0, 0, 0)
+	section := func(id byte, entries [][]byte) {
+		if len(entries) == 0 {
+			return
+		}
+		payload := ULEB(uint64(len(entries)))
+		for _, entry := range entries {
+			payload = append(payload, entry...)
+		}
+		module = append(module, id)
+		module = append(module, ULEB(uint64(len(payload)))...)
+		module = append(module, payload...)
+	}
+	functions := make([][]byte, len(b.functions))
+	for i, t := range b.functions {
+		functions[i] = ULEB(uint64(t))
+	}
+	var memories [][]byte
+	if b.memory != nil {
+		memories = [][]byte{b.memory}
+	}
+	section(1, b.types)
+	section(2, b.imports)
+	section(3, functions)
+	section(5, memories)
+	section(7, b.exports)
+	section(10, b.bodies)
+	section(11, b.data)
+	return module
+}
+
+func encodeName(name string) []byte {
+	return append(ULEB(uint64(len(name))), name...)
+}
+
+// ULEB encodes an unsigned LEB128 integer
+func ULEB(value uint64) []byte {
+	var encoded []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value != 0 {
+			b |= 0x80
+		}
+		encoded = append(encoded, b)
+		if value == 0 {
+			return encoded
+		}
+	}
+}
+
+// SLEB encodes a signed LEB128 integer
+func SLEB(value int64) []byte {
+	var encoded []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		done := (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0)
+		if !done {
+			b |= 0x80
+		}
+		encoded = append(encoded, b)
+		if done {
+			return encoded
+		}
+	}
+}
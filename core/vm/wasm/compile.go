@@ -0,0 +1,385 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import "fmt"
+
+const (
+	opUnreachable  = 0x00
+	opNop          = 0x01
+	opBlock        = 0x02
+	opLoop         = 0x03
+	opIf           = 0x04
+	opElse         = 0x05
+	opEnd          = 0x0b
+	opBr           = 0x0c
+	opBrIf         = 0x0d
+	opBrTable      = 0x0e
+	opReturn       = 0x0f
+	opCall         = 0x10
+	opCallIndirect = 0x11
+	opDrop         = 0x1a
+	opSelect       = 0x1b
+	opSelectTyped  = 0x1c
+	opLocalGet     = 0x20
+	opLocalSet     = 0x21
+	opLocalTee     = 0x22
+	opGlobalGet    = 0x23
+	opGlobalSet    = 0x24
+
+	opI32Load    = 0x28
+	opI64Load    = 0x29
+	opI32Load8S  = 0x2c
+	opI32Load8U  = 0x2d
+	opI32Load16S = 0x2e
+	opI32Load16U = 0x2f
+	opI64Load8S  = 0x30
+	opI64Load8U  = 0x31
+	opI64Load16S = 0x32
+	opI64Load16U = 0x33
+	opI64Load32S = 0x34
+	opI64Load32U = 0x35
+	opI32Store   = 0x36
+	opI64Store   = 0x37
+	opI32Store8  = 0x3a
+	opI32Store16 = 0x3b
+	opI64Store8  = 0x3c
+	opI64Store16 = 0x3d
+	opI64Store32 = 0x3e
+	opMemorySize = 0x3f
+	opMemoryGrow = 0x40
+
+	opI32Const = 0x41
+	opI64Const = 0x42
+
+	opI32Eqz = 0x45
+	opI32Eq  = 0x46
+	opI32Ne  = 0x47
+	opI32LtS = 0x48
+	opI32LtU = 0x49
+	opI32GtS = 0x4a
+	opI32GtU = 0x4b
+	opI32LeS = 0x4c
+	opI32LeU = 0x4d
+	opI32GeS = 0x4e
+	opI32GeU = 0x4f
+	opI64Eqz = 0x50
+	opI64Eq  = 0x51
+	opI64Ne  = 0x52
+	opI64LtS = 0x53
+	opI64LtU = 0x54
+	opI64GtS = 0x55
+	opI64GtU = 0x56
+	opI64LeS = 0x57
+	opI64LeU = 0x58
+	opI64GeS = 0x59
+	opI64GeU = 0x5a
+
+	opI32Clz    = 0x67
+	opI32Ctz    = 0x68
+	opI32Popcnt = 0x69
+	opI32Add    = 0x6a
+	opI32Sub    = 0x6b
+	opI32Mul    = 0x6c
+	opI32DivS   = 0x6d
+	opI32DivU   = 0x6e
+	opI32RemS   = 0x6f
+	opI32RemU   = 0x70
+	opI32And    = 0x71
+	opI32Or     = 0x72
+	opI32Xor    = 0x73
+	opI32Shl    = 0x74
+	opI32ShrS   = 0x75
+	opI32ShrU   = 0x76
+	opI32Rotl   = 0x77
+	opI32Rotr   = 0x78
+	opI64Clz    = 0x79
+	opI64Ctz    = 0x7a
+	opI64Popcnt = 0x7b
+	opI64Add    = 0x7c
+	opI64Sub    = 0x7d
+	opI64Mul    = 0x7e
+	opI64DivS   = 0x7f
+	opI64DivU   = 0x80
+	opI64RemS   = 0x81
+	opI64RemU   = 0x82
+	opI64And    = 0x83
+	opI64Or     = 0x84
+	opI64Xor    = 0x85
+	opI64Shl    = 0x86
+	opI64ShrS   = 0x87
+	opI64ShrU   = 0x88
+	opI64Rotl   = 0x89
+	opI64Rotr   = 0x8a
+
+	opI32WrapI64      = 0xa7
+	opI64ExtendI32S   = 0xac
+	opI64ExtendI32U   = 0xad
+	opI32Extend8S     = 0xc0
+	opI32Extend16S    = 0xc1
+	opI64Extend8S     = 0xc2
+	opI64Extend16S    = 0xc3
+	opI64Extend32S    = 0xc4
+	opRefNull         = 0xd0
+	opRefFunc         = 0xd2
+	opPrefix          = 0xfc
+	opMemoryInit      = 0x100 + 8
+	opDataDrop        = 0x100 + 9
+	opMemoryCopy      = 0x100 + 10
+	opMemoryFill      = 0x100 + 11
+	blockTypeEmpty    = 0x40
+	maxBrTableEntries = 65536
+)
+
+// instr is a decoded instruction with its immediates and branch targets resolved
+type instr struct {
+	op uint16
+	// immediates: the index of locals, globals, functions and segments, the
+	// offset of memory accesses, the value of constants, the depth of branches.
+	// For blocks, a is the index of the else of an if and b the index of the end.
+	a, b    uint64
+	params  uint32 // the number of values a block takes
+	results uint32 // the number of values a block leaves
+	table   []uint32
+}
+
+// compile decodes the body of a function and checks it only uses supported instructions
+func (m *Module) compile(f *function) error {
+	r := &reader{data: f.body}
+	var code []instr
+	var blocks []int // the open blocks, as indices into the code
+	for !r.eof() {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		in := instr{op: uint16(b)}
+		switch b {
+		case opUnreachable, opNop, opReturn, opDrop, opSelect:
+		case opBlock, opLoop, opIf:
+			if in.params, in.results, err = m.blockType(r); err != nil {
+				return err
+			}
+			blocks = append(blocks, len(code))
+		case opElse:
+			if len(blocks) == 0 || code[blocks[len(blocks)-1]].op != opIf || code[blocks[len(blocks)-1]].a != 0 {
+				return r.fail("else without if")
+			}
+			code[blocks[len(blocks)-1]].a = uint64(len(code))
+		case opEnd:
+			if len(blocks) > 0 {
+				start := &code[blocks[len(blocks)-1]]
+				start.b = uint64(len(code))
+				if start.op == opIf {
+					if start.a == 0 {
+						start.a = start.b
+					} else {
+						code[start.a].b = start.b
+					}
+				}
+				blocks = blocks[:len(blocks)-1]
+			} else if !r.eof() {
+				return r.fail("code after the end of the function")
+			} else {
+				code = append(code, in)
+				f.code = code
+				return nil
+			}
+		case opBr, opBrIf, opCall, opLocalGet, opLocalSet, opLocalTee, opGlobalGet, opGlobalSet:
+			index, err := r.u32()
+			if err != nil {
+				return err
+			}
+			in.a = uint64(index)
+			if err := m.checkIndex(f, b, index); err != nil {
+				return r.fail("%v", err)
+			}
+			if (b == opBr || b == opBrIf) && int(index) > len(blocks) {
+				return r.fail("branch to unknown label %d", index)
+			}
+		case opBrTable:
+			count, err := r.u32()
+			if err != nil {
+				return err
+			}
+			if count >= maxBrTableEntries {
+				return r.fail("branch table too large")
+			}
+			for i := uint32(0); i <= count; i++ {
+				depth, err := r.u32()
+				if err != nil {
+					return err
+				}
+				if int(depth) > len(blocks) {
+					return r.fail("branch to unknown label %d", depth)
+				}
+				in.table = append(in.table, depth)
+			}
+		case opCallIndirect:
+			typ, err := r.u32()
+			if err != nil {
+				return err
+			}
+			table, err := r.u32()
+			if err != nil {
+				return err
+			}
+			if int(typ) >= len(m.Types) || int(table) >= len(m.Tables) {
+				return r.fail("call_indirect to unknown type or table")
+			}
+			in.a, in.b = uint64(typ), uint64(table)
+		case opSelectTyped:
+			if err := decodeVector(r, func() error {
+				_, err := r.valueType()
+				return err
+			}); err != nil {
+				return err
+			}
+			in.op = opSelect
+		case opMemorySize, opMemoryGrow:
+			if memory, err := r.byte(); err != nil {
+				return err
+			} else if memory != 0 || m.Memory == nil {
+				return r.fail("unknown memory")
+			}
+		case opI32Const:
+			value, err := r.s32()
+			if err != nil {
+				return err
+			}
+			in.a = uint64(uint32(value))
+		case opI64Const:
+			value, err := r.s64()
+			if err != nil {
+				return err
+			}
+			in.a = uint64(value)
+		case opPrefix:
+			sub, err := r.u32()
+			if err != nil {
+				return err
+			}
+			in.op = 0x100 + uint16(sub)
+			switch in.op {
+			case opMemoryInit:
+				index, err := r.u32()
+				if err != nil {
+					return err
+				}
+				if int(index) >= len(m.Data) {
+					return r.fail("unknown data segment %d", index)
+				}
+				in.a = uint64(index)
+				if memory, err := r.byte(); err != nil || memory != 0 {
+					return r.fail("unknown memory")
+				}
+			case opDataDrop:
+				index, err := r.u32()
+				if err != nil {
+					return err
+				}
+				if int(index) >= len(m.Data) {
+					return r.fail("unknown data segment %d", index)
+				}
+				in.a = uint64(index)
+			case opMemoryCopy:
+				for i := 0; i < 2; i++ {
+					if memory, err := r.byte(); err != nil || memory != 0 {
+						return r.fail("unknown memory")
+					}
+				}
+			case opMemoryFill:
+				if memory, err := r.byte(); err != nil || memory != 0 {
+					return r.fail("unknown memory")
+				}
+			default:
+				return fmt.Errorf("%w: instruction 0xfc %d", ErrUnsupported, sub)
+			}
+			if m.Memory == nil {
+				return r.fail("unknown memory")
+			}
+		default:
+			switch {
+			case (b >= opI32Load && b <= opI64Load) || (b >= opI32Load8S && b <= opI64Store) || (b >= opI32Store8 && b <= opI64Store32):
+				if m.Memory == nil {
+					return r.fail("unknown memory")
+				}
+				if _, err := r.u32(); err != nil { // alignment
+					return err
+				}
+				offset, err := r.u32()
+				if err != nil {
+					return err
+				}
+				in.a = uint64(offset)
+			case b >= opI32Eqz && b <= opI64GeU:
+			case b >= opI32Clz && b <= opI64Rotr:
+			case b == opI32WrapI64, b == opI64ExtendI32S, b == opI64ExtendI32U:
+			case b >= opI32Extend8S && b <= opI64Extend32S:
+			default:
+				return fmt.Errorf("%w: instruction %#x", ErrUnsupported, b)
+			}
+		}
+		code = append(code, in)
+	}
+	return fmt.Errorf("%w: function without end", ErrMalformed)
+}
+
+// blockType decodes the type of a block, returning its number of parameters and results
+func (m *Module) blockType(r *reader) (uint32, uint32, error) {
+	if r.eof() {
+		return 0, 0, r.fail("unexpected end")
+	}
+	switch next := r.data[r.pos]; ValueType(next) {
+	case blockTypeEmpty:
+		r.pos++
+		return 0, 0, nil
+	case I32, I64, F32, F64, V128, FuncRef, ExternRef:
+		_, err := r.valueType()
+		return 0, 1, err
+	}
+	index, err := r.signed(33)
+	if err != nil {
+		return 0, 0, err
+	}
+	if index < 0 || index >= int64(len(m.Types)) {
+		return 0, 0, r.fail("unknown block type %d", index)
+	}
+	t := m.Types[index]
+	return uint32(len(t.Params)), uint32(len(t.Results)), nil
+}
+
+func (m *Module) checkIndex(f *function, op byte, index uint32) error {
+	switch op {
+	case opCall:
+		if int(index) >= len(m.funcTypes) {
+			return fmt.Errorf("call to unknown function %d", index)
+		}
+	case opLocalGet, opLocalSet, opLocalTee:
+		if int(index) >= len(m.Types[f.typ].Params)+len(f.locals) {
+			return fmt.Errorf("unknown local %d", index)
+		}
+	case opGlobalGet, opGlobalSet:
+		if int(index) >= len(m.Globals) {
+			return fmt.Errorf("unknown global %d", index)
+		}
+		if op == opGlobalSet && !m.Globals[index].Mutable {
+			return fmt.Errorf("global %d is immutable", index)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,686 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+var (
+	ErrTrap       = errors.New("wasm trap")
+	ErrOutOfFuel  = errors.New("wasm out of fuel")
+	ErrLinkFailed = errors.New("wasm link failed")
+)
+
+func trap(reason string) error {
+	return fmt.Errorf("%w: %s", ErrTrap, reason)
+}
+
+// HostFunc is a function imported by a module
+type HostFunc struct {
+	Type FuncType
+	Call func(inst *Instance, args []uint64) ([]uint64, error)
+}
+
+// Imports are the host functions modules may import, keyed by module and name
+type Imports map[string]map[string]HostFunc
+
+type Config struct {
+	MaxPages        uint32 // bound on the memory size, on top of the module's (0 = the wasm limit)
+	MaxCallDepth    int    // bound on the depth of nested calls (0 = 1024)
+	InstructionCost uint64 // fuel charged per instruction executed
+}
+
+// Instance is an instantiated module. It isn't thread safe.
+type Instance struct {
+	// Fuel is charged for every instruction executed, failing the execution
+	// with ErrOutOfFuel once exhausted. Host functions may charge it as well.
+	Fuel uint64
+
+	module   *Module
+	config   Config
+	host     []HostFunc
+	memory   []byte
+	maxPages uint32
+	globals  []uint64
+	tables   [][]int64 // function indices, -1 for null references
+	dropped  []bool    // data segments dropped
+	depth    int
+}
+
+// Instantiate links the module against the imports and initializes its state
+func Instantiate(module *Module, imports Imports, config Config) (*Instance, error) {
+	if config.MaxCallDepth == 0 {
+		config.MaxCallDepth = 1024
+	}
+	inst := &Instance{
+		module:  module,
+		config:  config,
+		dropped: make([]bool, len(module.Data)),
+	}
+	for _, imp := range module.Imports {
+		host, ok := imports[imp.Module][imp.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown import %v.%v", ErrLinkFailed, imp.Module, imp.Name)
+		}
+		if want := module.Types[imp.Type]; !host.Type.Equal(want) {
+			return nil, fmt.Errorf("%w: import %v.%v has type %v, expected %v", ErrLinkFailed, imp.Module, imp.Name, want, host.Type)
+		}
+		inst.host = append(inst.host, host)
+	}
+	if module.Memory != nil {
+		inst.maxPages = 65536
+		if module.Memory.HasMax {
+			inst.maxPages = module.Memory.Max
+		}
+		if config.MaxPages != 0 && config.MaxPages < inst.maxPages {
+			inst.maxPages = config.MaxPages
+		}
+		if module.Memory.Min > inst.maxPages {
+			return nil, fmt.Errorf("%w: module needs %d pages, the limit is %d", ErrLinkFailed, module.Memory.Min, inst.maxPages)
+		}
+		inst.memory = make([]byte, uint64(module.Memory.Min)*PageSize)
+	}
+	for _, global := range module.Globals {
+		inst.globals = append(inst.globals, inst.eval(global.Init))
+	}
+	for _, table := range module.Tables {
+		entries := make([]int64, table.Min)
+		for i := range entries {
+			entries[i] = -1
+		}
+		inst.tables = append(inst.tables, entries)
+	}
+	for _, element := range module.Elements {
+		if !element.Active {
+			continue
+		}
+		table := inst.tables[element.Table]
+		offset := uint64(uint32(inst.eval(element.Offset)))
+		if offset+uint64(len(element.Init)) > uint64(len(table)) {
+			return nil, trap("out of bounds table access")
+		}
+		for i, expr := range element.Init {
+			if expr.op == opRefNull {
+				table[offset+uint64(i)] = -1
+			} else {
+				table[offset+uint64(i)] = int64(inst.eval(expr))
+			}
+		}
+	}
+	for i, data := range module.Data {
+		if !data.Active {
+			continue
+		}
+		offset := uint64(uint32(inst.eval(data.Offset)))
+		if offset+uint64(len(data.Init)) > uint64(len(inst.memory)) {
+			return nil, trap("out of bounds memory access")
+		}
+		copy(inst.memory[offset:], data.Init)
+		inst.dropped[i] = true
+	}
+	if module.Start != nil {
+		if _, err := inst.Call(*module.Start); err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+func (inst *Instance) eval(expr constExpr) uint64 {
+	if expr.op == opGlobalGet {
+		return inst.globals[expr.value]
+	}
+	return expr.value
+}
+
+// Module returns the module instantiated
+func (inst *Instance) Module() *Module {
+	return inst.module
+}
+
+// Memory returns the memory of the instance, which is invalidated by growing it
+func (inst *Instance) Memory() []byte {
+	return inst.memory
+}
+
+// Pages returns the size of the memory in pages
+func (inst *Instance) Pages() uint32 {
+	return uint32(len(inst.memory) / PageSize)
+}
+
+// Read copies size bytes of memory from ptr
+func (inst *Instance) Read(ptr, size uint32) ([]byte, error) {
+	if uint64(ptr)+uint64(size) > uint64(len(inst.memory)) {
+		return nil, trap("out of bounds memory access")
+	}
+	data := make([]byte, size)
+	copy(data, inst.memory[ptr:])
+	return data, nil
+}
+
+// Write copies the data to memory at ptr
+func (inst *Instance) Write(ptr uint32, data []byte) error {
+	if uint64(ptr)+uint64(len(data)) > uint64(len(inst.memory)) {
+		return trap("out of bounds memory access")
+	}
+	copy(inst.memory[ptr:], data)
+	return nil
+}
+
+// Call executes the function at the index, imported functions included
+func (inst *Instance) Call(index uint32, args ...uint64) (results []uint64, err error) {
+	typ, ok := inst.module.FuncType(index)
+	if !ok {
+		return nil, fmt.Errorf("unknown function %d", index)
+	}
+	if len(args) != len(typ.Params) {
+		return nil, fmt.Errorf("function %d takes %d arguments, got %d", index, len(typ.Params), len(args))
+	}
+	defer func() {
+		// ill-typed code underflows the value stack, see Decode
+		if recovered := recover(); recovered != nil {
+			if host, ok := recovered.(hostPanic); ok {
+				panic(host.value)
+			}
+			if _, isRuntimeErr := recovered.(interface{ RuntimeError() }); !isRuntimeErr {
+				panic(recovered)
+			}
+			results, err = nil, trap(fmt.Sprint(recovered))
+		}
+	}()
+	return inst.call(index, args)
+}
+
+// hostPanic wraps the panics of host functions, which aren't traps
+type hostPanic struct {
+	value interface{}
+}
+
+type label struct {
+	cont   int  // the instruction branches continue after
+	height int  // the height of the value stack below the block
+	arity  int  // the number of values branches carry
+	loop   bool // whether branches restart the block
+}
+
+func (inst *Instance) call(index uint32, args []uint64) ([]uint64, error) {
+	if int(index) < len(inst.host) {
+		host := inst.host[index]
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if _, ok := recovered.(hostPanic); ok {
+					panic(recovered)
+				}
+				panic(hostPanic{recovered})
+			}
+		}()
+		results, err := host.Call(inst, args)
+		if err == nil && len(results) != len(host.Type.Results) {
+			return nil, fmt.Errorf("host function %d returned %d results, expected %d", index, len(results), len(host.Type.Results))
+		}
+		return results, err
+	}
+	if inst.depth >= inst.config.MaxCallDepth {
+		return nil, trap("call stack exhausted")
+	}
+	inst.depth++
+	defer func() { inst.depth-- }()
+
+	f := inst.module.functions[int(index)-len(inst.host)]
+	typ := inst.module.Types[f.typ]
+	locals := make([]uint64, len(typ.Params)+len(f.locals))
+	copy(locals, args)
+	code := f.code
+	stack := make([]uint64, 0, 16)
+	labels := []label{{cont: len(code) - 1, arity: len(typ.Results)}}
+
+	pop := func() uint64 {
+		value := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return value
+	}
+	results := func() []uint64 {
+		return append([]uint64(nil), stack[len(stack)-len(typ.Results):]...)
+	}
+	address := func(in *instr, size uint64) (uint64, error) {
+		addr := uint64(uint32(pop())) + in.a
+		if addr+size > uint64(len(inst.memory)) {
+			return 0, trap("out of bounds memory access")
+		}
+		return addr, nil
+	}
+
+	for pc := 0; pc < len(code); pc++ {
+		in := &code[pc]
+		if inst.Fuel < inst.config.InstructionCost {
+			inst.Fuel = 0
+			return nil, ErrOutOfFuel
+		}
+		inst.Fuel -= inst.config.InstructionCost
+
+		switch in.op {
+		case opUnreachable:
+			return nil, trap("unreachable")
+		case opNop:
+		case opBlock:
+			labels = append(labels, label{cont: int(in.b), height: len(stack) - int(in.params), arity: int(in.results)})
+		case opLoop:
+			labels = append(labels, label{cont: pc, height: len(stack) - int(in.params), arity: int(in.params), loop: true})
+		case opIf:
+			condition := pop()
+			labels = append(labels, label{cont: int(in.b), height: len(stack) - int(in.params), arity: int(in.results)})
+			if condition == 0 {
+				if in.a != in.b {
+					pc = int(in.a) // continue after the else
+				} else {
+					pc = int(in.b) - 1 // the end closes the block
+				}
+			}
+		case opElse:
+			pc = int(in.b) - 1 // the end closes the block
+		case opEnd:
+			labels = labels[:len(labels)-1]
+			if len(labels) == 0 {
+				return results(), nil
+			}
+		case opBr, opBrIf, opBrTable:
+			var depth int
+			switch in.op {
+			case opBr:
+				depth = int(in.a)
+			case opBrIf:
+				if pop() == 0 {
+					continue
+				}
+				depth = int(in.a)
+			default:
+				index := pop()
+				if index >= uint64(len(in.table)-1) {
+					index = uint64(len(in.table) - 1)
+				}
+				depth = int(in.table[index])
+			}
+			target := labels[len(labels)-1-depth]
+			copy(stack[target.height:], stack[len(stack)-target.arity:])
+			stack = stack[:target.height+target.arity]
+			if target.loop {
+				labels = labels[:len(labels)-depth]
+			} else {
+				labels = labels[:len(labels)-1-depth]
+				if len(labels) == 0 {
+					return results(), nil
+				}
+			}
+			pc = target.cont
+		case opReturn:
+			return results(), nil
+		case opCall, opCallIndirect:
+			callee := uint32(in.a)
+			if in.op == opCallIndirect {
+				table := inst.tables[in.b]
+				entry := pop()
+				if entry >= uint64(len(table)) {
+					return nil, trap("undefined table element")
+				}
+				if table[entry] < 0 {
+					return nil, trap("uninitialized table element")
+				}
+				callee = uint32(table[entry])
+				if calleeType, _ := inst.module.FuncType(callee); !calleeType.Equal(inst.module.Types[in.a]) {
+					return nil, trap("indirect call type mismatch")
+				}
+			}
+			calleeType, _ := inst.module.FuncType(callee)
+			args := append([]uint64(nil), stack[len(stack)-len(calleeType.Params):]...)
+			stack = stack[:len(stack)-len(calleeType.Params)]
+			values, err := inst.call(callee, args)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, values...)
+		case opDrop:
+			pop()
+		case opSelect:
+			condition, second := pop(), pop()
+			if condition == 0 {
+				stack[len(stack)-1] = second
+			}
+		case opLocalGet:
+			stack = append(stack, locals[in.a])
+		case opLocalSet:
+			locals[in.a] = pop()
+		case opLocalTee:
+			locals[in.a] = stack[len(stack)-1]
+		case opGlobalGet:
+			stack = append(stack, inst.globals[in.a])
+		case opGlobalSet:
+			inst.globals[in.a] = pop()
+
+		case opI32Load, opI64Load, opI32Load8S, opI32Load8U, opI32Load16S, opI32Load16U,
+			opI64Load8S, opI64Load8U, opI64Load16S, opI64Load16U, opI64Load32S, opI64Load32U:
+			size := loadSize(in.op)
+			addr, err := address(in, size)
+			if err != nil {
+				return nil, err
+			}
+			mem := inst.memory[addr:]
+			var value uint64
+			switch in.op {
+			case opI32Load, opI64Load32U:
+				value = uint64(binary.LittleEndian.Uint32(mem))
+			case opI64Load:
+				value = binary.LittleEndian.Uint64(mem)
+			case opI32Load8S:
+				value = uint64(uint32(int32(int8(mem[0]))))
+			case opI32Load8U, opI64Load8U:
+				value = uint64(mem[0])
+			case opI32Load16S:
+				value = uint64(uint32(int32(int16(binary.LittleEndian.Uint16(mem)))))
+			case opI32Load16U, opI64Load16U:
+				value = uint64(binary.LittleEndian.Uint16(mem))
+			case opI64Load8S:
+				value = uint64(int64(int8(mem[0])))
+			case opI64Load16S:
+				value = uint64(int64(int16(binary.LittleEndian.Uint16(mem))))
+			case opI64Load32S:
+				value = uint64(int64(int32(binary.LittleEndian.Uint32(mem))))
+			}
+			stack = append(stack, value)
+		case opI32Store, opI64Store, opI32Store8, opI32Store16, opI64Store8, opI64Store16, opI64Store32:
+			value := pop()
+			size := storeSize(in.op)
+			addr, err := address(in, size)
+			if err != nil {
+				return nil, err
+			}
+			mem := inst.memory[addr:]
+			switch size {
+			case 1:
+				mem[0] = byte(value)
+			case 2:
+				binary.LittleEndian.PutUint16(mem, uint16(value))
+			case 4:
+				binary.LittleEndian.PutUint32(mem, uint32(value))
+			case 8:
+				binary.LittleEndian.PutUint64(mem, value)
+			}
+		case opMemorySize:
+			stack = append(stack, uint64(inst.Pages()))
+		case opMemoryGrow:
+			pages := uint64(uint32(pop()))
+			old := uint64(inst.Pages())
+			if old+pages > uint64(inst.maxPages) {
+				stack = append(stack, uint64(math.MaxUint32))
+			} else {
+				inst.memory = append(inst.memory, make([]byte, pages*PageSize)...)
+				stack = append(stack, old)
+			}
+		case opMemoryInit, opMemoryCopy, opMemoryFill:
+			size, source, dest := uint64(uint32(pop())), uint64(uint32(pop())), uint64(uint32(pop()))
+			if dest+size > uint64(len(inst.memory)) {
+				return nil, trap("out of bounds memory access")
+			}
+			switch in.op {
+			case opMemoryInit:
+				var data []byte
+				if !inst.dropped[in.a] {
+					data = inst.module.Data[in.a].Init
+				}
+				if source+size > uint64(len(data)) {
+					return nil, trap("out of bounds memory access")
+				}
+				copy(inst.memory[dest:dest+size], data[source:])
+			case opMemoryCopy:
+				if source+size > uint64(len(inst.memory)) {
+					return nil, trap("out of bounds memory access")
+				}
+				copy(inst.memory[dest:dest+size], inst.memory[source:source+size])
+			case opMemoryFill:
+				fill := inst.memory[dest : dest+size]
+				for i := range fill {
+					fill[i] = byte(source)
+				}
+			}
+		case opDataDrop:
+			inst.dropped[in.a] = true
+
+		case opI32Const, opI64Const:
+			stack = append(stack, in.a)
+
+		case opI32Eqz:
+			stack[len(stack)-1] = boolValue(uint32(stack[len(stack)-1]) == 0)
+		case opI64Eqz:
+			stack[len(stack)-1] = boolValue(stack[len(stack)-1] == 0)
+		case opI32Clz:
+			stack[len(stack)-1] = uint64(bits.LeadingZeros32(uint32(stack[len(stack)-1])))
+		case opI32Ctz:
+			stack[len(stack)-1] = uint64(bits.TrailingZeros32(uint32(stack[len(stack)-1])))
+		case opI32Popcnt:
+			stack[len(stack)-1] = uint64(bits.OnesCount32(uint32(stack[len(stack)-1])))
+		case opI64Clz:
+			stack[len(stack)-1] = uint64(bits.LeadingZeros64(stack[len(stack)-1]))
+		case opI64Ctz:
+			stack[len(stack)-1] = uint64(bits.TrailingZeros64(stack[len(stack)-1]))
+		case opI64Popcnt:
+			stack[len(stack)-1] = uint64(bits.OnesCount64(stack[len(stack)-1]))
+		case opI32WrapI64:
+			stack[len(stack)-1] = uint64(uint32(stack[len(stack)-1]))
+		case opI64ExtendI32S:
+			stack[len(stack)-1] = uint64(int64(int32(stack[len(stack)-1])))
+		case opI64ExtendI32U:
+			stack[len(stack)-1] = uint64(uint32(stack[len(stack)-1]))
+		case opI32Extend8S:
+			stack[len(stack)-1] = uint64(uint32(int32(int8(stack[len(stack)-1]))))
+		case opI32Extend16S:
+			stack[len(stack)-1] = uint64(uint32(int32(int16(stack[len(stack)-1]))))
+		case opI64Extend8S:
+			stack[len(stack)-1] = uint64(int64(int8(stack[len(stack)-1])))
+		case opI64Extend16S:
+			stack[len(stack)-1] = uint64(int64(int16(stack[len(stack)-1])))
+		case opI64Extend32S:
+			stack[len(stack)-1] = uint64(int64(int32(stack[len(stack)-1])))
+
+		default:
+			y := pop()
+			x := stack[len(stack)-1]
+			var value uint64
+			var err error
+			if (in.op >= opI32Eq && in.op <= opI32GeU) || (in.op >= opI32Add && in.op <= opI32Rotr) {
+				value, err = binaryOp32(in.op, uint32(x), uint32(y))
+			} else {
+				value, err = binaryOp64(in.op, x, y)
+			}
+			if err != nil {
+				return nil, err
+			}
+			stack[len(stack)-1] = value
+		}
+	}
+	return results(), nil
+}
+
+func loadSize(op uint16) uint64 {
+	switch op {
+	case opI32Load8S, opI32Load8U, opI64Load8S, opI64Load8U:
+		return 1
+	case opI32Load16S, opI32Load16U, opI64Load16S, opI64Load16U:
+		return 2
+	case opI32Load, opI64Load32S, opI64Load32U:
+		return 4
+	}
+	return 8
+}
+
+func storeSize(op uint16) uint64 {
+	switch op {
+	case opI32Store8, opI64Store8:
+		return 1
+	case opI32Store16, opI64Store16:
+		return 2
+	case opI32Store, opI64Store32:
+		return 4
+	}
+	return 8
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func binaryOp32(op uint16, x, y uint32) (uint64, error) {
+	switch op {
+	case opI32Eq:
+		return boolValue(x == y), nil
+	case opI32Ne:
+		return boolValue(x != y), nil
+	case opI32LtS:
+		return boolValue(int32(x) < int32(y)), nil
+	case opI32LtU:
+		return boolValue(x < y), nil
+	case opI32GtS:
+		return boolValue(int32(x) > int32(y)), nil
+	case opI32GtU:
+		return boolValue(x > y), nil
+	case opI32LeS:
+		return boolValue(int32(x) <= int32(y)), nil
+	case opI32LeU:
+		return boolValue(x <= y), nil
+	case opI32GeS:
+		return boolValue(int32(x) >= int32(y)), nil
+	case opI32GeU:
+		return boolValue(x >= y), nil
+	case opI32Add:
+		return uint64(x + y), nil
+	case opI32Sub:
+		return uint64(x - y), nil
+	case opI32Mul:
+		return uint64(x * y), nil
+	case opI32DivS, opI32DivU, opI32RemS, opI32RemU:
+		if y == 0 {
+			return 0, trap("integer divide by zero")
+		}
+		switch op {
+		case opI32DivS:
+			if int32(x) == math.MinInt32 && int32(y) == -1 {
+				return 0, trap("integer overflow")
+			}
+			return uint64(uint32(int32(x) / int32(y))), nil
+		case opI32DivU:
+			return uint64(x / y), nil
+		case opI32RemS:
+			if int32(y) == -1 {
+				return 0, nil
+			}
+			return uint64(uint32(int32(x) % int32(y))), nil
+		default:
+			return uint64(x % y), nil
+		}
+	case opI32And:
+		return uint64(x & y), nil
+	case opI32Or:
+		return uint64(x | y), nil
+	case opI32Xor:
+		return uint64(x ^ y), nil
+	case opI32Shl:
+		return uint64(x << (y & 31)), nil
+	case opI32ShrS:
+		return uint64(uint32(int32(x) >> (y & 31))), nil
+	case opI32ShrU:
+		return uint64(x >> (y & 31)), nil
+	case opI32Rotl:
+		return uint64(bits.RotateLeft32(x, int(y&31))), nil
+	case opI32Rotr:
+		return uint64(bits.RotateLeft32(x, -int(y&31))), nil
+	}
+	return 0, fmt.Errorf("%w: instruction %#x", ErrUnsupported, op)
+}
+
+func binaryOp64(op uint16, x, y uint64) (uint64, error) {
+	switch op {
+	case opI64Eq:
+		return boolValue(x == y), nil
+	case opI64Ne:
+		return boolValue(x != y), nil
+	case opI64LtS:
+		return boolValue(int64(x) < int64(y)), nil
+	case opI64LtU:
+		return boolValue(x < y), nil
+	case opI64GtS:
+		return boolValue(int64(x) > int64(y)), nil
+	case opI64GtU:
+		return boolValue(x > y), nil
+	case opI64LeS:
+		return boolValue(int64(x) <= int64(y)), nil
+	case opI64LeU:
+		return boolValue(x <= y), nil
+	case opI64GeS:
+		return boolValue(int64(x) >= int64(y)), nil
+	case opI64GeU:
+		return boolValue(x >= y), nil
+	case opI64Add:
+		return x + y, nil
+	case opI64Sub:
+		return x - y, nil
+	case opI64Mul:
+		return x * y, nil
+	case opI64DivS, opI64DivU, opI64RemS, opI64RemU:
+		if y == 0 {
+			return 0, trap("integer divide by zero")
+		}
+		switch op {
+		case opI64DivS:
+			if int64(x) == math.MinInt64 && int64(y) == -1 {
+				return 0, trap("integer overflow")
+			}
+			return uint64(int64(x) / int64(y)), nil
+		case opI64DivU:
+			return x / y, nil
+		case opI64RemS:
+			if int64(y) == -1 {
+				return 0, nil
+			}
+			return uint64(int64(x) % int64(y)), nil
+		default:
+			return x % y, nil
+		}
+	case opI64And:
+		return x & y, nil
+	case opI64Or:
+		return x | y, nil
+	case opI64Xor:
+		return x ^ y, nil
+	case opI64Shl:
+		return x << (y & 63), nil
+	case opI64ShrS:
+		return uint64(int64(x) >> (y & 63)), nil
+	case opI64ShrU:
+		return x >> (y & 63), nil
+	case opI64Rotl:
+		return bits.RotateLeft64(x, int(y&63)), nil
+	case opI64Rotr:
+		return bits.RotateLeft64(x, -int(y&63)), nil
+	}
+	return 0, fmt.Errorf("%w: instruction %#x", ErrUnsupported, op)
+}
@@ -0,0 +1,760 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package wasm implements a small WebAssembly interpreter for the subset of the
+// language Stylus programs are restricted to: integer instructions, a single
+// memory, function tables and the bulk memory operations. Floating point and
+// SIMD instructions are rejected when decoding.
+//
+// It's a reference implementation meant for tests and tools, favoring simplicity
+// over speed.
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const PageSize = 65536
+
+var magic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// IsModule returns whether the bytes start with the WebAssembly module magic
+func IsModule(b []byte) bool {
+	return bytes.HasPrefix(b, magic)
+}
+
+type ValueType byte
+
+const (
+	I32       ValueType = 0x7f
+	I64       ValueType = 0x7e
+	F32       ValueType = 0x7d
+	F64       ValueType = 0x7c
+	V128      ValueType = 0x7b
+	FuncRef   ValueType = 0x70
+	ExternRef ValueType = 0x6f
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case I32:
+		return "i32"
+	case I64:
+		return "i64"
+	case F32:
+		return "f32"
+	case F64:
+		return "f64"
+	case V128:
+		return "v128"
+	case FuncRef:
+		return "funcref"
+	case ExternRef:
+		return "externref"
+	}
+	return fmt.Sprintf("type(%#x)", byte(t))
+}
+
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+func (t FuncType) Equal(other FuncType) bool {
+	return bytes.Equal(valueTypeBytes(t.Params), valueTypeBytes(other.Params)) &&
+		bytes.Equal(valueTypeBytes(t.Results), valueTypeBytes(other.Results))
+}
+
+func (t FuncType) String() string {
+	return fmt.Sprintf("%v -> %v", t.Params, t.Results)
+}
+
+func valueTypeBytes(types []ValueType) []byte {
+	b := make([]byte, len(types))
+	for i, t := range types {
+		b[i] = byte(t)
+	}
+	return b
+}
+
+type Limits struct {
+	Min    uint32
+	Max    uint32
+	HasMax bool
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Type   uint32 // index of the function type, only functions can be imported
+}
+
+const (
+	ExternFunc   byte = 0x00
+	ExternTable  byte = 0x01
+	ExternMemory byte = 0x02
+	ExternGlobal byte = 0x03
+)
+
+type Export struct {
+	Name  string
+	Kind  byte
+	Index uint32
+}
+
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    constExpr
+}
+
+type Element struct {
+	Active bool
+	Table  uint32
+	Offset constExpr
+	Init   []constExpr // function references
+}
+
+type Data struct {
+	Active bool
+	Offset constExpr
+	Init   []byte
+}
+
+type function struct {
+	typ    uint32
+	locals []ValueType
+	body   []byte
+	code   []instr
+}
+
+// Module is a decoded WebAssembly module
+type Module struct {
+	Types     []FuncType
+	Imports   []Import
+	Exports   []Export
+	Globals   []Global
+	Tables    []Limits
+	Memory    *Limits
+	Elements  []Element
+	Data      []Data
+	Start     *uint32
+	funcTypes []uint32 // type index of every function, imported ones first
+	functions []*function
+}
+
+// FuncType returns the type of the function at the index, imported functions included
+func (m *Module) FuncType(index uint32) (FuncType, bool) {
+	if int(index) >= len(m.funcTypes) {
+		return FuncType{}, false
+	}
+	return m.Types[m.funcTypes[index]], true
+}
+
+// ExportedFunc returns the index of the function exported under the name
+func (m *Module) ExportedFunc(name string) (uint32, bool) {
+	for _, export := range m.Exports {
+		if export.Name == name && export.Kind == ExternFunc {
+			return export.Index, true
+		}
+	}
+	return 0, false
+}
+
+var ErrMalformed = errors.New("malformed wasm")
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) fail(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrMalformed, r.pos, fmt.Sprintf(format, args...))
+}
+
+func (r *reader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.eof() {
+		return 0, r.fail("unexpected end")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n uint32) ([]byte, error) {
+	if uint64(r.pos)+uint64(n) > uint64(len(r.data)) {
+		return nil, r.fail("unexpected end")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	value, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 || n > 5 || value > 0xffffffff {
+		return 0, r.fail("bad u32")
+	}
+	r.pos += n
+	return uint32(value), nil
+}
+
+func (r *reader) signed(bits uint) (int64, error) {
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			break
+		}
+		if shift >= bits {
+			return 0, r.fail("bad s%d", bits)
+		}
+	}
+	return result, nil
+}
+
+func (r *reader) s32() (int32, error) {
+	value, err := r.signed(32)
+	return int32(value), err
+}
+
+func (r *reader) s64() (int64, error) {
+	return r.signed(64)
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(n)
+	return string(b), err
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch t := ValueType(b); t {
+	case I32, I64, FuncRef, ExternRef:
+		return t, nil
+	case F32, F64, V128:
+		return 0, fmt.Errorf("%w: %v values aren't supported", ErrUnsupported, t)
+	default:
+		return 0, r.fail("bad value type %#x", b)
+	}
+}
+
+func (r *reader) limits() (Limits, error) {
+	flags, err := r.byte()
+	if err != nil {
+		return Limits{}, err
+	}
+	var limits Limits
+	if limits.Min, err = r.u32(); err != nil {
+		return Limits{}, err
+	}
+	switch flags {
+	case 0:
+	case 1:
+		limits.HasMax = true
+		if limits.Max, err = r.u32(); err != nil {
+			return Limits{}, err
+		}
+		if limits.Max < limits.Min {
+			return Limits{}, r.fail("maximum below minimum")
+		}
+	default:
+		return Limits{}, r.fail("bad limits flags %#x", flags)
+	}
+	return limits, nil
+}
+
+var ErrUnsupported = errors.New("unsupported wasm")
+
+// Decode decodes a WebAssembly module, checking the indices it refers to and that
+// it only uses supported instructions. Instructions aren't type checked, so that
+// ill-typed code traps when executed instead of being rejected.
+func Decode(data []byte) (*Module, error) {
+	r := &reader{data: data}
+	header, err := r.bytes(8)
+	if err != nil || !bytes.Equal(header[:4], magic) {
+		return nil, fmt.Errorf("%w: bad magic", ErrMalformed)
+	}
+	if binary.LittleEndian.Uint32(header[4:]) != 1 {
+		return nil, fmt.Errorf("%w: unknown version", ErrUnsupported)
+	}
+	m := &Module{}
+	var functionTypes []uint32
+	var lastSection byte
+	for !r.eof() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(size)
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 {
+			// the data count section comes before the code section despite its id
+			order := id * 2
+			if id == 12 {
+				order = 19
+			}
+			if order <= lastSection {
+				return nil, fmt.Errorf("%w: section %d out of order", ErrMalformed, id)
+			}
+			lastSection = order
+		}
+		s := &reader{data: payload}
+		switch id {
+		case 0: // custom
+		case 1:
+			err = decodeVector(s, func() error {
+				form, err := s.byte()
+				if err != nil {
+					return err
+				}
+				if form != 0x60 {
+					return s.fail("bad function type form %#x", form)
+				}
+				var t FuncType
+				for _, types := range []*[]ValueType{&t.Params, &t.Results} {
+					if err := decodeVector(s, func() error {
+						vt, err := s.valueType()
+						*types = append(*types, vt)
+						return err
+					}); err != nil {
+						return err
+					}
+				}
+				m.Types = append(m.Types, t)
+				return nil
+			})
+		case 2:
+			err = decodeVector(s, func() error {
+				var imp Import
+				var err error
+				if imp.Module, err = s.name(); err != nil {
+					return err
+				}
+				if imp.Name, err = s.name(); err != nil {
+					return err
+				}
+				kind, err := s.byte()
+				if err != nil {
+					return err
+				}
+				if kind != ExternFunc {
+					return fmt.Errorf("%w: import %v.%v isn't a function", ErrUnsupported, imp.Module, imp.Name)
+				}
+				if imp.Type, err = s.u32(); err != nil {
+					return err
+				}
+				if int(imp.Type) >= len(m.Types) {
+					return s.fail("unknown type %d", imp.Type)
+				}
+				m.Imports = append(m.Imports, imp)
+				m.funcTypes = append(m.funcTypes, imp.Type)
+				return nil
+			})
+		case 3:
+			err = decodeVector(s, func() error {
+				index, err := s.u32()
+				if err != nil {
+					return err
+				}
+				if int(index) >= len(m.Types) {
+					return s.fail("unknown type %d", index)
+				}
+				functionTypes = append(functionTypes, index)
+				return nil
+			})
+		case 4:
+			err = decodeVector(s, func() error {
+				if t, err := s.valueType(); err != nil {
+					return err
+				} else if t != FuncRef {
+					return fmt.Errorf("%w: %v tables", ErrUnsupported, t)
+				}
+				limits, err := s.limits()
+				m.Tables = append(m.Tables, limits)
+				return err
+			})
+		case 5:
+			err = decodeVector(s, func() error {
+				if m.Memory != nil {
+					return fmt.Errorf("%w: multiple memories", ErrUnsupported)
+				}
+				limits, err := s.limits()
+				if err == nil && (limits.Min > 65536 || limits.Max > 65536) {
+					err = s.fail("memory too large")
+				}
+				m.Memory = &limits
+				return err
+			})
+		case 6:
+			err = decodeVector(s, func() error {
+				var global Global
+				var err error
+				if global.Type, err = s.valueType(); err != nil {
+					return err
+				}
+				mutable, err := s.byte()
+				if err != nil {
+					return err
+				}
+				if mutable > 1 {
+					return s.fail("bad global mutability %#x", mutable)
+				}
+				global.Mutable = mutable == 1
+				if global.Init, err = decodeConstExpr(s); err != nil {
+					return err
+				}
+				m.Globals = append(m.Globals, global)
+				return nil
+			})
+		case 7:
+			names := make(map[string]bool)
+			err = decodeVector(s, func() error {
+				var export Export
+				var err error
+				if export.Name, err = s.name(); err != nil {
+					return err
+				}
+				if names[export.Name] {
+					return s.fail("duplicate export %v", export.Name)
+				}
+				names[export.Name] = true
+				if export.Kind, err = s.byte(); err != nil {
+					return err
+				}
+				if export.Kind > ExternGlobal {
+					return s.fail("bad export kind %#x", export.Kind)
+				}
+				export.Index, err = s.u32()
+				m.Exports = append(m.Exports, export)
+				return err
+			})
+		case 8:
+			start, err := s.u32()
+			if err != nil {
+				return nil, err
+			}
+			m.Start = &start
+		case 9:
+			err = decodeVector(s, func() error {
+				element, err := decodeElement(s)
+				m.Elements = append(m.Elements, element)
+				return err
+			})
+		case 10:
+			if uint64(len(functionTypes)) != uint64(peekCount(payload)) {
+				return nil, fmt.Errorf("%w: function and code section lengths differ", ErrMalformed)
+			}
+			err = decodeVector(s, func() error {
+				size, err := s.u32()
+				if err != nil {
+					return err
+				}
+				body, err := s.bytes(size)
+				if err != nil {
+					return err
+				}
+				b := &reader{data: body}
+				f := &function{typ: functionTypes[len(m.functions)]}
+				if err := decodeVector(b, func() error {
+					count, err := b.u32()
+					if err != nil {
+						return err
+					}
+					t, err := b.valueType()
+					if err != nil {
+						return err
+					}
+					if uint64(len(f.locals))+uint64(count) > 50000 {
+						return b.fail("too many locals")
+					}
+					for i := uint32(0); i < count; i++ {
+						f.locals = append(f.locals, t)
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+				f.body = body[b.pos:]
+				m.functions = append(m.functions, f)
+				return nil
+			})
+		case 11:
+			err = decodeVector(s, func() error {
+				data, err := decodeData(s)
+				m.Data = append(m.Data, data)
+				return err
+			})
+		case 12:
+			_, err = s.u32()
+		default:
+			return nil, fmt.Errorf("%w: unknown section %d", ErrMalformed, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if id != 0 && !s.eof() {
+			return nil, fmt.Errorf("%w: section %d has trailing bytes", ErrMalformed, id)
+		}
+	}
+	if len(functionTypes) != len(m.functions) {
+		return nil, fmt.Errorf("%w: function and code section lengths differ", ErrMalformed)
+	}
+	m.funcTypes = append(m.funcTypes, functionTypes...)
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	for i, f := range m.functions {
+		if err := m.compile(f); err != nil {
+			return nil, fmt.Errorf("function %d: %w", len(m.Imports)+i, err)
+		}
+	}
+	return m, nil
+}
+
+func peekCount(payload []byte) uint32 {
+	count, _ := (&reader{data: payload}).u32()
+	return count
+}
+
+func decodeVector(r *reader, decode func() error) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		if err := decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeElement(r *reader) (Element, error) {
+	var element Element
+	flags, err := r.u32()
+	if err != nil {
+		return element, err
+	}
+	if flags > 7 {
+		return element, r.fail("bad element flags %#x", flags)
+	}
+	passiveOrDeclarative := flags&1 != 0
+	explicitTable := flags&2 != 0
+	expressions := flags&4 != 0
+
+	element.Active = !passiveOrDeclarative
+	if element.Active {
+		if explicitTable {
+			if element.Table, err = r.u32(); err != nil {
+				return element, err
+			}
+		}
+		if element.Offset, err = decodeConstExpr(r); err != nil {
+			return element, err
+		}
+	}
+	if passiveOrDeclarative || explicitTable {
+		// the element kind or reference type
+		kind, err := r.byte()
+		if err != nil {
+			return element, err
+		}
+		if (expressions && ValueType(kind) != FuncRef) || (!expressions && kind != 0) {
+			return element, fmt.Errorf("%w: element kind %#x", ErrUnsupported, kind)
+		}
+	}
+	err = decodeVector(r, func() error {
+		if expressions {
+			expr, err := decodeConstExpr(r)
+			element.Init = append(element.Init, expr)
+			return err
+		}
+		index, err := r.u32()
+		element.Init = append(element.Init, constExpr{op: opRefFunc, value: uint64(index)})
+		return err
+	})
+	return element, err
+}
+
+func decodeData(r *reader) (Data, error) {
+	var data Data
+	flags, err := r.u32()
+	if err != nil {
+		return data, err
+	}
+	switch flags {
+	case 0, 2:
+		if flags == 2 {
+			if memory, err := r.u32(); err != nil {
+				return data, err
+			} else if memory != 0 {
+				return data, r.fail("unknown memory %d", memory)
+			}
+		}
+		data.Active = true
+		if data.Offset, err = decodeConstExpr(r); err != nil {
+			return data, err
+		}
+	case 1:
+	default:
+		return data, r.fail("bad data flags %#x", flags)
+	}
+	size, err := r.u32()
+	if err != nil {
+		return data, err
+	}
+	data.Init, err = r.bytes(size)
+	return data, err
+}
+
+// constExpr is a constant expression, made of a single instruction
+type constExpr struct {
+	op    byte
+	value uint64
+}
+
+func decodeConstExpr(r *reader) (constExpr, error) {
+	op, err := r.byte()
+	if err != nil {
+		return constExpr{}, err
+	}
+	expr := constExpr{op: op}
+	switch op {
+	case opI32Const:
+		value, err := r.s32()
+		if err != nil {
+			return expr, err
+		}
+		expr.value = uint64(uint32(value))
+	case opI64Const:
+		value, err := r.s64()
+		if err != nil {
+			return expr, err
+		}
+		expr.value = uint64(value)
+	case opGlobalGet, opRefFunc:
+		index, err := r.u32()
+		if err != nil {
+			return expr, err
+		}
+		expr.value = uint64(index)
+	case opRefNull:
+		if _, err := r.byte(); err != nil {
+			return expr, err
+		}
+	default:
+		return expr, r.fail("unsupported constant expression %#x", op)
+	}
+	if end, err := r.byte(); err != nil {
+		return expr, err
+	} else if end != opEnd {
+		return expr, r.fail("constant expression isn't a single instruction")
+	}
+	return expr, nil
+}
+
+// validate checks the indices the module refers to
+func (m *Module) validate() error {
+	functions := uint32(len(m.funcTypes))
+	for _, export := range m.Exports {
+		var count int
+		switch export.Kind {
+		case ExternFunc:
+			count = int(functions)
+		case ExternTable:
+			count = len(m.Tables)
+		case ExternMemory:
+			if m.Memory != nil {
+				count = 1
+			}
+		case ExternGlobal:
+			count = len(m.Globals)
+		}
+		if int(export.Index) >= count {
+			return fmt.Errorf("%w: export %v refers to an unknown item", ErrMalformed, export.Name)
+		}
+	}
+	if m.Start != nil && *m.Start >= functions {
+		return fmt.Errorf("%w: unknown start function", ErrMalformed)
+	}
+	checkExpr := func(expr constExpr, globals int) error {
+		switch expr.op {
+		case opGlobalGet:
+			if expr.value >= uint64(globals) || m.Globals[expr.value].Mutable {
+				return fmt.Errorf("%w: constant expression reads global %d", ErrMalformed, expr.value)
+			}
+		case opRefFunc:
+			if expr.value >= uint64(functions) {
+				return fmt.Errorf("%w: reference to unknown function %d", ErrMalformed, expr.value)
+			}
+		}
+		return nil
+	}
+	for i, global := range m.Globals {
+		if err := checkExpr(global.Init, i); err != nil {
+			return err
+		}
+	}
+	for _, element := range m.Elements {
+		if element.Active && int(element.Table) >= len(m.Tables) {
+			return fmt.Errorf("%w: element for unknown table %d", ErrMalformed, element.Table)
+		}
+		for _, expr := range append([]constExpr{element.Offset}, element.Init...) {
+			if err := checkExpr(expr, len(m.Globals)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, data := range m.Data {
+		if data.Active && m.Memory == nil {
+			return fmt.Errorf("%w: data segment without a memory", ErrMalformed)
+		}
+		if err := checkExpr(data.Offset, len(m.Globals)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	i32ToI32 = FuncType{Params: []ValueType{I32}, Results: []ValueType{I32}}
+	i64ToI64 = FuncType{Params: []ValueType{I64}, Results: []ValueType{I64}}
+)
+
+func instantiate(t *testing.T, b *Builder, imports Imports) *Instance {
+	t.Helper()
+	module, err := Decode(b.Bytes())
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	inst, err := Instantiate(module, imports, Config{InstructionCost: 1})
+	if err != nil {
+		t.Fatalf("failed to instantiate: %v", err)
+	}
+	inst.Fuel = 1_000_000
+	return inst
+}
+
+func call(t *testing.T, inst *Instance, index uint32, args ...uint64) uint64 {
+	t.Helper()
+	results, err := inst.Call(index, args...)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	return results[0]
+}
+
+func TestRecursion(t *testing.T) {
+	b := &Builder{}
+	// factorial(n) = n == 0 ? 1 : n * factorial(n - 1)
+	factorial := b.Function(i64ToI64, nil, []byte{
+		opLocalGet, 0, opI64Eqz,
+		opIf, byte(I64),
+		opI64Const, 1,
+		opElse,
+		opLocalGet, 0,
+		opLocalGet, 0, opI64Const, 1, opI64Sub,
+		opCall, 0,
+		opI64Mul,
+		opEnd,
+		opEnd,
+	})
+	inst := instantiate(t, b, nil)
+	if have := call(t, inst, factorial, 20); have != 2432902008176640000 {
+		t.Errorf("factorial(20): have %d", have)
+	}
+}
+
+func TestLoopAndBranchTable(t *testing.T) {
+	b := &Builder{}
+	// sum(n) = n + (n-1) + ... + 1, with a loop
+	sum := b.Function(i32ToI32, []ValueType{I32}, []byte{
+		opBlock, blockTypeEmpty,
+		opLoop, blockTypeEmpty,
+		opLocalGet, 0, opI32Eqz, opBrIf, 1,
+		opLocalGet, 1, opLocalGet, 0, opI32Add, opLocalSet, 1,
+		opLocalGet, 0, opI32Const, 1, opI32Sub, opLocalSet, 0,
+		opBr, 0,
+		opEnd,
+		opEnd,
+		opLocalGet, 1,
+		opEnd,
+	})
+	// pick(n) = [10, 20][n], 30 otherwise
+	pick := b.Function(i32ToI32, nil, []byte{
+		opBlock, blockTypeEmpty,
+		opBlock, blockTypeEmpty,
+		opBlock, blockTypeEmpty,
+		opLocalGet, 0,
+		opBrTable, 2, 0, 1, 2,
+		opEnd,
+		opI32Const, 10, opReturn,
+		opEnd,
+		opI32Const, 20, opReturn,
+		opEnd,
+		opI32Const, 30,
+		opEnd,
+	})
+	inst := instantiate(t, b, nil)
+	if have := call(t, inst, sum, 100); have != 5050 {
+		t.Errorf("sum(100): have %d", have)
+	}
+	for n, want := range []uint64{10, 20, 30, 30} {
+		if have := call(t, inst, pick, uint64(n)); have != want {
+			t.Errorf("pick(%d): have %d, want %d", n, have, want)
+		}
+	}
+}
+
+func TestMemoryAndHostFunctions(t *testing.T) {
+	b := &Builder{}
+	var stored []byte
+	store := b.Import("env", "store", FuncType{Params: []ValueType{I32, I32}})
+	b.Memory(1)
+	b.Data(16, []byte("hello"))
+	// copies the data to 0, upper-cases its first byte and hands it to the host
+	run := b.Function(FuncType{Results: []ValueType{I32}}, nil, []byte{
+		opI32Const, 0, opI32Const, 16, opI32Const, 5, opPrefix, 10, 0, 0,
+		opI32Const, 0,
+		opI32Const, 0, opI32Load8U, 0, 0, opI32Const, 32, opI32Sub,
+		opI32Store8, 0, 0,
+		opI32Const, 0, opI32Const, 5, opCall, byte(store),
+		opI32Const, 1, opMemoryGrow, 0,
+		opEnd,
+	})
+	b.Export("memory", ExternMemory, 0)
+	inst := instantiate(t, b, Imports{"env": {"store": HostFunc{
+		Type: FuncType{Params: []ValueType{I32, I32}},
+		Call: func(inst *Instance, args []uint64) ([]uint64, error) {
+			data, err := inst.Read(uint32(args[0]), uint32(args[1]))
+			stored = data
+			return nil, err
+		},
+	}}})
+	if have := call(t, inst, run); have != 1 {
+		t.Errorf("memory.grow returned %d", have)
+	}
+	if string(stored) != "Hello" {
+		t.Errorf("host got %q", stored)
+	}
+	if inst.Pages() != 2 {
+		t.Errorf("memory has %d pages", inst.Pages())
+	}
+}
+
+func TestTraps(t *testing.T) {
+	b := &Builder{}
+	div := b.Function(FuncType{Params: []ValueType{I32, I32}, Results: []ValueType{I32}}, nil, []byte{
+		opLocalGet, 0, opLocalGet, 1, opI32DivS, opEnd,
+	})
+	spin := b.Function(FuncType{}, nil, []byte{opLoop, blockTypeEmpty, opBr, 0, opEnd, opEnd})
+	underflow := b.Function(FuncType{Results: []ValueType{I32}}, nil, []byte{opI32Add, opEnd})
+	inst := instantiate(t, b, nil)
+
+	if _, err := inst.Call(div, 1, 0); !errors.Is(err, ErrTrap) {
+		t.Errorf("division by zero: have %v", err)
+	}
+	if _, err := inst.Call(div, 0x80000000, 0xffffffff); !errors.Is(err, ErrTrap) {
+		t.Errorf("division overflow: have %v", err)
+	}
+	if have := call(t, inst, div, uint64(uint32(0xfffffff6)), 3); have != uint64(uint32(0xfffffffd)) {
+		t.Errorf("-10 / 3: have %#x", have)
+	}
+	if _, err := inst.Call(spin); !errors.Is(err, ErrOutOfFuel) {
+		t.Errorf("infinite loop: have %v", err)
+	}
+	inst.Fuel = 1_000_000
+	if _, err := inst.Call(underflow); !errors.Is(err, ErrTrap) {
+		t.Errorf("stack underflow: have %v", err)
+	}
+}
+
+func TestRejectsFloats(t *testing.T) {
+	b := &Builder{}
+	b.Function(FuncType{}, nil, []byte{opI32Const, 0, 0xb2 /* f32.convert_i32_s */, opDrop, opEnd})
+	if _, err := Decode(b.Bytes()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("float instruction: have %v", err)
+	}
+	b = &Builder{}
+	b.Function(FuncType{Params: []ValueType{F64}}, nil, []byte{opEnd})
+	if _, err := Decode(b.Bytes()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("float parameter: have %v", err)
+	}
+}
+
+// testModule builds a module using most sections, whose code section comes last
+func testModule() []byte {
+	b := &Builder{}
+	b.Import("env", "log", FuncType{Params: []ValueType{I32}})
+	b.Memory(1)
+	run := b.Function(i32ToI32, []ValueType{I64}, []byte{opLocalGet, 0, opCall, 0, opLocalGet, 0, opEnd})
+	b.Export("run", ExternFunc, run)
+	b.Export("memory", ExternMemory, 0)
+	return b.Bytes()
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	header := append(append([]byte{}, magic...), 1, 0, 0, 0)
+	module := func(sections ...byte) []byte {
+		return append(append([]byte{}, header...), sections...)
+	}
+	for name, test := range map[string]struct {
+		data []byte
+		want error
+	}{
+		"empty":                 {nil, ErrMalformed},
+		"bad magic":             {[]byte{0x00, 'w', 'a', 's', 'm', 1, 0, 0, 0}, ErrMalformed},
+		"unknown version":       {append(append([]byte{}, magic...), 2, 0, 0, 0), ErrUnsupported},
+		"section beyond end":    {module(1, 5, 1, 0x60), ErrMalformed},
+		"overlong size":         {module(1, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01), ErrMalformed},
+		"unknown section":       {module(13, 0), ErrMalformed},
+		"sections out of order": {module(3, 1, 0, 1, 1, 0), ErrMalformed},
+		"trailing bytes":        {module(1, 2, 0, 0), ErrMalformed},
+		"bad type form":         {module(1, 4, 1, 0x61, 0, 0), ErrMalformed},
+		"float type":            {module(1, 5, 1, 0x60, 1, byte(F32), 0), ErrUnsupported},
+		"unknown type":          {module(3, 2, 1, 0), ErrMalformed},
+		"missing code":          {module(1, 4, 1, 0x60, 0, 0, 3, 2, 1, 0), ErrMalformed},
+		"code without function": {module(10, 4, 1, 2, 0, opEnd), ErrMalformed},
+		"memory too large":      {module(5, 5, 1, 0, 0x81, 0x80, 0x08), ErrMalformed},
+		"maximum below minimum": {module(5, 4, 1, 1, 2, 1), ErrMalformed},
+		"multiple memories":     {module(5, 5, 2, 0, 1, 0, 1), ErrUnsupported},
+		"bad global init":       {module(6, 6, 1, byte(I32), 0, opI32Const, 0, opDrop), ErrMalformed},
+		"duplicate export":      {module(7, 9, 2, 1, 'a', ExternFunc, 0, 1, 'a', ExternFunc, 0), ErrMalformed},
+		"unknown export":        {module(7, 5, 1, 1, 'a', ExternFunc, 0), ErrMalformed},
+		"unknown start":         {module(8, 1, 0), ErrMalformed},
+		"too many locals":       {module(1, 4, 1, 0x60, 0, 0, 3, 2, 1, 0, 10, 9, 1, 7, 1, 0xff, 0xff, 0x03, byte(I32), opEnd, 0), ErrMalformed},
+	} {
+		if _, err := Decode(test.data); !errors.Is(err, test.want) {
+			t.Errorf("%s: have %v, want %v", name, err, test.want)
+		}
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data := testModule()
+	if _, err := Decode(data); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	// cut short the module misses part of a section or its code, unless it's cut
+	// between the sections preceding the functions
+	for n := 0; n < len(data); n++ {
+		module, err := Decode(data[:n])
+		if err == nil && len(module.functions) != 0 || err != nil && !errors.Is(err, ErrMalformed) {
+			t.Errorf("%d of %d bytes: have %v, want a malformed module", n, len(data), err)
+		}
+	}
+}
+
+// FuzzDecode checks that decoding arbitrary bytes fails with an error rather than
+// a panic, and that the errors are those of malformed or unsupported modules.
+func FuzzDecode(f *testing.F) {
+	f.Add(testModule())
+	f.Add([]byte(nil))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if _, err := Decode(data); err != nil && !errors.Is(err, ErrMalformed) && !errors.Is(err, ErrUnsupported) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,610 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/wasm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// WasmExecutor executes the Stylus programs run by DefaultTxProcessor. ArbOS
+// provides its own processing hook instead.
+type WasmExecutor interface {
+	ExecuteWASM(scope *ScopeContext, input []byte, interpreter *EVMInterpreter) ([]byte, error)
+}
+
+const (
+	StylusInkPrice       = 10000 // ink per gas
+	stylusInstructionInk = 100   // ink per wasm instruction
+	stylusHostioInk      = 8400  // ink per hostio call
+	stylusFreePages      = 2     // pages open at once before paying for memory
+	stylusPageGas        = 1000  // gas per page opened beyond the free ones
+	stylusPageLimit      = 128   // pages a program's memory may grow to
+	stylusMaxCallDepth   = 1024  // wasm calls a program may nest
+	stylusMaxTopics      = 4
+
+	stylusEntrypoint = "user_entrypoint"
+	stylusHostModule = "vm_hooks"
+
+	// the statuses of programs and of the calls they make
+	stylusStatusSuccess = 0
+	stylusStatusFailure = 1
+)
+
+var ErrStylusCompressed = errors.New("compressed stylus program without a decompressor")
+
+// ReferenceWasmExecutor is a pure-Go WasmExecutor implementing the core of the
+// Stylus hostio surface: calldata and results, storage, calls and their return
+// data, logs, hashing, and the message, transaction and block context. Creating
+// contracts isn't supported.
+//
+// It's meant for tests and tools, and isn't consensus compatible with ArbOS:
+// programs aren't activated nor instrumented, and the ink prices of instructions,
+// hostios and memory only approximate those of Stylus. State accesses are
+// priced by the Stylus gas schedule like ArbOS does. The hostios a program invokes
+// are reported to the tracer of the EVM.
+//
+// An executor keeps per-EVM state, so every EVM needs its own.
+type ReferenceWasmExecutor struct {
+	// Decompress expands the brotli-compressed programs deployed on chain. Without
+	// it, only programs whose code is the plain wasm after the Stylus prefix run.
+	Decompress func(compressed []byte, dictionary byte) ([]byte, error)
+
+	modules map[common.Hash]*wasm.Module // decoded programs by code hash
+	active  map[common.Address]uint32    // the frames each program executes in
+	imports wasm.Imports                 // the hostios, bound to the executor
+	runs    []*stylusRun                 // the programs executing, the innermost last
+}
+
+// NewReferenceWasmExecutor creates an executor for a single EVM. It's cheap, the
+// executor setting itself up once it runs a program.
+func NewReferenceWasmExecutor() *ReferenceWasmExecutor {
+	return &ReferenceWasmExecutor{}
+}
+
+// stylusRun is a program being executed
+type stylusRun struct {
+	inst        *wasm.Instance
+	scope       *ScopeContext
+	interpreter *EVMInterpreter
+	evm         *EVM
+	input       []byte
+	output      []byte
+	reentrant   bool
+}
+
+type hostio struct {
+	params  []wasm.ValueType
+	results []wasm.ValueType
+	call    func(run *stylusRun, args []uint64) ([]uint64, error)
+}
+
+func (e *ReferenceWasmExecutor) ExecuteWASM(scope *ScopeContext, input []byte, interpreter *EVMInterpreter) ([]byte, error) {
+	if e.imports == nil {
+		e.modules = make(map[common.Hash]*wasm.Module)
+		e.active = make(map[common.Address]uint32)
+		e.imports = e.hostImports()
+	}
+	evm := interpreter.Evm()
+	contract := scope.Contract
+	module, err := e.module(contract)
+	if err != nil {
+		return nil, err
+	}
+	inst, err := wasm.Instantiate(module, e.imports, wasm.Config{
+		MaxPages:        stylusPageLimit,
+		MaxCallDepth:    stylusMaxCallDepth,
+		InstructionCost: stylusInstructionInk,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entrypoint, ok := module.ExportedFunc(stylusEntrypoint)
+	if !ok {
+		return nil, fmt.Errorf("stylus program doesn't export %v", stylusEntrypoint)
+	}
+	if typ, _ := module.FuncType(entrypoint); !typ.Equal(wasm.FuncType{Params: []wasm.ValueType{wasm.I32}, Results: []wasm.ValueType{wasm.I32}}) {
+		return nil, fmt.Errorf("stylus entrypoint has type %v", typ)
+	}
+	inst.Fuel = saturatingMul(contract.Gas, StylusInkPrice)
+	defer func() { contract.Gas = inst.Fuel / StylusInkPrice }()
+
+	run := &stylusRun{
+		inst:        inst,
+		scope:       scope,
+		interpreter: interpreter,
+		evm:         evm,
+		input:       input,
+	}
+	// pay for the memory the program starts with, which is released when it returns
+	open, ever := evm.StateDB.GetStylusPages()
+	if err := run.useGas(stylusMemoryCost(uint16(inst.Pages()), open, ever)); err != nil {
+		return nil, err
+	}
+	evm.StateDB.AddStylusPages(uint16(inst.Pages()))
+	defer evm.StateDB.SetStylusPagesOpen(open)
+
+	address := contract.Address()
+	e.active[address]++
+	run.reentrant = e.active[address] > 1
+	e.runs = append(e.runs, run)
+	defer func() {
+		e.runs = e.runs[:len(e.runs)-1]
+		if e.active[address]--; e.active[address] == 0 {
+			delete(e.active, address)
+		}
+	}()
+
+	results, err := inst.Call(entrypoint, uint64(len(input)))
+	if errors.Is(err, wasm.ErrOutOfFuel) {
+		return nil, ErrOutOfGas
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch uint32(results[0]) {
+	case stylusStatusSuccess:
+		return run.output, nil
+	case stylusStatusFailure:
+		return run.output, ErrExecutionReverted
+	default:
+		return nil, fmt.Errorf("stylus program returned unknown status %d", uint32(results[0]))
+	}
+}
+
+// module decodes the program of the contract
+func (e *ReferenceWasmExecutor) module(contract *Contract) (*wasm.Module, error) {
+	codeHash := contract.CodeHash
+	if codeHash == (common.Hash{}) {
+		codeHash = crypto.Keccak256Hash(contract.Code)
+	}
+	if module, ok := e.modules[codeHash]; ok {
+		return module, nil
+	}
+	payload, dictionary, err := state.StripStylusPrefix(contract.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !wasm.IsModule(payload) {
+		if e.Decompress == nil {
+			return nil, ErrStylusCompressed
+		}
+		if payload, err = e.Decompress(payload, dictionary); err != nil {
+			return nil, fmt.Errorf("failed to decompress stylus program: %w", err)
+		}
+	}
+	module, err := wasm.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+	if module.Start != nil {
+		return nil, errors.New("stylus programs can't have a start function")
+	}
+	e.modules[codeHash] = module
+	return module, nil
+}
+
+func (e *ReferenceWasmExecutor) hostImports() wasm.Imports {
+	imports := make(map[string]wasm.HostFunc, len(stylusHostios))
+	for name, io := range stylusHostios {
+		name, io := name, io
+		imports[name] = wasm.HostFunc{
+			Type: wasm.FuncType{Params: io.params, Results: io.results},
+			Call: func(inst *wasm.Instance, args []uint64) ([]uint64, error) {
+				run := e.runs[len(e.runs)-1]
+				startInk := run.inst.Fuel
+				if err := run.useInk(stylusHostioInk); err != nil {
+					return nil, err
+				}
+				results, err := io.call(run, args)
+				if tracer := run.evm.Config.Tracer; tracer != nil && err == nil {
+					tracer.CaptureStylusHostio(name, encodeWasmValues(io.params, args), encodeWasmValues(io.results, results), startInk, run.inst.Fuel)
+				}
+				return results, err
+			},
+		}
+	}
+	return wasm.Imports{stylusHostModule: imports}
+}
+
+// encodeWasmValues encodes the arguments or results of a hostio for tracers, each
+// big-endian in the width of its type
+func encodeWasmValues(types []wasm.ValueType, values []uint64) []byte {
+	var encoded []byte
+	for i, typ := range types {
+		if typ == wasm.I32 {
+			encoded = binary.BigEndian.AppendUint32(encoded, uint32(values[i]))
+		} else {
+			encoded = binary.BigEndian.AppendUint64(encoded, values[i])
+		}
+	}
+	return encoded
+}
+
+// stylusMemoryCost is the gas to open pages given the pages open and ever opened in the transaction
+func stylusMemoryCost(pages, open, ever uint16) uint64 {
+	newOpen := common.SaturatingUAdd(open, pages)
+	if common.MaxInt(ever, newOpen) <= stylusFreePages {
+		return 0
+	}
+	paid := func(pages uint16) uint64 {
+		if pages < stylusFreePages {
+			return 0
+		}
+		return uint64(pages - stylusFreePages)
+	}
+	return (paid(newOpen) - paid(open)) * stylusPageGas
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
+func (run *stylusRun) useInk(ink uint64) error {
+	if run.inst.Fuel < ink {
+		run.inst.Fuel = 0
+		return ErrOutOfGas
+	}
+	run.inst.Fuel -= ink
+	return nil
+}
+
+func (run *stylusRun) useGas(gas uint64) error {
+	return run.useInk(saturatingMul(gas, StylusInkPrice))
+}
+
+func (run *stylusRun) gasLeft() uint64 {
+	return run.inst.Fuel / StylusInkPrice
+}
+
+// read copies memory, paying for the words copied
+func (run *stylusRun) read(ptr, size uint64) ([]byte, error) {
+	if err := run.useGas(toWordSize(size) * params.CopyGas); err != nil {
+		return nil, err
+	}
+	return run.inst.Read(uint32(ptr), uint32(size))
+}
+
+// write copies data to memory, paying for the words copied
+func (run *stylusRun) write(ptr uint64, data []byte) error {
+	if err := run.useGas(toWordSize(uint64(len(data))) * params.CopyGas); err != nil {
+		return err
+	}
+	return run.inst.Write(uint32(ptr), data)
+}
+
+func (run *stylusRun) readAddress(ptr uint64) (common.Address, error) {
+	data, err := run.read(ptr, common.AddressLength)
+	return common.BytesToAddress(data), err
+}
+
+func (run *stylusRun) readWord(ptr uint64) (common.Hash, error) {
+	data, err := run.read(ptr, common.HashLength)
+	return common.BytesToHash(data), err
+}
+
+func (run *stylusRun) writeBig(ptr uint64, value *big.Int) error {
+	word := common.Hash{}
+	if value != nil {
+		value.FillBytes(word[:])
+	}
+	return run.write(ptr, word[:])
+}
+
+func (run *stylusRun) schedule() *params.StylusGasSchedule {
+	return run.evm.StylusGasSchedule()
+}
+
+func (run *stylusRun) checkWritable() error {
+	if run.interpreter.ReadOnly() {
+		return ErrWriteProtection
+	}
+	return nil
+}
+
+func u64Result(value uint64) []uint64 {
+	return []uint64{value}
+}
+
+// stylusHostios is the hostio surface implemented, named after the vm_hooks of the Stylus SDK
+var stylusHostios = map[string]hostio{
+	"read_args": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.write(args[0], run.input)
+	}},
+	"write_result": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		data, err := run.read(args[0], args[1])
+		run.output = data
+		return nil, err
+	}},
+	"pay_for_memory_grow": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		pages := uint16(args[0])
+		if pages == 0 {
+			return nil, nil
+		}
+		db := run.evm.StateDB
+		open, ever := db.GetStylusPages()
+		if err := run.useGas(stylusMemoryCost(pages, open, ever)); err != nil {
+			return nil, err
+		}
+		db.AddStylusPages(pages)
+		return nil, nil
+	}},
+	"storage_load_bytes32": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		key, err := run.readWord(args[0])
+		if err != nil {
+			return nil, err
+		}
+		address := run.scope.Contract.Address()
+		if err := run.useGas(WasmStateLoadCost(run.schedule(), run.evm.StateDB, address, key)); err != nil {
+			return nil, err
+		}
+		value := run.evm.StateDB.GetState(address, key)
+		return nil, run.write(args[1], value[:])
+	}},
+	"storage_store_bytes32": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: storageStore},
+	"storage_cache_bytes32": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: storageStore},
+	"storage_flush_cache": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		// stores are written through, leaving nothing to flush
+		return nil, nil
+	}},
+	"call_contract": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32, wasm.I32, wasm.I64, wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		value, err := run.readWord(args[3])
+		if err != nil {
+			return nil, err
+		}
+		return callContract(run, CALL, args[0], args[1], args[2], value.Big(), args[4], args[5])
+	}},
+	"delegate_call_contract": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32, wasm.I64, wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return callContract(run, DELEGATECALL, args[0], args[1], args[2], new(big.Int), args[3], args[4])
+	}},
+	"static_call_contract": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32, wasm.I64, wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return callContract(run, STATICCALL, args[0], args[1], args[2], new(big.Int), args[3], args[4])
+	}},
+	"return_data_size": {results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(uint64(len(run.interpreter.GetReturnData()))), nil
+	}},
+	"read_return_data": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		data := run.interpreter.GetReturnData()
+		offset, size := common.MinInt(args[1], uint64(len(data))), args[2]
+		data = data[offset:common.MinInt(offset+size, uint64(len(data)))]
+		return u64Result(uint64(len(data))), run.write(args[0], data)
+	}},
+	"emit_log": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		if err := run.checkWritable(); err != nil {
+			return nil, err
+		}
+		size, topics := args[1], args[2]
+		if topics > stylusMaxTopics || size < topics*common.HashLength {
+			return nil, fmt.Errorf("bad log of %d bytes with %d topics", size, topics)
+		}
+		if err := run.useGas(params.LogGas + topics*params.LogTopicGas + (size-topics*common.HashLength)*params.LogDataGas); err != nil {
+			return nil, err
+		}
+		data, err := run.read(args[0], size)
+		if err != nil {
+			return nil, err
+		}
+		log := &types.Log{
+			Address:     run.scope.Contract.Address(),
+			Data:        data[topics*common.HashLength:],
+			BlockNumber: run.evm.Context.BlockNumber.Uint64(),
+		}
+		for i := uint64(0); i < topics; i++ {
+			log.Topics = append(log.Topics, common.BytesToHash(data[i*common.HashLength:(i+1)*common.HashLength]))
+		}
+		run.evm.StateDB.AddLog(log)
+		return nil, nil
+	}},
+	"account_balance": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		address, err := touchAccount(run, args[0], false)
+		if err != nil {
+			return nil, err
+		}
+		return nil, run.writeBig(args[1], run.evm.StateDB.GetBalance(address))
+	}},
+	"account_code": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32, wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		address, err := touchAccount(run, args[0], true)
+		if err != nil {
+			return nil, err
+		}
+		code := run.evm.StateDB.GetCode(address)
+		offset := common.MinInt(args[1], uint64(len(code)))
+		code = code[offset:common.MinInt(offset+args[2], uint64(len(code)))]
+		return u64Result(uint64(len(code))), run.write(args[3], code)
+	}},
+	"account_code_size": {params: []wasm.ValueType{wasm.I32}, results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		address, err := touchAccount(run, args[0], true)
+		if err != nil {
+			return nil, err
+		}
+		return u64Result(uint64(run.evm.StateDB.GetCodeSize(address))), nil
+	}},
+	"account_codehash": {params: []wasm.ValueType{wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		address, err := touchAccount(run, args[0], false)
+		if err != nil {
+			return nil, err
+		}
+		var hash common.Hash
+		if !run.evm.StateDB.Empty(address) {
+			hash = run.evm.StateDB.GetCodeHash(address)
+		}
+		return nil, run.write(args[1], hash[:])
+	}},
+	"native_keccak256": {params: []wasm.ValueType{wasm.I32, wasm.I32, wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		if err := run.useGas(params.Keccak256Gas + toWordSize(args[1])*params.Keccak256WordGas); err != nil {
+			return nil, err
+		}
+		data, err := run.read(args[0], args[1])
+		if err != nil {
+			return nil, err
+		}
+		return nil, run.write(args[2], crypto.Keccak256(data))
+	}},
+	"block_basefee": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.writeBig(args[0], run.evm.Context.BaseFee)
+	}},
+	"block_coinbase": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.write(args[0], run.evm.Context.Coinbase[:])
+	}},
+	"block_gas_limit": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(run.evm.Context.GasLimit), nil
+	}},
+	"block_number": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		number, err := run.evm.ProcessingHook.L1BlockNumber(run.evm.Context)
+		return u64Result(number), err
+	}},
+	"block_timestamp": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(run.evm.Context.Time), nil
+	}},
+	"chainid": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(run.evm.chainConfig.ChainID.Uint64()), nil
+	}},
+	"contract_address": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		address := run.scope.Contract.Address()
+		return nil, run.write(args[0], address[:])
+	}},
+	"msg_sender": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		caller := run.scope.Contract.Caller()
+		return nil, run.write(args[0], caller[:])
+	}},
+	"msg_value": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.writeBig(args[0], run.scope.Contract.Value())
+	}},
+	"msg_reentrant": {results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		if run.reentrant {
+			return u64Result(1), nil
+		}
+		return u64Result(0), nil
+	}},
+	"tx_gas_price": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.writeBig(args[0], run.evm.ProcessingHook.GasPriceOp(run.evm))
+	}},
+	"tx_ink_price": {results: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(StylusInkPrice), nil
+	}},
+	"tx_origin": {params: []wasm.ValueType{wasm.I32}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return nil, run.write(args[0], run.evm.Origin[:])
+	}},
+	"evm_gas_left": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(run.gasLeft()), nil
+	}},
+	"evm_ink_left": {results: []wasm.ValueType{wasm.I64}, call: func(run *stylusRun, args []uint64) ([]uint64, error) {
+		return u64Result(run.inst.Fuel), nil
+	}},
+}
+
+func storageStore(run *stylusRun, args []uint64) ([]uint64, error) {
+	if err := run.checkWritable(); err != nil {
+		return nil, err
+	}
+	key, err := run.readWord(args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := run.readWord(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if run.gasLeft() <= params.SstoreSentryGasEIP2200 {
+		return nil, ErrOutOfGas
+	}
+	address := run.scope.Contract.Address()
+	if err := run.useGas(WasmStateStoreCost(run.schedule(), run.evm.StateDB, address, key, value)); err != nil {
+		return nil, err
+	}
+	run.evm.StateDB.SetState(address, key, value)
+	return nil, nil
+}
+
+func touchAccount(run *stylusRun, ptr uint64, withCode bool) (common.Address, error) {
+	address, err := run.readAddress(ptr)
+	if err != nil {
+		return address, err
+	}
+	return address, run.useGas(WasmAccountTouchCost(run.schedule(), run.evm.StateDB, address, withCode))
+}
+
+// callContract makes a call from the program, writing the length of the data
+// returned to returnDataLenPtr
+func callContract(run *stylusRun, kind OpCode, contractPtr, calldataPtr, calldataLen uint64, value *big.Int, gas uint64, returnDataLenPtr uint64) ([]uint64, error) {
+	target, err := run.readAddress(contractPtr)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := run.read(calldataPtr, calldataLen)
+	if err != nil {
+		return nil, err
+	}
+	if value.Sign() != 0 {
+		if err := run.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+	cost, err := WasmCallCost(run.schedule(), run.evm.StateDB, target, value, run.gasLeft())
+	if err != nil {
+		return nil, err
+	}
+	if err := run.useGas(cost); err != nil {
+		return nil, err
+	}
+	// EIP-150: the callee gets at most all but one 64th of the gas left
+	available := run.gasLeft()
+	gas = common.MinInt(gas, available-available/64)
+	if err := run.useGas(gas); err != nil {
+		return nil, err
+	}
+	if value.Sign() != 0 {
+		gas += params.CallStipend
+	}
+
+	evm, contract := run.evm, run.scope.Contract
+	var ret []byte
+	var returnGas uint64
+	switch kind {
+	case CALL:
+		ret, returnGas, err = evm.Call(contract, target, calldata, gas, value)
+	case DELEGATECALL:
+		ret, returnGas, err = evm.DelegateCall(contract, target, calldata, gas)
+	case STATICCALL:
+		ret, returnGas, err = evm.StaticCall(contract, target, calldata, gas)
+	}
+	run.inst.Fuel = common.SaturatingUAdd(run.inst.Fuel, saturatingMul(returnGas, StylusInkPrice))
+	run.interpreter.SetReturnData(ret)
+
+	lenBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lenBytes, uint32(len(ret)))
+	if err := run.inst.Write(uint32(returnDataLenPtr), lenBytes); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return u64Result(stylusStatusFailure), nil
+	}
+	return u64Result(stylusStatusSuccess), nil
+}
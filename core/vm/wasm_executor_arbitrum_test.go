@@ -0,0 +1,253 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/wasm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// the wasm instructions used by the test programs
+const (
+	wasmIf       = 0x04
+	wasmEnd      = 0x0b
+	wasmReturn   = 0x0f
+	wasmCall     = 0x10
+	wasmDrop     = 0x1a
+	wasmLocalGet = 0x20
+	wasmI32Const = 0x41
+	wasmI64Const = 0x42
+	wasmI32Ne    = 0x47
+	wasmI32Sub   = 0x6b
+)
+
+var (
+	wasmCaller = common.BytesToAddress([]byte("caller"))
+	wasmStore  = common.BytesToAddress([]byte("store"))
+	wasmProxy  = common.BytesToAddress([]byte("proxy"))
+	wasmAnswer = common.BytesToAddress([]byte("answer"))
+)
+
+// programBuilder assembles a Stylus program, importing the hostios it needs
+type programBuilder struct {
+	wasm.Builder
+	hostios map[string]byte
+}
+
+func newProgramBuilder(hostios ...string) *programBuilder {
+	b := &programBuilder{hostios: make(map[string]byte)}
+	for _, name := range hostios {
+		io := stylusHostios[name]
+		b.hostios[name] = byte(b.Import(stylusHostModule, name, wasm.FuncType{Params: io.params, Results: io.results}))
+	}
+	return b
+}
+
+// call calls the hostio, its arguments having been pushed by the code
+func (b *programBuilder) call(name string, code ...byte) []byte {
+	return append(code, wasmCall, b.hostios[name])
+}
+
+// program exports the entrypoint and memory, returning the code to deploy
+func (b *programBuilder) program(code ...[]byte) []byte {
+	b.Memory(1)
+	entrypoint := b.Function(wasm.FuncType{Params: []wasm.ValueType{wasm.I32}, Results: []wasm.ValueType{wasm.I32}}, nil, code...)
+	b.Export(stylusEntrypoint, wasm.ExternFunc, entrypoint)
+	b.Export("memory", wasm.ExternMemory, 0)
+	return append(state.NewStylusPrefix(0), b.Bytes()...)
+}
+
+func i32Const(value int32) []byte {
+	return append([]byte{wasmI32Const}, wasm.SLEB(int64(value))...)
+}
+
+// storeProgram stores the value in the key it's called with, logs the value and
+// returns it. It reverts with its calldata if not called with a key and value.
+func storeProgram() []byte {
+	b := newProgramBuilder("read_args", "write_result", "storage_store_bytes32", "storage_load_bytes32", "emit_log")
+	return b.program(
+		b.call("read_args", i32Const(0)...),
+		[]byte{wasmLocalGet, 0}, i32Const(64), []byte{wasmI32Ne, wasmIf, 0x40},
+		b.call("write_result", append(i32Const(0), wasmLocalGet, 0)...),
+		i32Const(1), []byte{wasmReturn, wasmEnd},
+		b.call("storage_store_bytes32", append(i32Const(0), i32Const(32)...)...),
+		b.call("storage_load_bytes32", append(i32Const(0), i32Const(64)...)...),
+		b.call("emit_log", append(append(i32Const(64), i32Const(32)...), i32Const(1)...)...),
+		b.call("write_result", append(i32Const(64), i32Const(32)...)...),
+		i32Const(0), []byte{wasmEnd},
+	)
+}
+
+// proxyProgram calls the contract whose address it's called with, passing along the
+// rest of its calldata and returning the first word the callee returns. It reverts
+// if the call fails.
+func proxyProgram(static bool) []byte {
+	name := "call_contract"
+	if static {
+		name = "static_call_contract"
+	}
+	b := newProgramBuilder("read_args", "write_result", name, "read_return_data")
+	args := append(append(i32Const(0), i32Const(20)...), wasmLocalGet, 0)
+	args = append(args, i32Const(20)...)
+	args = append(args, wasmI32Sub)
+	if !static {
+		args = append(args, i32Const(1024)...) // zero value
+	}
+	args = append(args, wasmI64Const, 0x7f) // all the gas
+	args = append(args, i32Const(1056)...)
+	return b.program(
+		b.call("read_args", i32Const(0)...),
+		b.call(name, args...),
+		b.call("read_return_data", append(append(i32Const(1088), i32Const(0)...), i32Const(32)...)...),
+		[]byte{wasmDrop}, // the size read
+		b.call("write_result", append(i32Const(1088), i32Const(32)...)...),
+		[]byte{wasmEnd},
+	)
+}
+
+func newWasmTestEVM(t *testing.T) (*EVM, *state.StateDB) {
+	t.Helper()
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for address, code := range map[common.Address][]byte{
+		wasmStore:  storeProgram(),
+		wasmProxy:  proxyProgram(false),
+		wasmAnswer: common.FromHex("602a60005260206000f3"), // returns 42
+	} {
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, code)
+	}
+	statedb.Finalise(true)
+	// the transaction would've warmed the programs called
+	statedb.AddAddressToAccessList(wasmStore)
+	statedb.AddAddressToAccessList(wasmProxy)
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(1),
+		Random:      &common.Hash{},
+	}
+	config := Config{NewWasmExecutor: func(*EVM) WasmExecutor { return NewReferenceWasmExecutor() }}
+	evm := NewEVM(vmctx, TxContext{Origin: wasmCaller}, statedb, params.ArbitrumDevTestChainConfig(), config)
+	return evm, statedb
+}
+
+func TestWasmExecutorStorageAndLogs(t *testing.T) {
+	evm, statedb := newWasmTestEVM(t)
+	key, value := common.HexToHash("0x01"), common.HexToHash("0xc0ffee")
+
+	ret, gasLeft, err := evm.Call(AccountRef(wasmCaller), wasmStore, append(key[:], value[:]...), 1_000_000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !bytes.Equal(ret, value[:]) {
+		t.Errorf("program returned %x", ret)
+	}
+	if have := statedb.GetState(wasmStore, key); have != value {
+		t.Errorf("stored %v", have)
+	}
+	if logs := statedb.Logs(); len(logs) != 1 || logs[0].Address != wasmStore || len(logs[0].Topics) != 1 || logs[0].Topics[0] != value {
+		t.Errorf("unexpected logs %v", logs)
+	}
+	gasUsed := 1_000_000 - gasLeft
+	if gasUsed < params.SstoreSetGasEIP2200+params.ColdSloadCostEIP2929 {
+		t.Errorf("program used only %d gas", gasUsed)
+	}
+	if open, _ := statedb.GetStylusPages(); open != 0 {
+		t.Errorf("program left %d pages open", open)
+	}
+
+	// reverts return the calldata and undo the store
+	ret, _, err = evm.Call(AccountRef(wasmCaller), wasmStore, []byte("oops"), 1_000_000, new(big.Int))
+	if !errors.Is(err, ErrExecutionReverted) || string(ret) != "oops" {
+		t.Errorf("have %q, %v, want a revert", ret, err)
+	}
+
+	// programs run out of gas like contracts
+	key = common.HexToHash("0x02")
+	if _, _, err := evm.Call(AccountRef(wasmCaller), wasmStore, append(key[:], value[:]...), 5_000, new(big.Int)); !errors.Is(err, ErrOutOfGas) {
+		t.Errorf("have %v, want out of gas", err)
+	}
+}
+
+func TestWasmExecutorCalls(t *testing.T) {
+	evm, statedb := newWasmTestEVM(t)
+
+	// a program calling a contract
+	ret, _, err := evm.Call(AccountRef(wasmCaller), wasmProxy, wasmAnswer[:], 1_000_000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if new(big.Int).SetBytes(ret).Uint64() != 42 {
+		t.Errorf("proxy returned %x", ret)
+	}
+
+	// a program calling a program
+	key, value := common.HexToHash("0x02"), common.HexToHash("0xbeef")
+	calldata := append(append(wasmStore.Bytes(), key[:]...), value[:]...)
+	ret, _, err = evm.Call(AccountRef(wasmCaller), wasmProxy, calldata, 1_000_000, new(big.Int))
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if !bytes.Equal(ret, value[:]) {
+		t.Errorf("proxy returned %x", ret)
+	}
+	if have := statedb.GetState(wasmStore, key); have != value {
+		t.Errorf("stored %v", have)
+	}
+
+	// static calls can't store
+	statedb.SetCode(wasmProxy, proxyProgram(true))
+	statedb.SetState(wasmStore, key, common.Hash{})
+	if _, _, err := evm.Call(AccountRef(wasmCaller), wasmProxy, calldata, 1_000_000, new(big.Int)); !errors.Is(err, ErrExecutionReverted) {
+		t.Errorf("have %v, want the static call to fail", err)
+	}
+	if have := statedb.GetState(wasmStore, key); have != (common.Hash{}) {
+		t.Errorf("static call stored %v", have)
+	}
+}
+
+func TestWasmExecutorIsPluggable(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(wasmStore, storeProgram())
+	statedb.AddAddressToAccessList(wasmStore)
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: new(big.Int),
+	}
+	executed := errors.New("executed")
+	config := Config{NewWasmExecutor: func(evm *EVM) WasmExecutor { return failingExecutor{executed} }}
+	evm := NewEVM(vmctx, TxContext{}, statedb, params.ArbitrumDevTestChainConfig(), config)
+	if _, _, err := evm.Call(AccountRef(wasmCaller), wasmStore, nil, 100_000, new(big.Int)); !errors.Is(err, executed) {
+		t.Errorf("have %v, want the configured executor to run", err)
+	}
+}
+
+type failingExecutor struct{ err error }
+
+func (e failingExecutor) ExecuteWASM(*ScopeContext, []byte, *EVMInterpreter) ([]byte, error) {
+	return nil, e.err
+}
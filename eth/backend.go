@@ -187,6 +187,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TriesInMemory: 128,
 			TrieRetention: 30 * time.Minute,
 
+			StateCheckpointBlocks:    config.StateCheckpointBlocks,
+			StateCheckpointGas:       config.StateCheckpointGas,
+			StateCheckpointRetention: config.StateCheckpointRetention,
+			StateCheckpointMaxAge:    config.StateCheckpointMaxAge,
+
 			TrieCleanLimit:      config.TrieCleanCache,
 			TrieCleanJournal:    stack.ResolvePath(config.TrieCleanCacheJournal),
 			TrieCleanRejournal:  config.TrieCleanCacheRejournal,
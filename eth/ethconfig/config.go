@@ -132,6 +132,12 @@ type Config struct {
 	SnapshotCache           int
 	Preimages               bool
 
+	// Arbitrum: persisted state checkpoints bounding the recreation of pruned states
+	StateCheckpointBlocks    uint64        `toml:",omitempty"` // Number of blocks after which a state checkpoint is persisted (0 = disabled)
+	StateCheckpointGas       uint64        `toml:",omitempty"` // Amount of L2 gas after which a state checkpoint is persisted (0 = disabled)
+	StateCheckpointRetention uint64        `toml:",omitempty"` // Number of most recent state checkpoints retained (0 = all)
+	StateCheckpointMaxAge    time.Duration `toml:",omitempty"` // Block age after which a state checkpoint is no longer retained (0 = forever)
+
 	// This is the number of blocks for which logs will be cached in the filter system.
 	FilterLogCacheSize int
 
@@ -16,45 +16,49 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64                 `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightIngress            int                    `toml:",omitempty"`
-		LightEgress             int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		LightNoPrune            bool                   `toml:",omitempty"`
-		LightNoSyncServe        bool                   `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      int                    `toml:",omitempty"`
-		UltraLightOnlyAnnounce  bool                   `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		TrieCleanCache          int
-		TrieCleanCacheJournal   string        `toml:",omitempty"`
-		TrieCleanCacheRejournal time.Duration `toml:",omitempty"`
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Preimages               bool
-		FilterLogCacheSize      int
-		Miner                   miner.Config
-		TxPool                  txpool.Config
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		RPCGasCap               uint64
-		RPCEVMTimeout           time.Duration
-		RPCTxFeeCap             float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                uint64
+		SyncMode                 downloader.SyncMode
+		EthDiscoveryURLs         []string
+		SnapDiscoveryURLs        []string
+		NoPruning                bool
+		NoPrefetch               bool
+		TxLookupLimit            uint64                 `toml:",omitempty"`
+		RequiredBlocks           map[uint64]common.Hash `toml:"-"`
+		LightServ                int                    `toml:",omitempty"`
+		LightIngress             int                    `toml:",omitempty"`
+		LightEgress              int                    `toml:",omitempty"`
+		LightPeers               int                    `toml:",omitempty"`
+		LightNoPrune             bool                   `toml:",omitempty"`
+		LightNoSyncServe         bool                   `toml:",omitempty"`
+		UltraLightServers        []string               `toml:",omitempty"`
+		UltraLightFraction       int                    `toml:",omitempty"`
+		UltraLightOnlyAnnounce   bool                   `toml:",omitempty"`
+		SkipBcVersionCheck       bool                   `toml:"-"`
+		DatabaseHandles          int                    `toml:"-"`
+		DatabaseCache            int
+		DatabaseFreezer          string
+		TrieCleanCache           int
+		TrieCleanCacheJournal    string        `toml:",omitempty"`
+		TrieCleanCacheRejournal  time.Duration `toml:",omitempty"`
+		TrieDirtyCache           int
+		TrieTimeout              time.Duration
+		SnapshotCache            int
+		Preimages                bool
+		StateCheckpointBlocks    uint64        `toml:",omitempty"`
+		StateCheckpointGas       uint64        `toml:",omitempty"`
+		StateCheckpointRetention uint64        `toml:",omitempty"`
+		StateCheckpointMaxAge    time.Duration `toml:",omitempty"`
+		FilterLogCacheSize       int
+		Miner                    miner.Config
+		TxPool                   txpool.Config
+		GPO                      gasprice.Config
+		EnablePreimageRecording  bool
+		DocRoot                  string `toml:"-"`
+		RPCGasCap                uint64
+		RPCEVMTimeout            time.Duration
+		RPCTxFeeCap              float64
+		OverrideCancun           *uint64 `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -86,6 +90,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
 	enc.Preimages = c.Preimages
+	enc.StateCheckpointBlocks = c.StateCheckpointBlocks
+	enc.StateCheckpointGas = c.StateCheckpointGas
+	enc.StateCheckpointRetention = c.StateCheckpointRetention
+	enc.StateCheckpointMaxAge = c.StateCheckpointMaxAge
 	enc.FilterLogCacheSize = c.FilterLogCacheSize
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
@@ -102,45 +110,49 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64                `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightIngress            *int                   `toml:",omitempty"`
-		LightEgress             *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		LightNoPrune            *bool                  `toml:",omitempty"`
-		LightNoSyncServe        *bool                  `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      *int                   `toml:",omitempty"`
-		UltraLightOnlyAnnounce  *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		TrieCleanCache          *int
-		TrieCleanCacheJournal   *string        `toml:",omitempty"`
-		TrieCleanCacheRejournal *time.Duration `toml:",omitempty"`
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Preimages               *bool
-		FilterLogCacheSize      *int
-		Miner                   *miner.Config
-		TxPool                  *txpool.Config
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		RPCGasCap               *uint64
-		RPCEVMTimeout           *time.Duration
-		RPCTxFeeCap             *float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                *uint64
+		SyncMode                 *downloader.SyncMode
+		EthDiscoveryURLs         []string
+		SnapDiscoveryURLs        []string
+		NoPruning                *bool
+		NoPrefetch               *bool
+		TxLookupLimit            *uint64                `toml:",omitempty"`
+		RequiredBlocks           map[uint64]common.Hash `toml:"-"`
+		LightServ                *int                   `toml:",omitempty"`
+		LightIngress             *int                   `toml:",omitempty"`
+		LightEgress              *int                   `toml:",omitempty"`
+		LightPeers               *int                   `toml:",omitempty"`
+		LightNoPrune             *bool                  `toml:",omitempty"`
+		LightNoSyncServe         *bool                  `toml:",omitempty"`
+		UltraLightServers        []string               `toml:",omitempty"`
+		UltraLightFraction       *int                   `toml:",omitempty"`
+		UltraLightOnlyAnnounce   *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck       *bool                  `toml:"-"`
+		DatabaseHandles          *int                   `toml:"-"`
+		DatabaseCache            *int
+		DatabaseFreezer          *string
+		TrieCleanCache           *int
+		TrieCleanCacheJournal    *string        `toml:",omitempty"`
+		TrieCleanCacheRejournal  *time.Duration `toml:",omitempty"`
+		TrieDirtyCache           *int
+		TrieTimeout              *time.Duration
+		SnapshotCache            *int
+		Preimages                *bool
+		StateCheckpointBlocks    *uint64        `toml:",omitempty"`
+		StateCheckpointGas       *uint64        `toml:",omitempty"`
+		StateCheckpointRetention *uint64        `toml:",omitempty"`
+		StateCheckpointMaxAge    *time.Duration `toml:",omitempty"`
+		FilterLogCacheSize       *int
+		Miner                    *miner.Config
+		TxPool                   *txpool.Config
+		GPO                      *gasprice.Config
+		EnablePreimageRecording  *bool
+		DocRoot                  *string `toml:"-"`
+		RPCGasCap                *uint64
+		RPCEVMTimeout            *time.Duration
+		RPCTxFeeCap              *float64
+		OverrideCancun           *uint64 `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -233,6 +245,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Preimages != nil {
 		c.Preimages = *dec.Preimages
 	}
+	if dec.StateCheckpointBlocks != nil {
+		c.StateCheckpointBlocks = *dec.StateCheckpointBlocks
+	}
+	if dec.StateCheckpointGas != nil {
+		c.StateCheckpointGas = *dec.StateCheckpointGas
+	}
+	if dec.StateCheckpointRetention != nil {
+		c.StateCheckpointRetention = *dec.StateCheckpointRetention
+	}
+	if dec.StateCheckpointMaxAge != nil {
+		c.StateCheckpointMaxAge = *dec.StateCheckpointMaxAge
+	}
 	if dec.FilterLogCacheSize != nil {
 		c.FilterLogCacheSize = *dec.FilterLogCacheSize
 	}
@@ -0,0 +1,129 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/native"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestBalanceAuditTracer drives the balance audit tracer alongside the state
+// changes it observes, checking the baseline taken before the tx and the
+// residual left by ETH minted without being reported.
+func TestBalanceAuditTracer(t *testing.T) {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	var (
+		env    = vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, params.TestChainConfig, vm.Config{})
+		tracer = native.NewBalanceAuditTracer()
+		a      = common.Address{0xa}
+		b      = common.Address{0xb}
+		c      = common.Address{0xc}
+		d      = common.Address{0xd}
+	)
+	// an earlier tx of the block left a delta the audit must not include
+	statedb.AddBalance(d, big.NewInt(7))
+
+	// ArbOS captures its transfers after applying them
+	statedb.AddBalance(a, big.NewInt(10))
+	tracer.CaptureArbitrumTransfer(env, nil, &a, big.NewInt(10), true, "deposit")
+
+	tracer.CaptureStart(env, a, b, false, nil, 0, big.NewInt(4))
+	statedb.SubBalance(a, big.NewInt(4))
+	statedb.AddBalance(b, big.NewInt(4))
+
+	// the value of a reverted call is dropped
+	tracer.CaptureEnter(vm.CALL, b, c, nil, 0, big.NewInt(1))
+	tracer.CaptureExit(nil, 0, errors.New("execution reverted"))
+
+	// ArbOS mints during execution appear as INVALID frames from the zero address
+	tracer.CaptureEnter(vm.INVALID, common.Address{}, c, nil, 0, big.NewInt(2))
+	statedb.AddBalance(c, big.NewInt(2))
+	tracer.CaptureExit(nil, 0, nil)
+
+	// nothing reports this mint
+	statedb.AddBalance(d, big.NewInt(3))
+	tracer.CaptureEnd(nil, 0, nil)
+
+	audit, err := tracer.Audit()
+	if err != nil {
+		t.Fatalf("failed to audit: %v", err)
+	}
+	purposes := map[string][4]int64{ // count, minted, burned, moved
+		"deposit":  {1, 10, 0, 0},
+		"value":    {1, 0, 0, 4},
+		"internal": {1, 2, 0, 0},
+	}
+	if len(audit.Purposes) != len(purposes) {
+		t.Errorf("have %d purposes, want %d", len(audit.Purposes), len(purposes))
+	}
+	for name, want := range purposes {
+		have := audit.Purposes[name]
+		if have == nil || int64(have.Count) != want[0] || have.Minted.ToInt().Int64() != want[1] || have.Burned.ToInt().Int64() != want[2] || have.Moved.ToInt().Int64() != want[3] {
+			t.Errorf("purpose %s: have %+v, want %v", name, have, want)
+		}
+	}
+	accounts := map[common.Address]map[string]int64{
+		a: {"deposit": 10, "value": -4},
+		b: {"value": 4},
+		c: {"internal": 2},
+	}
+	if len(audit.Accounts) != len(accounts) {
+		t.Errorf("have %d accounts, want %d", len(audit.Accounts), len(accounts))
+	}
+	for addr, deltas := range accounts {
+		for purpose, want := range deltas {
+			if have := audit.Accounts[addr][purpose]; have == nil || have.ToInt().Int64() != want {
+				t.Errorf("account %v: have %s delta %v, want %d", addr, purpose, have, want)
+			}
+		}
+	}
+	for _, delta := range []struct {
+		name string
+		have *hexutil.Big
+		want int64
+	}{
+		{"expected", audit.ExpectedDelta, 12},
+		{"unexpected", audit.UnexpectedDelta, 15},
+		{"residual", audit.Residual, 3},
+	} {
+		if delta.have == nil || delta.have.ToInt().Int64() != delta.want {
+			t.Errorf("have %s delta %v, want %d", delta.name, delta.have, delta.want)
+		}
+	}
+	// the JSON result is the encoded audit
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to get result: %v", err)
+	}
+	if want, _ := json.Marshal(audit); string(res) != string(want) {
+		t.Errorf("have result %s, want %s", res, want)
+	}
+}
@@ -65,17 +65,21 @@ type callTrace struct {
 	BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
 	AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
 
-	From         common.Address  `json:"from"`
-	Gas          *hexutil.Uint64 `json:"gas"`
-	GasUsed      *hexutil.Uint64 `json:"gasUsed"`
-	To           *common.Address `json:"to,omitempty"`
-	Input        hexutil.Bytes   `json:"input"`
-	Output       hexutil.Bytes   `json:"output,omitempty"`
-	Error        string          `json:"error,omitempty"`
-	RevertReason string          `json:"revertReason,omitempty"`
-	Calls        []callTrace     `json:"calls,omitempty"`
-	Logs         []callLog       `json:"logs,omitempty"`
-	Value        *hexutil.Big    `json:"value,omitempty"`
+	BeforeEVMArbosStorage *[]arbosStorageAccess `json:"beforeEVMArbosStorage,omitempty"`
+	AfterEVMArbosStorage  *[]arbosStorageAccess `json:"afterEVMArbosStorage,omitempty"`
+
+	From         common.Address       `json:"from"`
+	Gas          *hexutil.Uint64      `json:"gas"`
+	GasUsed      *hexutil.Uint64      `json:"gasUsed"`
+	To           *common.Address      `json:"to,omitempty"`
+	Input        hexutil.Bytes        `json:"input"`
+	Output       hexutil.Bytes        `json:"output,omitempty"`
+	Error        string               `json:"error,omitempty"`
+	RevertReason string               `json:"revertReason,omitempty"`
+	Calls        []callTrace          `json:"calls,omitempty"`
+	Logs         []callLog            `json:"logs,omitempty"`
+	ArbosStorage []arbosStorageAccess `json:"arbosStorage,omitempty"`
+	Value        *hexutil.Big         `json:"value,omitempty"`
 	// Gencodec adds overridden fields at the end
 	Type string `json:"type"`
 }
@@ -87,6 +91,9 @@ type callTracerTest struct {
 	Input        string          `json:"input"`
 	TracerConfig json.RawMessage `json:"tracerConfig"`
 	Result       *callTrace      `json:"result"`
+
+	// Arbitrum: ArbOS storage accesses around evm execution
+	ArbosStorage *arbosStorageTest `json:"arbosStorage,omitempty"`
 }
 
 // Iterates over all the input-output datasets in the tracer test harness and
@@ -154,7 +161,7 @@ func testCallTracer(tracerName string, dirPath string, t *testing.T) {
 			if err != nil {
 				t.Fatalf("failed to create call tracer: %v", err)
 			}
-			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Tracer: tracer})
+			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Tracer: tracer, NewProcessingHook: test.ArbosStorage.newProcessingHook()})
 			msg, err := core.TransactionToMessage(tx, signer, nil)
 			if err != nil {
 				t.Fatalf("failed to prepare transaction for tracing: %v", err)
@@ -50,6 +50,9 @@ type testcase struct {
 	Input        string          `json:"input"`
 	TracerConfig json.RawMessage `json:"tracerConfig"`
 	Result       interface{}     `json:"result"`
+
+	// Arbitrum: ArbOS storage accesses around evm execution
+	ArbosStorage *arbosStorageTest `json:"arbosStorage,omitempty"`
 }
 
 func TestPrestateTracerLegacy(t *testing.T) {
@@ -114,7 +117,7 @@ func testPrestateDiffTracer(tracerName string, dirPath string, t *testing.T) {
 			if err != nil {
 				t.Fatalf("failed to create call tracer: %v", err)
 			}
-			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Tracer: tracer})
+			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Tracer: tracer, NewProcessingHook: test.ArbosStorage.newProcessingHook()})
 			msg, err := core.TransactionToMessage(tx, signer, nil)
 			if err != nil {
 				t.Fatalf("failed to prepare transaction for tracing: %v", err)
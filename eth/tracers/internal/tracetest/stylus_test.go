@@ -0,0 +1,144 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// stylusTracerTest defines a single test to check the stylus tracer against.
+type stylusTracerTest struct {
+	Genesis      *core.Genesis   `json:"genesis"`
+	Context      *callContext    `json:"context"`
+	Input        string          `json:"input"`
+	TracerConfig json.RawMessage `json:"tracerConfig"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// TestStylusTracer runs the transactions of the stylus tracer test suite on
+// the reference wasm executor and compares the ink profiles they produce.
+func TestStylusTracer(t *testing.T) {
+	files, err := os.ReadDir(filepath.Join("testdata", "stylus_tracer"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(file.Name(), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			test := new(stylusTracerTest)
+			if blob, err := os.ReadFile(filepath.Join("testdata", "stylus_tracer", file.Name())); err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			} else if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			res, err := runStylusTracer(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			have, err := canonicalJSON(res)
+			if err != nil {
+				t.Fatalf("failed to normalize trace result: %v", err)
+			}
+			want, err := canonicalJSON(test.Result)
+			if err != nil {
+				t.Fatalf("failed to normalize expected result: %v", err)
+			}
+			if have != want {
+				t.Fatalf("trace mismatch\n have: %v\n want: %v\n", have, want)
+			}
+		})
+	}
+}
+
+// runStylusTracer executes the transaction of a stylus tracer test and returns
+// the result of the tracer.
+func runStylusTracer(test *stylusTracerTest) (json.RawMessage, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(test.Input)); err != nil {
+		return nil, fmt.Errorf("failed to parse testcase input: %v", err)
+	}
+	var (
+		signer    = types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)), uint64(test.Context.Time))
+		origin, _ = signer.Sender(tx)
+		txContext = vm.TxContext{
+			Origin:   origin,
+			GasPrice: tx.GasPrice(),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    test.Context.Miner,
+			BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+			Time:        uint64(test.Context.Time),
+			Difficulty:  (*big.Int)(test.Context.Difficulty),
+			GasLimit:    uint64(test.Context.GasLimit),
+			BaseFee:     test.Genesis.BaseFee,
+		}
+		_, statedb = tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+	)
+	tracer, err := tracers.DefaultDirectory.New("stylusTracer", new(tracers.Context), test.TracerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stylus tracer: %v", err)
+	}
+	config := vm.Config{
+		Tracer:          tracer,
+		NewWasmExecutor: func(*vm.EVM) vm.WasmExecutor { return vm.NewReferenceWasmExecutor() },
+	}
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, config)
+	msg, err := core.TransactionToMessage(tx, signer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+		return nil, fmt.Errorf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve trace result: %v", err)
+	}
+	return res, nil
+}
+
+// canonicalJSON re-encodes a JSON document so that documents differing only
+// in formatting compare equal.
+func canonicalJSON(blob json.RawMessage) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(blob, &v); err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(v)
+	return string(out), err
+}
@@ -4,6 +4,10 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+
 	// Force-load native and js packages, to trigger registration
 	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
 	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
@@ -70,3 +74,59 @@ func camel(str string) string {
 	}
 	return strings.Join(pieces, "")
 }
+
+// arbosStorageAccess is a read or write of the ArbOS state account's storage
+type arbosStorageAccess struct {
+	Type  string       `json:"type"`
+	Key   common.Hash  `json:"key"`
+	Value *common.Hash `json:"value,omitempty"`
+}
+
+// arbosStorageTest lists the ArbOS storage accesses a test replays before and
+// after the evm executes its transaction.
+type arbosStorageTest struct {
+	BeforeEVM []arbosStorageAccess `json:"beforeEVM"`
+	AfterEVM  []arbosStorageAccess `json:"afterEVM"`
+}
+
+// arbosStorageHook is a processing hook accessing the ArbOS storage around evm
+// execution the way ArbOS does, calling the tracer before each access.
+type arbosStorageHook struct {
+	vm.DefaultTxProcessor
+	evm  *vm.EVM
+	test *arbosStorageTest
+}
+
+// newProcessingHook returns the processing hook replaying the test's ArbOS
+// storage accesses, or nil if it has none.
+func (test *arbosStorageTest) newProcessingHook() func(*vm.EVM) vm.TxProcessingHook {
+	if test == nil {
+		return nil
+	}
+	return func(evm *vm.EVM) vm.TxProcessingHook {
+		return &arbosStorageHook{DefaultTxProcessor: vm.NewDefaultTxProcessor(evm), evm: evm, test: test}
+	}
+}
+
+func (h *arbosStorageHook) StartTxHook() (bool, uint64, error, []byte) {
+	h.access(h.test.BeforeEVM, true)
+	return false, 0, nil, nil
+}
+
+func (h *arbosStorageHook) EndTxHook(totalGasUsed uint64, evmSuccess bool) {
+	h.access(h.test.AfterEVM, false)
+}
+
+func (h *arbosStorageHook) access(accesses []arbosStorageAccess, before bool) {
+	tracer := h.evm.Config.Tracer
+	for _, access := range accesses {
+		switch access.Type {
+		case "get":
+			tracer.CaptureArbitrumStorageGet(access.Key, h.evm.Depth(), before)
+			h.evm.StateDB.GetState(types.ArbosStateAddress, access.Key)
+		case "set":
+			tracer.CaptureArbitrumStorageSet(access.Key, *access.Value, h.evm.Depth(), before)
+			h.evm.StateDB.SetState(types.ArbosStateAddress, access.Key, *access.Value)
+		}
+	}
+}
@@ -53,8 +53,38 @@ func (jst *jsTracer) CaptureArbitrumTransfer(
 	}
 }
 
-func (*jsTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool)        {}
-func (*jsTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {}
+func (jst *jsTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {
+	traceGet, ok := goja.AssertFunction(jst.obj.Get("captureArbitrumStorageGet"))
+	if !ok {
+		return
+	}
+
+	access := jst.vm.NewObject()
+	access.Set("key", key.String())
+	access.Set("depth", depth)
+	access.Set("before", before)
+
+	if _, err := traceGet(access); err != nil {
+		jst.err = wrapError("captureArbitrumStorageGet", err)
+	}
+}
+
+func (jst *jsTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {
+	traceSet, ok := goja.AssertFunction(jst.obj.Get("captureArbitrumStorageSet"))
+	if !ok {
+		return
+	}
+
+	access := jst.vm.NewObject()
+	access.Set("key", key.String())
+	access.Set("value", value.String())
+	access.Set("depth", depth)
+	access.Set("before", before)
+
+	if _, err := traceSet(access); err != nil {
+		jst.err = wrapError("captureArbitrumStorageSet", err)
+	}
+}
 
 func (jst *jsTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
 	hostio, ok := goja.AssertFunction(jst.obj.Get("hostio"))
@@ -48,12 +48,15 @@ func (s Storage) Copy() Storage {
 
 // Config are the configuration options for structured logger the EVM
 type Config struct {
-	EnableMemory     bool // enable memory capture
-	DisableStack     bool // disable stack capture
-	DisableStorage   bool // disable storage capture
-	EnableReturnData bool // enable return data capture
-	Debug            bool // print output during capture end
-	Limit            int  // maximum length of output, but zero means unlimited
+	EnableMemory            bool // enable memory capture
+	DisableStack            bool // disable stack capture
+	DisableStorage          bool // disable storage capture
+	EnableReturnData        bool // enable return data capture
+	DisableHostio           bool // disable stylus hostio capture
+	EnableHostioData        bool // enable capture of stylus hostio args and outs
+	EnableArbitrumTransfers bool // enable capture of ArbOS transfers such as fee payments and refunds
+	Debug                   bool // print output during capture end
+	Limit                   int  // maximum length of output, but zero means unlimited
 	// Chain overrides, can be used to execute a trace using future fork rules
 	Overrides *params.ChainConfig `json:"overrides,omitempty"`
 }
@@ -109,12 +112,14 @@ type StructLogger struct {
 	cfg Config
 	env *vm.EVM
 
-	storage  map[common.Address]Storage
-	logs     []StructLog
-	output   []byte
-	err      error
-	gasLimit uint64
-	usedGas  uint64
+	storage      map[common.Address]Storage
+	logs         []StructLog
+	arbitrumLogs []arbitrumEntry // Arbitrum: stylus hostio calls and ArbOS transfers, positioned among the logs
+	depth        int             // Arbitrum: current call depth, as hostio calls and transfers don't report it
+	output       []byte
+	err          error
+	gasLimit     uint64
+	usedGas      uint64
 
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
 	reason    error       // Textual reason for the interruption
@@ -136,12 +141,15 @@ func (l *StructLogger) Reset() {
 	l.storage = make(map[common.Address]Storage)
 	l.output = make([]byte, 0)
 	l.logs = l.logs[:0]
+	l.arbitrumLogs = l.arbitrumLogs[:0]
+	l.depth = 0
 	l.err = nil
 }
 
 // CaptureStart implements the EVMLogger interface to initialize the tracing operation.
 func (l *StructLogger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
 	l.env = env
+	l.depth = 1
 }
 
 // CaptureState logs a new structured log message and pushes it out to the environment
@@ -221,6 +229,7 @@ func (l *StructLogger) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, s
 func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {
 	l.output = output
 	l.err = err
+	l.depth = 0
 	if l.cfg.Debug {
 		fmt.Printf("%#x\n", output)
 		if err != nil {
@@ -230,9 +239,11 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {
 }
 
 func (l *StructLogger) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	l.depth++
 }
 
 func (l *StructLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	l.depth--
 }
 
 func (l *StructLogger) GetResult() (json.RawMessage, error) {
@@ -251,7 +262,7 @@ func (l *StructLogger) GetResult() (json.RawMessage, error) {
 		Gas:         l.usedGas,
 		Failed:      failed,
 		ReturnValue: returnVal,
-		StructLogs:  formatLogs(l.StructLogs()),
+		StructLogs:  formatLogsWithArbitrum(l.StructLogs(), l.arbitrumLogs),
 	})
 }
 
@@ -421,6 +432,19 @@ type StructLogRes struct {
 	Memory        *[]string          `json:"memory,omitempty"`
 	Storage       *map[string]string `json:"storage,omitempty"`
 	RefundCounter uint64             `json:"refund,omitempty"`
+
+	// Arbitrum: set for stylus hostio entries, whose Op is HostioOpName
+	Hostio   string  `json:"hostio,omitempty"`
+	Args     *string `json:"args,omitempty"`
+	Outs     *string `json:"outs,omitempty"`
+	StartInk uint64  `json:"startInk,omitempty"`
+	EndInk   uint64  `json:"endInk,omitempty"`
+
+	// Arbitrum: set for ArbOS transfer entries, whose Op is TransferOpName
+	Transfer string  `json:"transfer,omitempty"`
+	From     *string `json:"from,omitempty"`
+	To       *string `json:"to,omitempty"`
+	Value    *string `json:"value,omitempty"`
 }
 
 // formatLogs formats EVM returned structured logs for json output
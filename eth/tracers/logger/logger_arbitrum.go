@@ -17,19 +17,87 @@
 package logger
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
 func (*AccessListTracer) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
 }
-func (*JSONLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+
+// TransferOpName is the op reported for ArbOS transfers among the struct logs
+const TransferOpName = "TRANSFER"
+
+// TransferLog is emitted for each balance movement ArbOS makes outside of opcode
+// execution, such as fee payments, tips, gas refunds and self-destruct sweeps.
+// From is nil for mints and To is nil for burns.
+type TransferLog struct {
+	Purpose string          `json:"transfer"`
+	From    *common.Address `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Depth   int             `json:"depth"` // zero outside of evm execution
 }
-func (*StructLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+
+func newTransferLog(from, to *common.Address, value *big.Int, purpose string, depth int) TransferLog {
+	log := TransferLog{
+		Purpose: purpose,
+		Value:   (*hexutil.Big)(new(big.Int)),
+		Depth:   depth,
+	}
+	if from != nil {
+		from := *from
+		log.From = &from
+	}
+	if to != nil {
+		to := *to
+		log.To = &to
+	}
+	if value != nil {
+		log.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	return log
+}
+
+func (l *JSONLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+	if !l.cfg.EnableArbitrumTransfers {
+		return
+	}
+	l.encoder.Encode(newTransferLog(from, to, value, purpose, l.depth))
+}
+
+func (l *StructLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+	if l.interrupt.Load() || !l.cfg.EnableArbitrumTransfers {
+		return
+	}
+	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs)+len(l.arbitrumLogs) {
+		return
+	}
+	transfer := newTransferLog(from, to, value, purpose, l.depth)
+	l.arbitrumLogs = append(l.arbitrumLogs, arbitrumEntry{index: len(l.logs), transfer: &transfer})
+}
+
+// TransferLogs returns the captured ArbOS transfers.
+func (l *StructLogger) TransferLogs() []TransferLog {
+	var logs []TransferLog
+	for _, entry := range l.arbitrumLogs {
+		if entry.transfer != nil {
+			logs = append(logs, *entry.transfer)
+		}
+	}
+	return logs
 }
-func (*mdLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, amount *big.Int, before bool, purpose string) {
+
+func (t *mdLogger) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, amount *big.Int, before bool, purpose string) {
+	if !t.cfg.EnableArbitrumTransfers {
+		return
+	}
+	log := newTransferLog(from, to, amount, purpose, 0)
+	fmt.Fprintf(t.out, "| %5v | %10v  | %v -> %v | %v wei |\n", TransferOpName, purpose,
+		formatTransferParty(log.From), formatTransferParty(log.To), log.Value.ToInt())
 }
 
 func (*AccessListTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {}
@@ -44,6 +112,151 @@ func (*mdLogger) CaptureArbitrumStorageSet(key, value common.Hash, depth int, be
 
 func (*AccessListTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
 }
-func (*JSONLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64)   {}
-func (*StructLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {}
-func (*mdLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64)     {}
+
+// HostioOpName is the op reported for stylus hostio entries among the struct logs
+const HostioOpName = "HOSTIO"
+
+// HostioLog is emitted for each hostio a Stylus program invokes
+type HostioLog struct {
+	Name     string        `json:"hostio"`
+	Args     hexutil.Bytes `json:"args,omitempty"`
+	Outs     hexutil.Bytes `json:"outs,omitempty"`
+	StartInk uint64        `json:"startInk"`
+	EndInk   uint64        `json:"endInk"`
+	Depth    int           `json:"depth"`
+}
+
+// arbitrumEntry positions a hostio or transfer log among the struct logs
+type arbitrumEntry struct {
+	index    int // number of struct logs captured before the entry
+	hostio   *HostioLog
+	transfer *TransferLog
+}
+
+func newHostioLog(cfg *Config, name string, args, outs []byte, startInk, endInk uint64, depth int) HostioLog {
+	log := HostioLog{
+		Name:     name,
+		StartInk: startInk,
+		EndInk:   endInk,
+		Depth:    depth,
+	}
+	if cfg.EnableHostioData {
+		log.Args = common.CopyBytes(args)
+		log.Outs = common.CopyBytes(outs)
+	}
+	return log
+}
+
+func (l *JSONLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	if l.cfg.DisableHostio {
+		return
+	}
+	l.encoder.Encode(newHostioLog(l.cfg, name, args, outs, startInk, endInk, l.depth))
+}
+
+func (l *StructLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	if l.interrupt.Load() || l.cfg.DisableHostio {
+		return
+	}
+	if l.cfg.Limit != 0 && l.cfg.Limit <= len(l.logs)+len(l.arbitrumLogs) {
+		return
+	}
+	hostio := newHostioLog(&l.cfg, name, args, outs, startInk, endInk, l.depth)
+	l.arbitrumLogs = append(l.arbitrumLogs, arbitrumEntry{index: len(l.logs), hostio: &hostio})
+}
+
+// HostioLogs returns the captured stylus hostio entries.
+func (l *StructLogger) HostioLogs() []HostioLog {
+	var logs []HostioLog
+	for _, entry := range l.arbitrumLogs {
+		if entry.hostio != nil {
+			logs = append(logs, *entry.hostio)
+		}
+	}
+	return logs
+}
+
+func (t *mdLogger) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	if t.cfg.DisableHostio {
+		return
+	}
+	fmt.Fprintf(t.out, "| %5v | %10v  | ink %d -> %d |", HostioOpName, name, startInk, endInk)
+	if t.cfg.EnableHostioData {
+		fmt.Fprintf(t.out, " args %#x outs %#x |", args, outs)
+	}
+	fmt.Fprintln(t.out, "")
+}
+
+// formatLogsWithArbitrum formats the struct logs for json output, interleaving
+// the hostio and transfer entries
+func formatLogsWithArbitrum(logs []StructLog, entries []arbitrumEntry) []StructLogRes {
+	if len(entries) == 0 {
+		return formatLogs(logs)
+	}
+	formatted := formatLogs(logs)
+	interleaved := make([]StructLogRes, 0, len(formatted)+len(entries))
+	next := 0
+	for i := range formatted {
+		for next < len(entries) && entries[next].index <= i {
+			interleaved = append(interleaved, entries[next].format())
+			next++
+		}
+		interleaved = append(interleaved, formatted[i])
+	}
+	for ; next < len(entries); next++ {
+		interleaved = append(interleaved, entries[next].format())
+	}
+	return interleaved
+}
+
+func (entry *arbitrumEntry) format() StructLogRes {
+	if entry.transfer != nil {
+		return formatTransfer(entry.transfer)
+	}
+	return formatHostio(entry.hostio)
+}
+
+func formatHostio(log *HostioLog) StructLogRes {
+	res := StructLogRes{
+		Op:       HostioOpName,
+		Depth:    log.Depth,
+		Hostio:   log.Name,
+		StartInk: log.StartInk,
+		EndInk:   log.EndInk,
+	}
+	if log.Args != nil {
+		args := log.Args.String()
+		res.Args = &args
+	}
+	if log.Outs != nil {
+		outs := log.Outs.String()
+		res.Outs = &outs
+	}
+	return res
+}
+
+func formatTransfer(log *TransferLog) StructLogRes {
+	value := log.Value.String()
+	res := StructLogRes{
+		Op:       TransferOpName,
+		Depth:    log.Depth,
+		Transfer: log.Purpose,
+		Value:    &value,
+	}
+	if log.From != nil {
+		from := log.From.Hex()
+		res.From = &from
+	}
+	if log.To != nil {
+		to := log.To.Hex()
+		res.To = &to
+	}
+	return res
+}
+
+func formatTransferParty(addr *common.Address) string {
+	if addr == nil {
+		return "-"
+	}
+	return addr.Hex()
+}
@@ -30,6 +30,7 @@ type JSONLogger struct {
 	encoder *json.Encoder
 	cfg     *Config
 	env     *vm.EVM
+	depth   int // Arbitrum: current call depth, as hostio calls and transfers don't report it
 }
 
 // NewJSONLogger creates a new EVM tracer that prints execution steps as JSON objects
@@ -44,6 +45,7 @@ func NewJSONLogger(cfg *Config, writer io.Writer) *JSONLogger {
 
 func (l *JSONLogger) CaptureStart(env *vm.EVM, from, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
 	l.env = env
+	l.depth = 1
 }
 
 func (l *JSONLogger) CaptureFault(pc uint64, op vm.OpCode, gas uint64, cost uint64, scope *vm.ScopeContext, depth int, err error) {
@@ -89,13 +91,17 @@ func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {
 	if err != nil {
 		errMsg = err.Error()
 	}
+	l.depth = 0
 	l.encoder.Encode(endLog{common.Bytes2Hex(output), math.HexOrDecimal64(gasUsed), errMsg})
 }
 
 func (l *JSONLogger) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	l.depth++
 }
 
-func (l *JSONLogger) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (l *JSONLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	l.depth--
+}
 
 func (l *JSONLogger) CaptureTxStart(gasLimit uint64) {}
 
@@ -17,9 +17,11 @@
 package logger
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -105,3 +107,227 @@ func TestStructLogMarshalingOmitEmpty(t *testing.T) {
 		})
 	}
 }
+
+// captureHostioTrace feeds the tracer a program invoking hostios at depth 1,
+// calling into another at depth 2 in between.
+func captureHostioTrace(tracer vm.EVMLogger) {
+	var (
+		env      = vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Tracer: tracer})
+		contract = vm.NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 100000)
+		scope    = &vm.ScopeContext{Memory: vm.NewMemory(), Stack: &vm.Stack{}, Contract: contract}
+	)
+	tracer.CaptureStart(env, common.Address{}, contract.Address(), false, nil, 0, nil)
+	tracer.CaptureState(0, vm.PUSH1, 100, 3, scope, nil, 1, nil)
+	tracer.CaptureStylusHostio("read_args", []byte{0x01}, []byte{0x02}, 1000, 900)
+	tracer.CaptureEnter(vm.CALL, common.Address{}, common.Address{0x01}, nil, 50, nil)
+	tracer.CaptureState(0, vm.PUSH1, 50, 3, scope, nil, 2, nil)
+	tracer.CaptureStylusHostio("storage_load_bytes32", []byte{0x03}, []byte{0x04}, 800, 700)
+	tracer.CaptureExit(nil, 3, nil)
+	tracer.CaptureStylusHostio("write_result", []byte{0x05}, nil, 600, 500)
+	tracer.CaptureEnd(nil, 0, nil)
+}
+
+// structLogSummary is the op, hostio and depth of a struct log entry
+type structLogSummary struct {
+	Op     string
+	Hostio string
+	Depth  int
+}
+
+func summarizeStructLogs(t *testing.T, tracer *StructLogger) ([]structLogSummary, []StructLogRes) {
+	t.Helper()
+	raw, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to get result: %v", err)
+	}
+	var result ExecutionResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	summary := make([]structLogSummary, 0, len(result.StructLogs))
+	for _, log := range result.StructLogs {
+		summary = append(summary, structLogSummary{log.Op, log.Hostio, log.Depth})
+	}
+	return summary, result.StructLogs
+}
+
+func TestStructLoggerHostio(t *testing.T) {
+	tracer := NewStructLogger(nil)
+	captureHostioTrace(tracer)
+	summary, logs := summarizeStructLogs(t, tracer)
+	want := []structLogSummary{
+		{"PUSH1", "", 1},
+		{HostioOpName, "read_args", 1},
+		{"PUSH1", "", 2},
+		{HostioOpName, "storage_load_bytes32", 2},
+		{HostioOpName, "write_result", 1},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("have struct logs %v, want %v", summary, want)
+	}
+	if logs[1].StartInk != 1000 || logs[1].EndInk != 900 || logs[1].Args != nil || logs[1].Outs != nil {
+		t.Errorf("have hostio %+v, want ink 1000 -> 900 without data", logs[1])
+	}
+	if hostios := tracer.HostioLogs(); len(hostios) != 3 || hostios[2].Name != "write_result" {
+		t.Errorf("have hostio logs %+v, want 3", hostios)
+	}
+
+	// the hostio data is opt-in
+	tracer = NewStructLogger(&Config{EnableHostioData: true})
+	captureHostioTrace(tracer)
+	_, logs = summarizeStructLogs(t, tracer)
+	if logs[3].Args == nil || *logs[3].Args != "0x03" || logs[3].Outs == nil || *logs[3].Outs != "0x04" {
+		t.Errorf("have hostio %+v, want args 0x03 and outs 0x04", logs[3])
+	}
+
+	// and hostios can be left out
+	tracer = NewStructLogger(&Config{DisableHostio: true})
+	captureHostioTrace(tracer)
+	if summary, _ := summarizeStructLogs(t, tracer); len(summary) != 2 || summary[1].Op != "PUSH1" {
+		t.Errorf("have struct logs %v, want the opcodes only", summary)
+	}
+
+	// hostios count toward the limit
+	tracer = NewStructLogger(&Config{Limit: 3})
+	captureHostioTrace(tracer)
+	if summary, _ := summarizeStructLogs(t, tracer); len(summary) != 3 || summary[2].Op != "PUSH1" {
+		t.Errorf("have struct logs %v, want the first 3", summary)
+	}
+}
+
+func TestJSONLoggerHostio(t *testing.T) {
+	var out bytes.Buffer
+	captureHostioTrace(NewJSONLogger(&Config{EnableHostioData: true}, &out))
+
+	var entries []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var entry map[string]interface{}
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("failed to decode entry %d: %v", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+	want := []struct {
+		key, value string
+		depth      float64
+	}{
+		{"opName", "PUSH1", 1},
+		{"hostio", "read_args", 1},
+		{"opName", "PUSH1", 2},
+		{"hostio", "storage_load_bytes32", 2},
+		{"hostio", "write_result", 1},
+	}
+	if len(entries) != len(want)+1 {
+		t.Fatalf("have %d entries, want %d and the end", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i][w.key] != w.value || entries[i]["depth"] != w.depth {
+			t.Errorf("entry %d: have %v, want %s %s at depth %v", i, entries[i], w.key, w.value, w.depth)
+		}
+	}
+	if entries[1]["args"] != "0x01" || entries[1]["outs"] != "0x02" || entries[1]["startInk"] != float64(1000) || entries[1]["endInk"] != float64(900) {
+		t.Errorf("have hostio %v, want its data and ink", entries[1])
+	}
+	if _, ok := entries[4]["outs"]; ok {
+		t.Errorf("have empty outs in %v", entries[4])
+	}
+	if _, ok := entries[5]["output"]; !ok {
+		t.Errorf("have last entry %v, want the end", entries[5])
+	}
+
+	out.Reset()
+	captureHostioTrace(NewJSONLogger(&Config{DisableHostio: true}, &out))
+	if bytes.Contains(out.Bytes(), []byte(`"hostio"`)) {
+		t.Errorf("have hostios despite disabling them: %s", out.String())
+	}
+}
+
+// captureTransferTrace feeds the tracer the transfers ArbOS makes around and
+// during a tx running a stylus program.
+func captureTransferTrace(tracer vm.EVMLogger) {
+	var (
+		env      = vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Tracer: tracer})
+		contract = vm.NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 100000)
+		scope    = &vm.ScopeContext{Memory: vm.NewMemory(), Stack: &vm.Stack{}, Contract: contract}
+		sender   = common.Address{0x01}
+		network  = common.Address{0x02}
+	)
+	tracer.CaptureArbitrumTransfer(env, &sender, nil, big.NewInt(100), true, "feePayment")
+	tracer.CaptureStart(env, sender, contract.Address(), false, nil, 0, nil)
+	tracer.CaptureState(0, vm.PUSH1, 100, 3, scope, nil, 1, nil)
+	tracer.CaptureStylusHostio("read_args", nil, nil, 1000, 900)
+	tracer.CaptureArbitrumTransfer(env, &sender, &network, big.NewInt(10), true, "escrow")
+	tracer.CaptureState(2, vm.PUSH1, 97, 3, scope, nil, 1, nil)
+	tracer.CaptureEnd(nil, 0, nil)
+	tracer.CaptureArbitrumTransfer(env, nil, &sender, big.NewInt(40), false, "gasRefund")
+}
+
+func TestStructLoggerTransfers(t *testing.T) {
+	// transfers are opt-in
+	tracer := NewStructLogger(nil)
+	captureTransferTrace(tracer)
+	if summary, _ := summarizeStructLogs(t, tracer); len(summary) != 3 {
+		t.Errorf("have struct logs %v, want no transfers", summary)
+	}
+
+	tracer = NewStructLogger(&Config{EnableArbitrumTransfers: true})
+	captureTransferTrace(tracer)
+	_, logs := summarizeStructLogs(t, tracer)
+	want := []struct {
+		op, transfer string
+		depth        int
+	}{
+		{TransferOpName, "feePayment", 0},
+		{"PUSH1", "", 1},
+		{HostioOpName, "", 1},
+		{TransferOpName, "escrow", 1},
+		{"PUSH1", "", 1},
+		{TransferOpName, "gasRefund", 0},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("have %d struct logs, want %d", len(logs), len(want))
+	}
+	for i, w := range want {
+		if logs[i].Op != w.op || logs[i].Transfer != w.transfer || logs[i].Depth != w.depth {
+			t.Errorf("log %d: have %s %q at depth %d, want %s %q at depth %d", i, logs[i].Op, logs[i].Transfer, logs[i].Depth, w.op, w.transfer, w.depth)
+		}
+	}
+	// mints have no sender and burns no recipient
+	sender := common.Address{0x01}.Hex()
+	if burn := logs[0]; burn.From == nil || *burn.From != sender || burn.To != nil || burn.Value == nil || *burn.Value != "0x64" {
+		t.Errorf("have burn %+v, want 0x64 from %s", burn, sender)
+	}
+	if mint := logs[5]; mint.From != nil || mint.To == nil || *mint.To != sender || mint.Value == nil || *mint.Value != "0x28" {
+		t.Errorf("have mint %+v, want 0x28 to %s", mint, sender)
+	}
+	if transfers := tracer.TransferLogs(); len(transfers) != 3 || transfers[1].Purpose != "escrow" || transfers[1].To == nil {
+		t.Errorf("have transfer logs %+v, want 3", transfers)
+	}
+}
+
+func TestJSONLoggerTransfers(t *testing.T) {
+	var out bytes.Buffer
+	captureTransferTrace(NewJSONLogger(&Config{EnableArbitrumTransfers: true, DisableHostio: true}, &out))
+
+	var purposes []string
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var entry TransferLog
+		if err := decoder.Decode(&entry); err != nil {
+			t.Fatalf("failed to decode entry: %v", err)
+		}
+		if entry.Purpose != "" {
+			purposes = append(purposes, entry.Purpose)
+		}
+	}
+	if want := []string{"feePayment", "escrow", "gasRefund"}; !reflect.DeepEqual(purposes, want) {
+		t.Errorf("have transfers %v, want %v", purposes, want)
+	}
+
+	out.Reset()
+	captureTransferTrace(NewJSONLogger(nil, &out))
+	if bytes.Contains(out.Bytes(), []byte(`"transfer"`)) {
+		t.Errorf("have transfers without enabling them: %s", out.String())
+	}
+}
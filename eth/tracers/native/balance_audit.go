@@ -0,0 +1,294 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("balanceAuditTracer", newBalanceAuditTracer, false)
+}
+
+const (
+	// auditValuePurpose is reported for value moved by evm calls, creations and self destructs
+	auditValuePurpose = "value"
+	// auditInternalPurpose is reported for transfers ArbOS makes during evm execution,
+	// which tracers observe as INVALID call frames
+	auditInternalPurpose = "internal"
+	// auditSelfDestructPurpose is the transfer purpose of balances burnt when
+	// self-destructed accounts are deleted, which happens after the tx is traced
+	auditSelfDestructPurpose = "selfDestruct"
+)
+
+// unexpectedBalanceDeltaReader is implemented by state databases tracking the
+// total balance minted or burnt since their last commit.
+type unexpectedBalanceDeltaReader interface {
+	GetUnexpectedBalanceDelta() *big.Int
+}
+
+// auditTransfer is a balance movement, where a nil from mints and a nil to burns.
+type auditTransfer struct {
+	purpose  string
+	from, to *common.Address
+	value    *big.Int
+}
+
+// BalanceAuditPurpose aggregates the transfers made for a single purpose.
+type BalanceAuditPurpose struct {
+	Count  hexutil.Uint64 `json:"count"`
+	Minted *hexutil.Big   `json:"minted"`
+	Burned *hexutil.Big   `json:"burned"`
+	Moved  *hexutil.Big   `json:"moved"`
+}
+
+// BalanceAudit is the result of the balanceAuditTracer for a single tx.
+type BalanceAudit struct {
+	Purposes map[string]*BalanceAuditPurpose            `json:"purposes"`
+	Accounts map[common.Address]map[string]*hexutil.Big `json:"accounts"`
+
+	// ExpectedDelta is the net amount minted by the transfers. UnexpectedDelta
+	// is the change of the state's unexpected balance delta over the tx, and
+	// Residual their difference, which is zero unless ETH was minted or burnt
+	// without being accounted for. The latter two are omitted when the state
+	// doesn't track the delta.
+	ExpectedDelta   *hexutil.Big `json:"expectedDelta"`
+	UnexpectedDelta *hexutil.Big `json:"unexpectedDelta,omitempty"`
+	Residual        *hexutil.Big `json:"residual,omitempty"`
+}
+
+// BalanceAuditTracer breaks down the balance changes of a tx by the purpose of
+// the transfers causing them, and checks them against the unexpected balance
+// delta tracked by the state.
+//
+// ArbOS captures its transfers after applying them, so the delta before the tx
+// is the one observed at the first transfer less what that transfer minted.
+type BalanceAuditTracer struct {
+	noopTracer
+	transfers [][]auditTransfer // pending evm transfers per call frame, dropped on revert
+	settled   []auditTransfer   // transfers that can no longer be reverted
+	state     unexpectedBalanceDeltaReader
+	baseline  *big.Int
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newBalanceAuditTracer returns a native go tracer which audits
+// the balance changes of a tx, and implements vm.EVMLogger.
+func newBalanceAuditTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return NewBalanceAuditTracer(), nil
+}
+
+// NewBalanceAuditTracer returns a tracer auditing the balance changes of a
+// single tx, for callers reading the audit without going through JSON.
+func NewBalanceAuditTracer() *BalanceAuditTracer {
+	return &BalanceAuditTracer{}
+}
+
+// observe captures the unexpected balance delta before the tx, given that
+// the state already includes the net amount minted by the current event.
+func (t *BalanceAuditTracer) observe(env *vm.EVM, minted *big.Int) {
+	if t.baseline != nil || env == nil {
+		return
+	}
+	state, ok := env.StateDB.(unexpectedBalanceDeltaReader)
+	if !ok {
+		return
+	}
+	t.state = state
+	t.baseline = new(big.Int).Sub(state.GetUnexpectedBalanceDelta(), minted)
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *BalanceAuditTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.observe(env, common.Big0)
+	t.transfers = [][]auditTransfer{nil}
+	t.push(auditValuePurpose, &from, &to, value)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *BalanceAuditTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.transfers) == 0 {
+		return
+	}
+	if err == nil {
+		t.settled = append(t.settled, t.transfers[0]...)
+	}
+	t.transfers = nil
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *BalanceAuditTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.transfers = append(t.transfers, nil)
+	switch typ {
+	case vm.CALL, vm.CREATE, vm.CREATE2, vm.SELFDESTRUCT:
+		t.push(auditValuePurpose, &from, &to, value)
+	case vm.INVALID:
+		// ArbOS reports mints and burns during evm execution with the zero address
+		var src, dst *common.Address
+		if from != (common.Address{}) {
+			src = &from
+		}
+		if to != (common.Address{}) {
+			dst = &to
+		}
+		t.push(auditInternalPurpose, src, dst, value)
+	}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *BalanceAuditTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.transfers)
+	if size <= 1 {
+		return
+	}
+	frame := t.transfers[size-1]
+	t.transfers = t.transfers[:size-1]
+	if err == nil {
+		t.transfers[size-2] = append(t.transfers[size-2], frame...)
+	}
+}
+
+func (t *BalanceAuditTracer) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.Address, value *big.Int, before bool, purpose string) {
+	if t.interrupt.Load() {
+		return
+	}
+	minted := new(big.Int)
+	if value != nil && purpose != auditSelfDestructPurpose {
+		if from == nil {
+			minted.Add(minted, value)
+		}
+		if to == nil {
+			minted.Sub(minted, value)
+		}
+	}
+	t.observe(env, minted)
+	t.settled = append(t.settled, newAuditTransfer(purpose, from, to, value))
+}
+
+// push records an evm transfer in the innermost call frame.
+func (t *BalanceAuditTracer) push(purpose string, from, to *common.Address, value *big.Int) {
+	if t.interrupt.Load() || value == nil || value.Sign() == 0 || len(t.transfers) == 0 {
+		return
+	}
+	frame := &t.transfers[len(t.transfers)-1]
+	*frame = append(*frame, newAuditTransfer(purpose, from, to, value))
+}
+
+func newAuditTransfer(purpose string, from, to *common.Address, value *big.Int) auditTransfer {
+	transfer := auditTransfer{purpose: purpose, value: new(big.Int)}
+	if from != nil {
+		from := *from
+		transfer.from = &from
+	}
+	if to != nil {
+		to := *to
+		transfer.to = &to
+	}
+	if value != nil {
+		transfer.value.Set(value)
+	}
+	return transfer
+}
+
+// Audit returns the balance audit of the tx, and the reason for any
+// forceful termination (via `Stop`).
+func (t *BalanceAuditTracer) Audit() (*BalanceAudit, error) {
+	var (
+		purposes = make(map[string]*BalanceAuditPurpose)
+		accounts = make(map[common.Address]map[string]*hexutil.Big)
+		expected = new(big.Int)
+	)
+	credit := func(addr common.Address, purpose string, amount *big.Int) {
+		deltas := accounts[addr]
+		if deltas == nil {
+			deltas = make(map[string]*hexutil.Big)
+			accounts[addr] = deltas
+		}
+		delta := deltas[purpose]
+		if delta == nil {
+			delta = new(hexutil.Big)
+			deltas[purpose] = delta
+		}
+		delta.ToInt().Add(delta.ToInt(), amount)
+	}
+	for _, transfer := range t.settled {
+		summary := purposes[transfer.purpose]
+		if summary == nil {
+			summary = &BalanceAuditPurpose{Minted: new(hexutil.Big), Burned: new(hexutil.Big), Moved: new(hexutil.Big)}
+			purposes[transfer.purpose] = summary
+		}
+		summary.Count++
+
+		value := transfer.value
+		switch {
+		case transfer.from == nil && transfer.to == nil:
+		case transfer.from == nil:
+			summary.Minted.ToInt().Add(summary.Minted.ToInt(), value)
+		case transfer.to == nil:
+			summary.Burned.ToInt().Add(summary.Burned.ToInt(), value)
+		default:
+			summary.Moved.ToInt().Add(summary.Moved.ToInt(), value)
+		}
+		if transfer.from != nil {
+			credit(*transfer.from, transfer.purpose, new(big.Int).Neg(value))
+		} else if transfer.purpose != auditSelfDestructPurpose {
+			expected.Add(expected, value)
+		}
+		if transfer.to != nil {
+			credit(*transfer.to, transfer.purpose, value)
+		} else if transfer.purpose != auditSelfDestructPurpose {
+			expected.Sub(expected, value)
+		}
+	}
+	result := &BalanceAudit{
+		Purposes:      purposes,
+		Accounts:      accounts,
+		ExpectedDelta: (*hexutil.Big)(expected),
+	}
+	if t.state != nil {
+		unexpected := new(big.Int).Sub(t.state.GetUnexpectedBalanceDelta(), t.baseline)
+		result.UnexpectedDelta = (*hexutil.Big)(unexpected)
+		result.Residual = (*hexutil.Big)(new(big.Int).Sub(unexpected, expected))
+	}
+	return result, t.reason
+}
+
+// GetResult returns the json-encoded balance audit of the tx, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *BalanceAuditTracer) GetResult() (json.RawMessage, error) {
+	result, reason := t.Audit()
+	res, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *BalanceAuditTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
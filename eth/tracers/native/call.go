@@ -45,18 +45,22 @@ type callFrame struct {
 	// Arbitrum: we add these here due to the tracer returning the top frame
 	BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
 	AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
-
-	Type         vm.OpCode       `json:"-"`
-	From         common.Address  `json:"from"`
-	Gas          uint64          `json:"gas"`
-	GasUsed      uint64          `json:"gasUsed"`
-	To           *common.Address `json:"to,omitempty" rlp:"optional"`
-	Input        []byte          `json:"input" rlp:"optional"`
-	Output       []byte          `json:"output,omitempty" rlp:"optional"`
-	Error        string          `json:"error,omitempty" rlp:"optional"`
-	RevertReason string          `json:"revertReason,omitempty"`
-	Calls        []callFrame     `json:"calls,omitempty" rlp:"optional"`
-	Logs         []callLog       `json:"logs,omitempty" rlp:"optional"`
+	// Arbitrum: ArbOS storage accesses outside of evm execution, if requested
+	BeforeEVMArbosStorage *[]arbosStorageAccess `json:"beforeEVMArbosStorage,omitempty"`
+	AfterEVMArbosStorage  *[]arbosStorageAccess `json:"afterEVMArbosStorage,omitempty"`
+
+	Type         vm.OpCode            `json:"-"`
+	From         common.Address       `json:"from"`
+	Gas          uint64               `json:"gas"`
+	GasUsed      uint64               `json:"gasUsed"`
+	To           *common.Address      `json:"to,omitempty" rlp:"optional"`
+	Input        []byte               `json:"input" rlp:"optional"`
+	Output       []byte               `json:"output,omitempty" rlp:"optional"`
+	Error        string               `json:"error,omitempty" rlp:"optional"`
+	RevertReason string               `json:"revertReason,omitempty"`
+	Calls        []callFrame          `json:"calls,omitempty" rlp:"optional"`
+	Logs         []callLog            `json:"logs,omitempty" rlp:"optional"`
+	ArbosStorage []arbosStorageAccess `json:"arbosStorage,omitempty" rlp:"optional"`
 
 	// Placed at end on purpose. The RLP will be decoded to 0 instead of
 	// nil if there are non-empty elements after in the struct.
@@ -106,6 +110,10 @@ type callTracer struct {
 	// Arbitrum: capture transfers occurring outside of evm execution
 	beforeEVMTransfers []arbitrumTransfer
 	afterEVMTransfers  []arbitrumTransfer
+	// Arbitrum: capture ArbOS storage accesses occurring outside of evm execution
+	beforeEVMArbosStorage []arbosStorageAccess
+	afterEVMArbosStorage  []arbosStorageAccess
+	inEVM                 bool
 
 	noopTracer
 	callstack []callFrame
@@ -118,6 +126,8 @@ type callTracer struct {
 type callTracerConfig struct {
 	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
 	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+
+	WithArbosStorage bool `json:"withArbosStorage"` // If true, call tracer will collect ArbOS storage accesses
 }
 
 // newCallTracer returns a native go tracer which tracks
@@ -153,11 +163,13 @@ func (t *callTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Ad
 	if create {
 		t.callstack[0].Type = vm.CREATE
 	}
+	t.inEVM = true
 }
 
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
 	t.callstack[0].processOutput(output, err)
+	t.inEVM = false
 }
 
 // CaptureState implements the EVMLogger interface to trace a single step of VM execution.
@@ -270,6 +282,10 @@ func (t *callTracer) GetResult() (json.RawMessage, error) {
 	call := t.callstack[0]
 	call.BeforeEVMTransfers = &t.beforeEVMTransfers
 	call.AfterEVMTransfers = &t.afterEVMTransfers
+	if t.config.WithArbosStorage {
+		call.BeforeEVMArbosStorage = &t.beforeEVMArbosStorage
+		call.AfterEVMArbosStorage = &t.afterEVMArbosStorage
+	}
 
 	res, err := json.Marshal(call)
 	if err != nil {
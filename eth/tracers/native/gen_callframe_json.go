@@ -16,25 +16,30 @@ var _ = (*callFrameMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (c callFrame) MarshalJSON() ([]byte, error) {
 	type callFrame0 struct {
-		BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
-		AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
-		Type               vm.OpCode           `json:"-"`
-		From               common.Address      `json:"from"`
-		Gas                hexutil.Uint64      `json:"gas"`
-		GasUsed            hexutil.Uint64      `json:"gasUsed"`
-		To                 *common.Address     `json:"to,omitempty" rlp:"optional"`
-		Input              hexutil.Bytes       `json:"input" rlp:"optional"`
-		Output             hexutil.Bytes       `json:"output,omitempty" rlp:"optional"`
-		Error              string              `json:"error,omitempty" rlp:"optional"`
-		RevertReason       string              `json:"revertReason,omitempty"`
-		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
-		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
-		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
-		TypeString         string              `json:"type"`
+		BeforeEVMTransfers    *[]arbitrumTransfer   `json:"beforeEVMTransfers,omitempty"`
+		AfterEVMTransfers     *[]arbitrumTransfer   `json:"afterEVMTransfers,omitempty"`
+		BeforeEVMArbosStorage *[]arbosStorageAccess `json:"beforeEVMArbosStorage,omitempty"`
+		AfterEVMArbosStorage  *[]arbosStorageAccess `json:"afterEVMArbosStorage,omitempty"`
+		Type                  vm.OpCode             `json:"-"`
+		From                  common.Address        `json:"from"`
+		Gas                   hexutil.Uint64        `json:"gas"`
+		GasUsed               hexutil.Uint64        `json:"gasUsed"`
+		To                    *common.Address       `json:"to,omitempty" rlp:"optional"`
+		Input                 hexutil.Bytes         `json:"input" rlp:"optional"`
+		Output                hexutil.Bytes         `json:"output,omitempty" rlp:"optional"`
+		Error                 string                `json:"error,omitempty" rlp:"optional"`
+		RevertReason          string                `json:"revertReason,omitempty"`
+		Calls                 []callFrame           `json:"calls,omitempty" rlp:"optional"`
+		Logs                  []callLog             `json:"logs,omitempty" rlp:"optional"`
+		ArbosStorage          []arbosStorageAccess  `json:"arbosStorage,omitempty" rlp:"optional"`
+		Value                 *hexutil.Big          `json:"value,omitempty" rlp:"optional"`
+		TypeString            string                `json:"type"`
 	}
 	var enc callFrame0
 	enc.BeforeEVMTransfers = c.BeforeEVMTransfers
 	enc.AfterEVMTransfers = c.AfterEVMTransfers
+	enc.BeforeEVMArbosStorage = c.BeforeEVMArbosStorage
+	enc.AfterEVMArbosStorage = c.AfterEVMArbosStorage
 	enc.Type = c.Type
 	enc.From = c.From
 	enc.Gas = hexutil.Uint64(c.Gas)
@@ -46,6 +51,7 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 	enc.RevertReason = c.RevertReason
 	enc.Calls = c.Calls
 	enc.Logs = c.Logs
+	enc.ArbosStorage = c.ArbosStorage
 	enc.Value = (*hexutil.Big)(c.Value)
 	enc.TypeString = c.TypeString()
 	return json.Marshal(&enc)
@@ -54,20 +60,23 @@ func (c callFrame) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (c *callFrame) UnmarshalJSON(input []byte) error {
 	type callFrame0 struct {
-		BeforeEVMTransfers *[]arbitrumTransfer `json:"beforeEVMTransfers,omitempty"`
-		AfterEVMTransfers  *[]arbitrumTransfer `json:"afterEVMTransfers,omitempty"`
-		Type               *vm.OpCode          `json:"-"`
-		From               *common.Address     `json:"from"`
-		Gas                *hexutil.Uint64     `json:"gas"`
-		GasUsed            *hexutil.Uint64     `json:"gasUsed"`
-		To                 *common.Address     `json:"to,omitempty" rlp:"optional"`
-		Input              *hexutil.Bytes      `json:"input" rlp:"optional"`
-		Output             *hexutil.Bytes      `json:"output,omitempty" rlp:"optional"`
-		Error              *string             `json:"error,omitempty" rlp:"optional"`
-		RevertReason       *string             `json:"revertReason,omitempty"`
-		Calls              []callFrame         `json:"calls,omitempty" rlp:"optional"`
-		Logs               []callLog           `json:"logs,omitempty" rlp:"optional"`
-		Value              *hexutil.Big        `json:"value,omitempty" rlp:"optional"`
+		BeforeEVMTransfers    *[]arbitrumTransfer   `json:"beforeEVMTransfers,omitempty"`
+		AfterEVMTransfers     *[]arbitrumTransfer   `json:"afterEVMTransfers,omitempty"`
+		BeforeEVMArbosStorage *[]arbosStorageAccess `json:"beforeEVMArbosStorage,omitempty"`
+		AfterEVMArbosStorage  *[]arbosStorageAccess `json:"afterEVMArbosStorage,omitempty"`
+		Type                  *vm.OpCode            `json:"-"`
+		From                  *common.Address       `json:"from"`
+		Gas                   *hexutil.Uint64       `json:"gas"`
+		GasUsed               *hexutil.Uint64       `json:"gasUsed"`
+		To                    *common.Address       `json:"to,omitempty" rlp:"optional"`
+		Input                 *hexutil.Bytes        `json:"input" rlp:"optional"`
+		Output                *hexutil.Bytes        `json:"output,omitempty" rlp:"optional"`
+		Error                 *string               `json:"error,omitempty" rlp:"optional"`
+		RevertReason          *string               `json:"revertReason,omitempty"`
+		Calls                 []callFrame           `json:"calls,omitempty" rlp:"optional"`
+		Logs                  []callLog             `json:"logs,omitempty" rlp:"optional"`
+		ArbosStorage          []arbosStorageAccess  `json:"arbosStorage,omitempty" rlp:"optional"`
+		Value                 *hexutil.Big          `json:"value,omitempty" rlp:"optional"`
 	}
 	var dec callFrame0
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -79,6 +88,12 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.AfterEVMTransfers != nil {
 		c.AfterEVMTransfers = dec.AfterEVMTransfers
 	}
+	if dec.BeforeEVMArbosStorage != nil {
+		c.BeforeEVMArbosStorage = dec.BeforeEVMArbosStorage
+	}
+	if dec.AfterEVMArbosStorage != nil {
+		c.AfterEVMArbosStorage = dec.AfterEVMArbosStorage
+	}
 	if dec.Type != nil {
 		c.Type = *dec.Type
 	}
@@ -112,6 +127,9 @@ func (c *callFrame) UnmarshalJSON(input []byte) error {
 	if dec.Logs != nil {
 		c.Logs = dec.Logs
 	}
+	if dec.ArbosStorage != nil {
+		c.ArbosStorage = dec.ArbosStorage
+	}
 	if dec.Value != nil {
 		c.Value = (*big.Int)(dec.Value)
 	}
@@ -113,6 +113,14 @@ func (t *muxTracer) CaptureTxEnd(restGas uint64) {
 	}
 }
 
+func (t *muxTracer) CaptureArbitrumTxEnv(env *vm.EVM) {
+	for _, t := range t.tracers {
+		if t, ok := t.(vm.ArbitrumTxEnvLogger); ok {
+			t.CaptureArbitrumTxEnv(env)
+		}
+	}
+}
+
 func (t *muxTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {
 	for _, t := range t.tracers {
 		t.CaptureArbitrumStorageGet(key, depth, before)
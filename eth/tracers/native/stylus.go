@@ -0,0 +1,226 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stylusTracer", newStylusTracer, false)
+}
+
+// hostioProfile aggregates the invocations of a single hostio within a call frame.
+type hostioProfile struct {
+	Count hexutil.Uint64 `json:"count"`
+	Ink   hexutil.Uint64 `json:"ink"`
+}
+
+// hostioCall is a single hostio invocation, reported when the ordered trace is requested.
+type hostioCall struct {
+	Name     string         `json:"name"`
+	Args     hexutil.Bytes  `json:"args,omitempty"`
+	Outs     hexutil.Bytes  `json:"outs,omitempty"`
+	StartInk hexutil.Uint64 `json:"startInk"`
+	EndInk   hexutil.Uint64 `json:"endInk"`
+}
+
+// stylusFrame is the ink profile of a single call frame. Frames that don't
+// execute a Stylus program carry no hostio information but are kept so that
+// the nesting mirrors the callTracer output.
+type stylusFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Error   string          `json:"error,omitempty"`
+
+	// HostioSpanInk is the ink spent from the start of the frame's first hostio
+	// to the end of its last, covering the program's execution and calls in
+	// between but not what it ran before the first or after the last. HostioInk
+	// only counts the ink spent inside hostios.
+	HostioSpanInk hexutil.Uint64            `json:"hostioSpanInk,omitempty"`
+	HostioInk     hexutil.Uint64            `json:"hostioInk,omitempty"`
+	Hostios       map[string]*hostioProfile `json:"hostios,omitempty"`
+	Trace         []hostioCall              `json:"trace,omitempty"`
+	Calls         []stylusFrame             `json:"calls,omitempty"`
+
+	startInk uint64 // ink left when the first hostio started
+	endInk   uint64 // ink left when the last hostio returned
+	stylus   bool   // whether a hostio was captured in this frame
+}
+
+func (f *stylusFrame) processOutput(gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	if err != nil {
+		f.Error = err.Error()
+	}
+	if f.stylus && f.startInk > f.endInk {
+		f.HostioSpanInk = hexutil.Uint64(f.startInk - f.endInk)
+	}
+}
+
+type stylusTracer struct {
+	noopTracer
+	callstack []stylusFrame
+	config    stylusTracerConfig
+	gasLimit  uint64
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+type stylusTracerConfig struct {
+	WithTrace bool `json:"withTrace"` // If true, stylus tracer will report the ordered hostio calls of each frame
+	WithData  bool `json:"withData"`  // If true, the ordered hostio calls include their arguments and outputs
+}
+
+// newStylusTracer returns a native go tracer which profiles the ink
+// spent on each hostio per call frame, and implements vm.EVMLogger.
+func newStylusTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config stylusTracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &stylusTracer{
+		callstack: make([]stylusFrame, 1),
+		config:    config,
+	}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *stylusTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	toCopy := to
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.callstack[0] = stylusFrame{
+		Type: typ.String(),
+		From: from,
+		To:   &toCopy,
+		Gas:  hexutil.Uint64(t.gasLimit),
+	}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *stylusTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.callstack[0].processOutput(gasUsed, err)
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *stylusTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	toCopy := to
+	t.callstack = append(t.callstack, stylusFrame{
+		Type: typ.String(),
+		From: from,
+		To:   &toCopy,
+		Gas:  hexutil.Uint64(gas),
+	})
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *stylusTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	call := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+	size -= 1
+
+	call.processOutput(gasUsed, err)
+	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
+}
+
+// CaptureStylusHostio attributes a hostio to the innermost call frame.
+func (t *stylusTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64) {
+	if t.interrupt.Load() {
+		return
+	}
+	frame := &t.callstack[len(t.callstack)-1]
+	if !frame.stylus {
+		frame.stylus = true
+		frame.startInk = startInk
+		frame.Hostios = make(map[string]*hostioProfile)
+	}
+	frame.endInk = endInk
+
+	var ink uint64
+	if startInk > endInk {
+		ink = startInk - endInk
+	}
+	profile := frame.Hostios[name]
+	if profile == nil {
+		profile = new(hostioProfile)
+		frame.Hostios[name] = profile
+	}
+	profile.Count++
+	profile.Ink += hexutil.Uint64(ink)
+	frame.HostioInk += hexutil.Uint64(ink)
+
+	if t.config.WithTrace {
+		call := hostioCall{
+			Name:     name,
+			StartInk: hexutil.Uint64(startInk),
+			EndInk:   hexutil.Uint64(endInk),
+		}
+		if t.config.WithData {
+			call.Args = common.CopyBytes(args)
+			call.Outs = common.CopyBytes(outs)
+		}
+		frame.Trace = append(frame.Trace, call)
+	}
+}
+
+func (t *stylusTracer) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+}
+
+func (t *stylusTracer) CaptureTxEnd(restGas uint64) {
+	t.callstack[0].GasUsed = hexutil.Uint64(t.gasLimit - restGas)
+}
+
+// GetResult returns the json-encoded ink profile of the call frames, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *stylusTracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *stylusTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 )
 
@@ -78,16 +79,68 @@ func (t *flatCallTracer) CaptureArbitrumTransfer(env *vm.EVM, from, to *common.A
 	}
 }
 
-func (*callTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool)     {}
+// arbosStorageAccess is a read or write of the ArbOS state account's storage
+type arbosStorageAccess struct {
+	Type  string       `json:"type"`
+	Key   common.Hash  `json:"key"`
+	Value *common.Hash `json:"value,omitempty" rlp:"optional"`
+}
+
+func (t *callTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {
+	t.captureArbosStorage(arbosStorageAccess{Type: "get", Key: key}, before)
+}
+
+func (t *callTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {
+	t.captureArbosStorage(arbosStorageAccess{Type: "set", Key: key, Value: &value}, before)
+}
+
+// captureArbosStorage attributes an ArbOS storage access to the innermost call
+// frame, or to the top-level call when it happens outside of evm execution.
+func (t *callTracer) captureArbosStorage(access arbosStorageAccess, before bool) {
+	if !t.config.WithArbosStorage || t.interrupt.Load() {
+		return
+	}
+	switch {
+	case before:
+		t.beforeEVMArbosStorage = append(t.beforeEVMArbosStorage, access)
+	case !t.inEVM:
+		t.afterEVMArbosStorage = append(t.afterEVMArbosStorage, access)
+	default:
+		frame := &t.callstack[len(t.callstack)-1]
+		frame.ArbosStorage = append(frame.ArbosStorage, access)
+	}
+}
+
+func (t *prestateTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {
+	t.lookupArbosStorage(key)
+}
+
+func (t *prestateTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {
+	t.lookupArbosStorage(key)
+}
+
+// CaptureArbitrumTxEnv implements vm.ArbitrumTxEnvLogger, reaching the state
+// before ArbOS accesses its storage ahead of the EVM.
+func (t *prestateTracer) CaptureArbitrumTxEnv(env *vm.EVM) {
+	t.env = env
+}
+
+// lookupArbosStorage adds an ArbOS storage slot to the prestate. The storage
+// hooks fire before ArbOS writes the slot, so the state holds its prior value.
+func (t *prestateTracer) lookupArbosStorage(key common.Hash) {
+	if t.interrupt.Load() || t.env == nil {
+		return
+	}
+	t.lookupAccount(types.ArbosStateAddress)
+	t.lookupStorage(types.ArbosStateAddress, key)
+}
+
 func (*fourByteTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {}
 func (*noopTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool)     {}
-func (*prestateTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {}
 func (*flatCallTracer) CaptureArbitrumStorageGet(key common.Hash, depth int, before bool) {}
 
-func (*callTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool)     {}
 func (*fourByteTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {}
 func (*noopTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool)     {}
-func (*prestateTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {}
 func (*flatCallTracer) CaptureArbitrumStorageSet(key, value common.Hash, depth int, before bool) {}
 
 func (*callTracer) CaptureStylusHostio(name string, args, outs []byte, startInk, endInk uint64)     {}
@@ -0,0 +1,272 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package arbclient provides an RPC client for the Arbitrum extensions of the Ethereum APIs.
+package arbclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client extends ethclient.Client with the Arbitrum fields and methods of the node APIs.
+type Client struct {
+	*ethclient.Client
+	c rpc.ClientInterface
+}
+
+// Dial connects a client to the given URL.
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+// DialContext connects a client to the given URL with context.
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return New(c), nil
+}
+
+// New creates a client that uses the given RPC client.
+func New(c rpc.ClientInterface) *Client {
+	return &Client{ethclient.NewClient(c), c}
+}
+
+// SendTransactionConditional injects a signed transaction into the pending pool for
+// execution, which the sequencer only includes while the options hold.
+func (ec *Client) SendTransactionConditional(ctx context.Context, tx *types.Transaction, options *arbitrum_types.ConditionalOptions) error {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return ec.c.CallContext(ctx, nil, "eth_sendRawTransactionConditional", hexutil.Encode(data), options)
+}
+
+// rpcHeader is a header along with the Arbitrum fields of the node
+type rpcHeader struct {
+	header        *types.Header
+	l1BlockNumber *hexutil.Uint64
+}
+
+func (h *rpcHeader) UnmarshalJSON(msg []byte) error {
+	if err := json.Unmarshal(msg, &h.header); err != nil {
+		return err
+	}
+	var fields struct {
+		L1BlockNumber *hexutil.Uint64 `json:"l1BlockNumber"`
+	}
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		return err
+	}
+	h.l1BlockNumber = fields.L1BlockNumber
+	return nil
+}
+
+// info decodes the Arbitrum information of the header. Classic blocks don't encode
+// their L1 block number in the header, which the node reports separately instead.
+func (h *rpcHeader) info() types.HeaderInfo {
+	info := types.DeserializeHeaderExtraInformation(h.header)
+	if info.L1BlockNumber == 0 && h.l1BlockNumber != nil {
+		info.L1BlockNumber = uint64(*h.l1BlockNumber)
+	}
+	return info
+}
+
+// HeaderInfo returns a block header from the current canonical chain along with its
+// Arbitrum information: the L1 block number, the send root and the send count. If
+// number is nil, the latest known header is returned.
+func (ec *Client) HeaderInfo(ctx context.Context, number *big.Int) (*types.Header, types.HeaderInfo, error) {
+	return ec.headerInfo(ctx, "eth_getBlockByNumber", toBlockNumArg(number), false)
+}
+
+// HeaderInfoByHash returns the block header with the given hash along with its Arbitrum information.
+func (ec *Client) HeaderInfoByHash(ctx context.Context, hash common.Hash) (*types.Header, types.HeaderInfo, error) {
+	return ec.headerInfo(ctx, "eth_getBlockByHash", hash, false)
+}
+
+func (ec *Client) headerInfo(ctx context.Context, method string, args ...interface{}) (*types.Header, types.HeaderInfo, error) {
+	var head *rpcHeader
+	err := ec.c.CallContext(ctx, &head, method, args...)
+	if err == nil && (head == nil || head.header == nil) {
+		err = ethereum.NotFound
+	}
+	if err != nil {
+		return nil, types.HeaderInfo{}, err
+	}
+	return head.header, head.info(), nil
+}
+
+// Receipt is a transaction receipt along with the Arbitrum fields of the node.
+// The gas used for L1 is part of the embedded receipt.
+type Receipt struct {
+	*types.Receipt
+	L1BlockNumber uint64 // the L1 block number of the block including the transaction
+}
+
+// ArbTransactionReceipt returns the receipt of a transaction by transaction hash
+// along with its Arbitrum fields. Note that the receipt is not available for
+// pending transactions.
+func (ec *Client) ArbTransactionReceipt(ctx context.Context, txHash common.Hash) (*Receipt, error) {
+	var raw json.RawMessage
+	if err := ec.c.CallContext(ctx, &raw, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+	var receipt types.Receipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, err
+	}
+	var fields struct {
+		L1BlockNumber *hexutil.Uint64 `json:"l1BlockNumber"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	result := &Receipt{Receipt: &receipt}
+	if fields.L1BlockNumber != nil {
+		result.L1BlockNumber = uint64(*fields.L1BlockNumber)
+	}
+	return result, nil
+}
+
+// GasUsedForL1 returns the part of the gas used paying for posting the transaction to L1
+func (r *Receipt) GasUsedForL1() uint64 {
+	return r.Receipt.GasUsedForL1
+}
+
+// Transaction is a transaction along with the fields the node reports about it
+type Transaction struct {
+	*types.Transaction
+	BlockNumber *big.Int     // nil while the transaction is pending
+	BlockHash   *common.Hash // nil while the transaction is pending
+	From        common.Address
+	Retryable   *RetryableInfo // nil unless the transaction submits or redeems a retryable
+}
+
+// RetryableInfo holds the retryable fields of a transaction. Which are set depends on the
+// type of the transaction: submissions have a request id, redemptions a ticket id.
+type RetryableInfo struct {
+	RequestId           *common.Hash
+	TicketId            *common.Hash
+	MaxRefund           *big.Int
+	SubmissionFeeRefund *big.Int
+	RefundTo            *common.Address
+	L1BaseFee           *big.Int
+	DepositValue        *big.Int
+	RetryTo             *common.Address
+	RetryValue          *big.Int
+	RetryData           []byte
+	Beneficiary         *common.Address
+	MaxSubmissionFee    *big.Int
+}
+
+type rpcTransactionFields struct {
+	BlockNumber *hexutil.Big    `json:"blockNumber"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+	From        *common.Address `json:"from"`
+
+	RequestId           *common.Hash    `json:"requestId"`
+	TicketId            *common.Hash    `json:"ticketId"`
+	MaxRefund           *hexutil.Big    `json:"maxRefund"`
+	SubmissionFeeRefund *hexutil.Big    `json:"submissionFeeRefund"`
+	RefundTo            *common.Address `json:"refundTo"`
+	L1BaseFee           *hexutil.Big    `json:"l1BaseFee"`
+	DepositValue        *hexutil.Big    `json:"depositValue"`
+	RetryTo             *common.Address `json:"retryTo"`
+	RetryValue          *hexutil.Big    `json:"retryValue"`
+	RetryData           *hexutil.Bytes  `json:"retryData"`
+	Beneficiary         *common.Address `json:"beneficiary"`
+	MaxSubmissionFee    *hexutil.Big    `json:"maxSubmissionFee"`
+}
+
+// ArbTransactionByHash returns the transaction with the given hash along with its
+// block, sender and retryable fields.
+func (ec *Client) ArbTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	var raw json.RawMessage
+	if err := ec.c.CallContext(ctx, &raw, "eth_getTransactionByHash", hash); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+	tx := new(types.Transaction)
+	if err := json.Unmarshal(raw, tx); err != nil {
+		return nil, err
+	}
+	var fields rpcTransactionFields
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if fields.From == nil {
+		return nil, errors.New("server returned transaction without sender")
+	}
+	result := &Transaction{
+		Transaction: tx,
+		BlockNumber: (*big.Int)(fields.BlockNumber),
+		BlockHash:   fields.BlockHash,
+		From:        *fields.From,
+	}
+	switch tx.Type() {
+	case types.ArbitrumRetryTxType, types.ArbitrumSubmitRetryableTxType:
+		result.Retryable = &RetryableInfo{
+			RequestId:           fields.RequestId,
+			TicketId:            fields.TicketId,
+			MaxRefund:           (*big.Int)(fields.MaxRefund),
+			SubmissionFeeRefund: (*big.Int)(fields.SubmissionFeeRefund),
+			RefundTo:            fields.RefundTo,
+			L1BaseFee:           (*big.Int)(fields.L1BaseFee),
+			DepositValue:        (*big.Int)(fields.DepositValue),
+			RetryTo:             fields.RetryTo,
+			RetryValue:          (*big.Int)(fields.RetryValue),
+			Beneficiary:         fields.Beneficiary,
+			MaxSubmissionFee:    (*big.Int)(fields.MaxSubmissionFee),
+		}
+		if fields.RetryData != nil {
+			result.Retryable.RetryData = *fields.RetryData
+		}
+	}
+	return result, nil
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	if number.Sign() >= 0 {
+		return hexutil.EncodeBig(number)
+	}
+	// It's negative.
+	if number.IsInt64() {
+		return rpc.BlockNumber(number.Int64()).String()
+	}
+	// It's negative and large, which is invalid.
+	return fmt.Sprintf("<invalid %d>", number)
+}
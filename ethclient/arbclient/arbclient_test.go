@@ -0,0 +1,212 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package arbclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/arbitrum_types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testHeaderInfo = types.HeaderInfo{
+		SendRoot:           common.HexToHash("0x5e4d"),
+		SendCount:          7,
+		L1BlockNumber:      1234,
+		ArbOSFormatVersion: 20,
+	}
+	testRetryable = &types.ArbitrumSubmitRetryableTx{
+		ChainId:          big.NewInt(412346),
+		RequestId:        common.HexToHash("0x01"),
+		From:             common.HexToAddress("0xf0"),
+		L1BaseFee:        big.NewInt(30),
+		DepositValue:     big.NewInt(1000),
+		GasFeeCap:        big.NewInt(100),
+		Gas:              50000,
+		RetryTo:          &common.Address{0x70},
+		RetryValue:       big.NewInt(5),
+		Beneficiary:      common.HexToAddress("0xbe"),
+		MaxSubmissionFee: big.NewInt(40),
+		FeeRefundAddr:    common.HexToAddress("0xfe"),
+		RetryData:        []byte{1, 2, 3},
+	}
+)
+
+// testService serves the Arbitrum fields as a node would
+type testService struct {
+	sent    hexutil.Bytes
+	options *arbitrum_types.ConditionalOptions
+}
+
+func merge(value interface{}, fields map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]interface{}
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return nil, err
+	}
+	for key, field := range fields {
+		merged[key] = field
+	}
+	return merged, nil
+}
+
+func (s *testService) GetBlockByNumber(number rpc.BlockNumber, full bool) (map[string]interface{}, error) {
+	if number > 10 {
+		return nil, nil
+	}
+	header := &types.Header{
+		Number:     big.NewInt(int64(number)),
+		Difficulty: common.Big1,
+		BaseFee:    big.NewInt(100000000),
+	}
+	testHeaderInfo.UpdateHeaderWithInfo(header)
+	return merge(header, map[string]interface{}{
+		"l1BlockNumber": hexutil.Uint64(testHeaderInfo.L1BlockNumber),
+		"sendRoot":      testHeaderInfo.SendRoot,
+		"sendCount":     hexutil.Uint64(testHeaderInfo.SendCount),
+	})
+}
+
+func (s *testService) GetTransactionReceipt(hash common.Hash) (map[string]interface{}, error) {
+	receipt := &types.Receipt{
+		Status:       types.ReceiptStatusSuccessful,
+		TxHash:       hash,
+		GasUsed:      30000,
+		GasUsedForL1: 9000,
+		BlockNumber:  big.NewInt(1),
+		Logs:         []*types.Log{},
+	}
+	return merge(receipt, map[string]interface{}{"l1BlockNumber": hexutil.Uint64(testHeaderInfo.L1BlockNumber)})
+}
+
+func (s *testService) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	return merge(types.NewTx(testRetryable), map[string]interface{}{
+		"blockHash":   common.HexToHash("0xb1"),
+		"blockNumber": (*hexutil.Big)(big.NewInt(1)),
+	})
+}
+
+func (s *testService) SendRawTransactionConditional(input hexutil.Bytes, options *arbitrum_types.ConditionalOptions) (common.Hash, error) {
+	s.sent, s.options = input, options
+	return crypto.Keccak256Hash(input), nil
+}
+
+func newTestClient(t *testing.T) (*Client, *testService) {
+	service := &testService{}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	client := New(rpc.DialInProc(server))
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return client, service
+}
+
+func TestHeaderInfo(t *testing.T) {
+	client, _ := newTestClient(t)
+	header, info, err := client.HeaderInfo(context.Background(), big.NewInt(3))
+	if err != nil {
+		t.Fatalf("failed to get header info: %v", err)
+	}
+	if header.Number.Uint64() != 3 {
+		t.Errorf("got header %d", header.Number)
+	}
+	if info != testHeaderInfo {
+		t.Errorf("have info %+v, want %+v", info, testHeaderInfo)
+	}
+	if _, _, err := client.HeaderInfo(context.Background(), big.NewInt(11)); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("missing header: have %v", err)
+	}
+}
+
+func TestArbTransactionReceipt(t *testing.T) {
+	client, _ := newTestClient(t)
+	receipt, err := client.ArbTransactionReceipt(context.Background(), common.HexToHash("0x01"))
+	if err != nil {
+		t.Fatalf("failed to get receipt: %v", err)
+	}
+	if receipt.L1BlockNumber != testHeaderInfo.L1BlockNumber {
+		t.Errorf("have l1 block %d", receipt.L1BlockNumber)
+	}
+	if receipt.GasUsedForL1() != 9000 || receipt.GasUsedForL2() != 21000 {
+		t.Errorf("have gas used for l1 %d and l2 %d", receipt.GasUsedForL1(), receipt.GasUsedForL2())
+	}
+}
+
+func TestArbTransactionByHash(t *testing.T) {
+	client, _ := newTestClient(t)
+	tx, err := client.ArbTransactionByHash(context.Background(), common.HexToHash("0x01"))
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if tx.Type() != types.ArbitrumSubmitRetryableTxType || tx.From != testRetryable.From || tx.BlockNumber.Uint64() != 1 {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	info := tx.Retryable
+	if info == nil {
+		t.Fatal("missing retryable fields")
+	}
+	if *info.RequestId != testRetryable.RequestId || *info.RefundTo != testRetryable.FeeRefundAddr || *info.RetryTo != *testRetryable.RetryTo {
+		t.Errorf("unexpected retryable fields %+v", info)
+	}
+	if info.MaxSubmissionFee.Cmp(testRetryable.MaxSubmissionFee) != 0 || info.DepositValue.Cmp(testRetryable.DepositValue) != 0 {
+		t.Errorf("unexpected retryable fees %+v", info)
+	}
+	if !bytes.Equal(info.RetryData, testRetryable.RetryData) {
+		t.Errorf("have retry data %x", info.RetryData)
+	}
+}
+
+func TestSendTransactionConditional(t *testing.T) {
+	client, service := newTestClient(t)
+	key, _ := crypto.GenerateKey()
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(big.NewInt(412346)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(412346),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(1),
+		GasTipCap: big.NewInt(0),
+	})
+	minBlock := hexutil.Uint64(5)
+	options := &arbitrum_types.ConditionalOptions{BlockNumberMin: &minBlock}
+	if err := client.SendTransactionConditional(context.Background(), tx, options); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	encoded, _ := tx.MarshalBinary()
+	if !bytes.Equal(service.sent, encoded) {
+		t.Errorf("sent %x, want %x", service.sent, encoded)
+	}
+	if service.options == nil || service.options.BlockNumberMin == nil || *service.options.BlockNumberMin != minBlock {
+		t.Errorf("sent options %+v", service.options)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func (t *Transaction) GasUsedForL1(ctx context.Context) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(receipt.GasUsedForL1)
+	return &ret, nil
+}
+
+// resolveInner returns the type specific data of the transaction
+func (t *Transaction) resolveInner(ctx context.Context) (types.TxData, error) {
+	tx, _, err := t.resolve(ctx)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	return tx.GetInner(), nil
+}
+
+func (t *Transaction) RequestId(ctx context.Context) (*common.Hash, error) {
+	inner, err := t.resolveInner(ctx)
+	switch inner := inner.(type) {
+	case *types.ArbitrumDepositTx:
+		return &inner.L1RequestId, err
+	case *types.ArbitrumContractTx:
+		return &inner.RequestId, err
+	case *types.ArbitrumSubmitRetryableTx:
+		return &inner.RequestId, err
+	}
+	return nil, err
+}
+
+func (t *Transaction) TicketId(ctx context.Context) (*common.Hash, error) {
+	inner, err := t.resolveInner(ctx)
+	if inner, ok := inner.(*types.ArbitrumRetryTx); ok {
+		return &inner.TicketId, err
+	}
+	return nil, err
+}
+
+func (t *Transaction) RefundTo(ctx context.Context) (*common.Address, error) {
+	inner, err := t.resolveInner(ctx)
+	switch inner := inner.(type) {
+	case *types.ArbitrumRetryTx:
+		return &inner.RefundTo, err
+	case *types.ArbitrumSubmitRetryableTx:
+		return &inner.FeeRefundAddr, err
+	}
+	return nil, err
+}
+
+func (t *Transaction) MaxRefund(ctx context.Context) (*hexutil.Big, error) {
+	inner, err := t.resolveInner(ctx)
+	if inner, ok := inner.(*types.ArbitrumRetryTx); ok {
+		return (*hexutil.Big)(inner.MaxRefund), err
+	}
+	return nil, err
+}
+
+func (t *Transaction) SubmissionFeeRefund(ctx context.Context) (*hexutil.Big, error) {
+	inner, err := t.resolveInner(ctx)
+	if inner, ok := inner.(*types.ArbitrumRetryTx); ok {
+		return (*hexutil.Big)(inner.SubmissionFeeRefund), err
+	}
+	return nil, err
+}
+
+// resolveSubmitRetryable returns the transaction if it submits a retryable
+func (t *Transaction) resolveSubmitRetryable(ctx context.Context) (*types.ArbitrumSubmitRetryableTx, error) {
+	inner, err := t.resolveInner(ctx)
+	submit, _ := inner.(*types.ArbitrumSubmitRetryableTx)
+	return submit, err
+}
+
+func (t *Transaction) L1BaseFee(ctx context.Context) (*hexutil.Big, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(submit.L1BaseFee), nil
+}
+
+func (t *Transaction) DepositValue(ctx context.Context) (*hexutil.Big, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(submit.DepositValue), nil
+}
+
+func (t *Transaction) RetryTo(ctx context.Context) (*common.Address, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return submit.RetryTo, nil
+}
+
+func (t *Transaction) RetryValue(ctx context.Context) (*hexutil.Big, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(submit.RetryValue), nil
+}
+
+func (t *Transaction) RetryData(ctx context.Context) (*hexutil.Bytes, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return (*hexutil.Bytes)(&submit.RetryData), nil
+}
+
+func (t *Transaction) Beneficiary(ctx context.Context) (*common.Address, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return &submit.Beneficiary, nil
+}
+
+func (t *Transaction) MaxSubmissionFee(ctx context.Context) (*hexutil.Big, error) {
+	submit, err := t.resolveSubmitRetryable(ctx)
+	if submit == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(submit.MaxSubmissionFee), nil
+}
+
+func (b *Block) RetryableTransactions(ctx context.Context, args struct{ TicketId *common.Hash }) (*[]*Transaction, error) {
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	ret := make([]*Transaction, 0)
+	for i, tx := range block.Transactions() {
+		ticketId, ok := tx.RetryableTicketId()
+		if !ok || (args.TicketId != nil && *args.TicketId != ticketId) {
+			continue
+		}
+		ret = append(ret, &Transaction{
+			r:     b.r,
+			hash:  tx.Hash(),
+			tx:    tx,
+			block: b,
+			index: uint64(i),
+		})
+	}
+	return &ret, nil
+}
+
+// resolveHeaderInfo returns the Arbitrum information of the block's header, which
+// is nil for blocks from before Nitro.
+func (b *Block) resolveHeaderInfo(ctx context.Context) (*types.HeaderInfo, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	if !b.r.backend.ChainConfig().IsArbitrumNitro(header.Number) {
+		return nil, nil
+	}
+	info := types.DeserializeHeaderExtraInformation(header)
+	return &info, nil
+}
+
+func (b *Block) L1BlockNumber(ctx context.Context) (*hexutil.Uint64, error) {
+	info, err := b.resolveHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(info.L1BlockNumber)
+	return &ret, nil
+}
+
+func (b *Block) SendRoot(ctx context.Context) (*common.Hash, error) {
+	info, err := b.resolveHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return &info.SendRoot, nil
+}
+
+func (b *Block) SendCount(ctx context.Context) (*hexutil.Uint64, error) {
+	info, err := b.resolveHeaderInfo(ctx)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	ret := hexutil.Uint64(info.SendCount)
+	return &ret, nil
+}
@@ -36,8 +36,10 @@ import (
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -404,3 +406,128 @@ func newGQLService(t *testing.T, stack *node.Node, gspec *core.Genesis, genBlock
 	}
 	return handler, chain
 }
+
+// arbitrumTestBackend serves the chain config of an Arbitrum chain
+type arbitrumTestBackend struct {
+	ethapi.Backend
+}
+
+func (b arbitrumTestBackend) ChainConfig() *params.ChainConfig {
+	return params.ArbitrumDevTestChainConfig()
+}
+
+func TestGraphQLArbitrumFields(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		chainId = params.ArbitrumDevTestChainConfig().ChainID
+		retryTo = common.HexToAddress("0x70")
+		info    = types.HeaderInfo{SendRoot: common.HexToHash("0x5e4d"), SendCount: 7, L1BlockNumber: 1234}
+	)
+	submit := types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:          chainId,
+		RequestId:        common.HexToHash("0x01"),
+		From:             common.HexToAddress("0xf0"),
+		L1BaseFee:        big.NewInt(30),
+		DepositValue:     big.NewInt(1000),
+		GasFeeCap:        big.NewInt(100),
+		Gas:              50000,
+		RetryTo:          &retryTo,
+		RetryValue:       big.NewInt(5),
+		Beneficiary:      common.HexToAddress("0xbe"),
+		MaxSubmissionFee: big.NewInt(40),
+		FeeRefundAddr:    common.HexToAddress("0xfe"),
+		RetryData:        []byte{1, 2, 3},
+	})
+	retry := func(ticketId common.Hash) *types.Transaction {
+		return types.NewTx(&types.ArbitrumRetryTx{
+			ChainId:             chainId,
+			From:                common.HexToAddress("0xf0"),
+			GasFeeCap:           big.NewInt(100),
+			Gas:                 50000,
+			To:                  &retryTo,
+			Value:               big.NewInt(5),
+			TicketId:            ticketId,
+			RefundTo:            common.HexToAddress("0xfe"),
+			MaxRefund:           big.NewInt(60),
+			SubmissionFeeRefund: big.NewInt(40),
+		})
+	}
+	deposit := types.NewTx(&types.ArbitrumDepositTx{
+		ChainId:     chainId,
+		L1RequestId: common.HexToHash("0x02"),
+		To:          common.HexToAddress("0xde"),
+		Value:       big.NewInt(1),
+	})
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: common.Big1,
+		BaseFee:    big.NewInt(100000000),
+	}
+	info.UpdateHeaderWithInfo(header)
+	txs := types.Transactions{deposit, submit, retry(submit.Hash()), retry(common.HexToHash("0x03"))}
+	block := types.NewBlockWithHeader(header).WithBody(txs, nil)
+	numberOrHash := rpc.BlockNumberOrHashWithHash(block.Hash(), false)
+	b := &Block{
+		r:            &Resolver{backend: arbitrumTestBackend{}},
+		numberOrHash: &numberOrHash,
+		hash:         block.Hash(),
+		header:       header,
+		block:        block,
+	}
+
+	// the header information
+	if number, err := b.L1BlockNumber(ctx); err != nil || number == nil || uint64(*number) != info.L1BlockNumber {
+		t.Errorf("have l1 block number %v, %v", number, err)
+	}
+	if root, err := b.SendRoot(ctx); err != nil || root == nil || *root != info.SendRoot {
+		t.Errorf("have send root %v, %v", root, err)
+	}
+	if count, err := b.SendCount(ctx); err != nil || count == nil || uint64(*count) != info.SendCount {
+		t.Errorf("have send count %v, %v", count, err)
+	}
+
+	// the retryable filter
+	all, err := b.RetryableTransactions(ctx, struct{ TicketId *common.Hash }{})
+	if err != nil || len(*all) != 3 {
+		t.Fatalf("have %d retryable transactions, %v", len(*all), err)
+	}
+	ticketId := submit.Hash()
+	ticket, err := b.RetryableTransactions(ctx, struct{ TicketId *common.Hash }{&ticketId})
+	if err != nil || len(*ticket) != 2 {
+		t.Fatalf("have %d transactions of the ticket, %v", len(*ticket), err)
+	}
+	submitted, redeemed := (*ticket)[0], (*ticket)[1]
+	if index, _ := redeemed.Index(ctx); index == nil || *index != 2 {
+		t.Errorf("have retry index %v", index)
+	}
+
+	// the transaction fields
+	if requestId, _ := submitted.RequestId(ctx); requestId == nil || *requestId != common.HexToHash("0x01") {
+		t.Errorf("have request id %v", requestId)
+	}
+	if to, _ := submitted.RetryTo(ctx); to == nil || *to != retryTo {
+		t.Errorf("have retry to %v", to)
+	}
+	if fee, _ := submitted.MaxSubmissionFee(ctx); fee == nil || fee.ToInt().Int64() != 40 {
+		t.Errorf("have max submission fee %v", fee)
+	}
+	if refundTo, _ := submitted.RefundTo(ctx); refundTo == nil || *refundTo != common.HexToAddress("0xfe") {
+		t.Errorf("have refund to %v", refundTo)
+	}
+	if ticketId, _ := submitted.TicketId(ctx); ticketId != nil {
+		t.Errorf("submission has ticket id %v", ticketId)
+	}
+	if id, _ := redeemed.TicketId(ctx); id == nil || *id != ticketId {
+		t.Errorf("have ticket id %v", id)
+	}
+	if refund, _ := redeemed.MaxRefund(ctx); refund == nil || refund.ToInt().Int64() != 60 {
+		t.Errorf("have max refund %v", refund)
+	}
+	if data, _ := redeemed.RetryData(ctx); data != nil {
+		t.Errorf("retry has retry data %v", data)
+	}
+	depositTx := &Transaction{r: b.r, hash: deposit.Hash(), tx: deposit, block: b}
+	if requestId, _ := depositTx.RequestId(ctx); requestId == nil || *requestId != common.HexToHash("0x02") {
+		t.Errorf("have deposit request id %v", requestId)
+	}
+}
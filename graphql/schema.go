@@ -122,6 +122,9 @@ const schema string = `
         # this transaction. If the transaction has not yet been mined, this field
         # will be null.
         cumulativeGasUsed: Long
+        # GasUsedForL1 is the part of gasUsed paying for posting the transaction
+        # to L1. If the transaction has not yet been mined, this field will be null.
+        gasUsedForL1: Long
         # EffectiveGasPrice is actual value per gas deducted from the sender's
         # account. Before EIP-1559, this is equal to the transaction's gas price.
         # After EIP-1559, it is baseFeePerGas + min(maxFeePerGas - baseFeePerGas,
@@ -149,6 +152,41 @@ const schema string = `
         # RawReceipt is the canonical encoding of the receipt. For post EIP-2718 typed transactions
         # this is equivalent to TxType || ReceiptEncoding.
         rawReceipt: Bytes!
+
+        # Arbitrum transaction support. Each field is null unless the type of the
+        # transaction has it.
+        # RequestId is the id of the L1 request creating a deposit, contract or
+        # retryable submission transaction.
+        requestId: Bytes32
+        # TicketId is the id of the retryable ticket redeemed by a retry
+        # transaction, which is the hash of the transaction submitting it.
+        ticketId: Bytes32
+        # RefundTo is the account refunded the unused fees of a retry or
+        # retryable submission transaction.
+        refundTo: Address
+        # MaxRefund is the maximum refund sent to refundTo by a retry transaction,
+        # the rest going to the sender.
+        maxRefund: BigInt
+        # SubmissionFeeRefund is the submission fee a successful retry
+        # transaction refunds, capped by maxRefund.
+        submissionFeeRefund: BigInt
+        # L1BaseFee is the L1 base fee a retryable was submitted at.
+        l1BaseFee: BigInt
+        # DepositValue is the value deposited by a retryable submission.
+        depositValue: BigInt
+        # RetryTo is the account a retryable calls. This is null for retryables
+        # creating a contract.
+        retryTo: Address
+        # RetryValue is the value a retryable calls with, in wei.
+        retryValue: BigInt
+        # RetryData is the data a retryable calls with.
+        retryData: Bytes
+        # Beneficiary is the account which can cancel a retryable, and receives
+        # its call value if it expires.
+        beneficiary: Address
+        # MaxSubmissionFee is the maximum fee a retryable submission pays for
+        # storing the retryable.
+        maxSubmissionFee: BigInt
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -244,6 +282,20 @@ const schema string = `
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
+        # RetryableTransactions is the list of the transactions of this block
+        # submitting or redeeming retryables, optionally restricted to the ticket
+        # with the given id. If transactions are unavailable for this block, this
+        # field will be null.
+        retryableTransactions(ticketId: Bytes32): [Transaction!]
+        # L1BlockNumber is the L1 block number of this block. This is null for
+        # blocks from before Nitro.
+        l1BlockNumber: Long
+        # SendRoot is the root of the outbox merkle tree after this block. This is
+        # null for blocks from before Nitro.
+        sendRoot: Bytes32
+        # SendCount is the number of outbox messages after this block. This is
+        # null for blocks from before Nitro.
+        sendCount: Long
         # RawHeader is the RLP encoding of the block's header.
         rawHeader: Bytes!
         # Raw is the RLP encoding of the block.
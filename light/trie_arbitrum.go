@@ -20,16 +20,20 @@ import (
 	"errors"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 )
 
-func (db *odrDatabase) ActivateWasm(moduleHash common.Hash, asm, module []byte) error {
+func (db *odrDatabase) ActivateWasm(moduleHash common.Hash, asmMap map[rawdb.WasmTarget][]byte, module []byte) error {
 	return errors.New("setting compiled wasm not supported in light client")
 }
 
-func (db *odrDatabase) ActivatedAsm(moduleHash common.Hash) ([]byte, error) {
+func (db *odrDatabase) ActivatedAsm(target rawdb.WasmTarget, moduleHash common.Hash) ([]byte, error) {
 	return nil, errors.New("retreiving compiled wasm not supported in light client")
 }
 
+func (db *odrDatabase) SetWasmRecompiler(recompiler state.WasmRecompiler, persist bool) {}
+
 func (db *odrDatabase) ActivatedModule(moduleHash common.Hash) ([]byte, error) {
 	return nil, errors.New("retreiving compiled wasm not supported in light client")
 }
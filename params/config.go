@@ -608,7 +608,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
-	return nil
+	return c.checkStylusGasSchedules()
 }
 
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64) *ConfigCompatError {
@@ -826,6 +826,8 @@ type Rules struct {
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai, IsCancun, IsPrague                 bool
+
+	StylusGasSchedule *StylusGasSchedule
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -851,5 +853,7 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64, curren
 		IsShanghai:       c.IsShanghai(num, timestamp, currentArbosVersion),
 		IsCancun:         c.IsCancun(num, timestamp),
 		IsPrague:         c.IsPrague(num, timestamp),
+
+		StylusGasSchedule: c.StylusGasSchedule(currentArbosVersion),
 	}
 }
@@ -29,6 +29,9 @@ type ArbitrumChainParams struct {
 	InitialArbOSVersion       uint64
 	InitialChainOwner         common.Address
 	GenesisBlockNum           uint64
+
+	// StylusGasSchedules overrides the Stylus pricing from the ArbOS versions they're keyed by
+	StylusGasSchedules map[uint64]*StylusGasSchedule `json:"StylusGasSchedules,omitempty"`
 }
 
 func (c *ChainConfig) IsArbitrum() bool {
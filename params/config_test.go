@@ -140,3 +140,35 @@ func TestConfigRules(t *testing.T) {
 		t.Errorf("expected %v to be shanghai", currentArbosVersion)
 	}
 }
+
+func TestCheckStylusGasSchedulesCompatible(t *testing.T) {
+	repriced := StylusGasScheduleMerge
+	repriced.ColdSloadCost *= 2
+	withSchedules := func(schedules map[uint64]*StylusGasSchedule) *ChainConfig {
+		config := ArbitrumDevTestChainConfig()
+		config.ArbitrumChainParams.StylusGasSchedules = schedules
+		return config
+	}
+	stored := withSchedules(map[uint64]*StylusGasSchedule{20: &repriced})
+	for _, test := range []struct {
+		schedules    map[uint64]*StylusGasSchedule
+		arbosVersion uint64
+		wantErr      bool
+	}{
+		{schedules: map[uint64]*StylusGasSchedule{20: &repriced}, arbosVersion: 30},
+		// schedules of versions not reached yet may change
+		{schedules: map[uint64]*StylusGasSchedule{30: &repriced}, arbosVersion: 19},
+		{schedules: nil, arbosVersion: 19},
+		{schedules: map[uint64]*StylusGasSchedule{20: &repriced, 31: &StylusGasScheduleMerge}, arbosVersion: 30},
+		// reached versions must keep their pricing
+		{schedules: nil, arbosVersion: 20, wantErr: true},
+		{schedules: map[uint64]*StylusGasSchedule{21: &repriced}, arbosVersion: 20, wantErr: true},
+		{schedules: map[uint64]*StylusGasSchedule{20: &repriced, 25: &StylusGasScheduleMerge}, arbosVersion: 30, wantErr: true},
+		{schedules: map[uint64]*StylusGasSchedule{10: &repriced}, arbosVersion: 15, wantErr: true},
+	} {
+		err := stored.CheckStylusGasSchedulesCompatible(withSchedules(test.schedules), test.arbosVersion)
+		if (err != nil) != test.wantErr {
+			t.Errorf("schedules %v at ArbOS version %d: have error %v, want error %v", test.schedules, test.arbosVersion, err, test.wantErr)
+		}
+	}
+}
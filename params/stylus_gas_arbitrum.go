@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// StylusGasSchedule holds the prices of the state accesses made by Stylus programs
+type StylusGasSchedule struct {
+	ColdSloadCost              uint64 // EIP-2929 COLD_SLOAD_COST
+	WarmStorageReadCost        uint64 // EIP-2929 WARM_STORAGE_READ_COST
+	ColdAccountAccessCost      uint64 // EIP-2929 COLD_ACCOUNT_ACCESS_COST
+	SstoreSetGas               uint64 // EIP-2200 SSTORE_SET_GAS
+	SstoreResetGas             uint64 // EIP-2200 SSTORE_RESET_GAS, before EIP-2929 deducts COLD_SLOAD_COST
+	SstoreClearsScheduleRefund uint64 // refund for clearing a slot
+	CallNewAccountGas          uint64 // paid when a value transfer creates an account
+	CallValueTransferGas       uint64 // paid for a non-zero value transfer
+	CodeLoadGas                uint64 // paid on top of the account access when loading its code
+}
+
+// StylusGasScheduleMerge is the pricing as of The Merge, which Stylus launched with
+var StylusGasScheduleMerge = StylusGasSchedule{
+	ColdSloadCost:              ColdSloadCostEIP2929,
+	WarmStorageReadCost:        WarmStorageReadCostEIP2929,
+	ColdAccountAccessCost:      ColdAccountAccessCostEIP2929,
+	SstoreSetGas:               SstoreSetGasEIP2200,
+	SstoreResetGas:             SstoreResetGasEIP2200,
+	SstoreClearsScheduleRefund: SstoreClearsScheduleRefundEIP3529,
+	CallNewAccountGas:          CallNewAccountGas,
+	CallValueTransferGas:       CallValueTransferGas,
+	CodeLoadGas:                MaxCodeSize / 24576 * ExtcodeSizeGasEIP150,
+}
+
+// stylusGasSchedules are the built-in schedules keyed by the ArbOS version activating them.
+// A repricing adds an entry here rather than changing an existing one.
+var stylusGasSchedules = map[uint64]*StylusGasSchedule{
+	0: &StylusGasScheduleMerge,
+}
+
+// StylusGasScheduleVersions returns the ArbOS versions activating the built-in schedules, in order
+func StylusGasScheduleVersions() []uint64 {
+	versions := make([]uint64, 0, len(stylusGasSchedules))
+	for version := range stylusGasSchedules {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// Validate checks the schedule can't underflow the differences the costs are charged in
+func (s *StylusGasSchedule) Validate() error {
+	if s.ColdSloadCost < s.WarmStorageReadCost {
+		return errors.New("cold sload cost below the warm storage read cost")
+	}
+	if s.ColdAccountAccessCost < s.WarmStorageReadCost {
+		return errors.New("cold account access cost below the warm storage read cost")
+	}
+	if s.SstoreSetGas < s.WarmStorageReadCost {
+		return errors.New("sstore set gas below the warm storage read cost")
+	}
+	if s.SstoreResetGas < s.ColdSloadCost+s.WarmStorageReadCost {
+		return errors.New("sstore reset gas below the cold sload and warm storage read costs")
+	}
+	return nil
+}
+
+// StylusGasSchedule returns the schedule in effect at the ArbOS version, which is the one
+// activated by the latest version not past it. Schedules configured in the chain params
+// take precedence over the built-in ones activated by the same version.
+//
+// Configured schedules are meant for devnets experimenting with pricing, and changing
+// them for versions already reached makes the node diverge from its own history,
+// which CheckStylusGasSchedulesCompatible rejects.
+func (c *ChainConfig) StylusGasSchedule(arbosVersion uint64) *StylusGasSchedule {
+	var schedule *StylusGasSchedule
+	var activation uint64
+	for _, schedules := range []map[uint64]*StylusGasSchedule{stylusGasSchedules, c.ArbitrumChainParams.StylusGasSchedules} {
+		for version, candidate := range schedules {
+			if candidate != nil && version <= arbosVersion && (schedule == nil || version >= activation) {
+				schedule = candidate
+				activation = version
+			}
+		}
+	}
+	return schedule
+}
+
+func (c *ChainConfig) checkStylusGasSchedules() error {
+	versions := make([]uint64, 0, len(c.ArbitrumChainParams.StylusGasSchedules))
+	for version := range c.ArbitrumChainParams.StylusGasSchedules {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	for _, version := range versions {
+		schedule := c.ArbitrumChainParams.StylusGasSchedules[version]
+		if schedule == nil {
+			return fmt.Errorf("missing stylus gas schedule for ArbOS version %d", version)
+		}
+		if err := schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid stylus gas schedule for ArbOS version %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// CheckStylusGasSchedulesCompatible checks the new config prices Stylus the same as this
+// one at every ArbOS version up to the one the chain has reached. Unlike forks, the
+// versions aren't tied to known blocks, so no rewind can correct a mismatch.
+func (c *ChainConfig) CheckStylusGasSchedulesCompatible(newcfg *ChainConfig, arbosVersion uint64) error {
+	versions := map[uint64]struct{}{arbosVersion: {}}
+	for _, cfg := range []*ChainConfig{c, newcfg} {
+		for version := range cfg.ArbitrumChainParams.StylusGasSchedules {
+			if version <= arbosVersion {
+				versions[version] = struct{}{}
+			}
+		}
+	}
+	for version := range versions {
+		stored, schedule := c.StylusGasSchedule(version), newcfg.StylusGasSchedule(version)
+		if (stored == nil) != (schedule == nil) || (stored != nil && *stored != *schedule) {
+			return fmt.Errorf("incompatible stylus gas schedule for reached ArbOS version %d (head at version %d)", version, arbosVersion)
+		}
+	}
+	return nil
+}